@@ -30,6 +30,7 @@ CREATE TABLE IF NOT EXISTS packages (
     install_scope       VARCHAR(32)   NOT NULL DEFAULT 'any',  -- any|local-only
     variables           JSON,                             -- token expansion (Tier 1 packages)
     options             JSON,                             -- install-time options
+    sha256              VARCHAR(64),                      -- content-addressable integrity hash
 
     created_at          TIMESTAMP     DEFAULT CURRENT_TIMESTAMP,
     updated_at          TIMESTAMP     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -39,7 +40,7 @@ CREATE TABLE IF NOT EXISTS packages (
 
 -- ---------------------------------------------------------------------------
 -- package_files: one row per file in a package
---   - content stored as LONGTEXT (all skill files are text)
+--   - content stored as LONGTEXT (binary assets are base64-encoded)
 --   - markdown files get YAML frontmatter extracted to JSON + fm_* columns
 -- ---------------------------------------------------------------------------
 CREATE TABLE IF NOT EXISTS package_files (
@@ -52,7 +53,7 @@ CREATE TABLE IF NOT EXISTS package_files (
 
     -- Classification
     file_type       VARCHAR(32)   NOT NULL DEFAULT 'skill',   -- skill|agent|command|script|hook|config
-    content_type    VARCHAR(32)   NOT NULL DEFAULT 'markdown', -- markdown|python|json|yaml|text
+    content_type    VARCHAR(32)   NOT NULL DEFAULT 'markdown', -- markdown|python|json|yaml|text|binary
     is_template     BOOLEAN       NOT NULL DEFAULT FALSE,
 
     -- Extracted frontmatter (denormalized for fast SQL filtering)
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// clientFactory opens the catalog client for a command, with ctx bounding
+// the connection. Commands receive it from NewRootCmd so tests can
+// substitute a dolt.MockClient.
+type clientFactory func(ctx context.Context, cfg *config.Config) (dolt.Client, error)
+
+// openClient connects to the local Dolt SQL server with default settings,
+// logging through the default logger tagged component=dolt; --verbose
+// also logs every SQL statement. With --dolt-dir it instead starts an
+// embedded dolt sql-server on that directory, which is stopped when the
+// client is closed.
+func openClient(ctx context.Context, cfg *config.Config) (dolt.Client, error) {
+	dcfg := dolt.DefaultConfig()
+	dcfg.Logger = slog.Default().With("component", "dolt")
+	dcfg.LogQueries = cfg.Verbose
+	var (
+		c   *dolt.SQLClient
+		err error
+	)
+	if dir := cfg.DoltDirExpanded(); dir != "" {
+		c, err = dolt.OpenEmbedded(ctx, &dolt.EmbeddedServer{Dir: dir}, dcfg)
+	} else {
+		c, err = dolt.OpenContext(ctx, dcfg)
+	}
+	if err != nil {
+		return nil, ioError(fmt.Errorf("connecting to dolt: %w", err))
+	}
+	return c, nil
+}
+
+// commandEnv loads the global configuration and builds a Formatter writing to
+// the command's output streams.
+func commandEnv(cmd *cobra.Command) (*config.Config, *output.Formatter, error) {
+	cfg, err := config.NewConfigFromFlags(cmd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading config flags: %w", err)
+	}
+	f := output.NewFormatter(cfg.JSON, cfg.Quiet)
+	f.NDJSON = cfg.NDJSON
+	f.Fields = cfg.Fields
+	f.NoColor = cfg.NoColor
+	f.Verbose = cfg.Verbose
+	f.Writer = cmd.OutOrStdout()
+	f.ErrW = cmd.ErrOrStderr()
+	if cfg.NoTruncate {
+		f.MaxWidth = -1
+	}
+	return cfg, f, nil
+}
+
+// connectClient opens the catalog with newClient, showing a spinner on a
+// terminal while the connection is made. ctx is the command's context, so
+// --timeout and cancellation also bound connecting.
+func connectClient(ctx context.Context, f *output.Formatter, newClient clientFactory, cfg *config.Config) (dolt.Client, error) {
+	spin := f.Spinner()
+	spin.Start("Connecting to dolt...")
+	defer spin.Stop()
+	return newClient(ctx, cfg)
+}
+
+// commandContext returns the command's context bounded by cfg.Timeout. A
+// zero timeout leaves it unbounded. The caller must call cancel.
+func commandContext(cmd *cobra.Command, cfg *config.Config) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, cfg.Timeout)
+}
+
+// longCommandContext is commandContext for commands whose run time grows
+// with the catalog or the network, such as sync and verify. The default
+// --timeout would cut them off, so they run unbounded unless --timeout is
+// given explicitly.
+func longCommandContext(cmd *cobra.Command, cfg *config.Config) (context.Context, context.CancelFunc) {
+	if cmd.Flags().Changed("timeout") {
+		return commandContext(cmd, cfg)
+	}
+	unbounded := *cfg
+	unbounded.Timeout = 0
+	return commandContext(cmd, &unbounded)
+}
+
+// maxSuggestions caps the package IDs offered by suggestPackages.
+const maxSuggestions = 3
+
+// suggestPackages appends a "Did you mean" list of the package IDs closest
+// to id when err is a dolt.ErrNotFound, like git does for mistyped
+// commands. The candidates come from ListPackages on opts.Branch; if they
+// cannot be listed, or none is close, err is returned unchanged. The result
+// still wraps err, so it keeps its exit code.
+func suggestPackages(ctx context.Context, client dolt.Client, id string, opts dolt.ListOptions, err error) error {
+	if !errors.Is(err, dolt.ErrNotFound) {
+		return err
+	}
+	pkgs, listErr := client.ListPackages(ctx, dolt.ListOptions{Branch: opts.Branch})
+	if listErr != nil {
+		return err
+	}
+	ids := make([]string, len(pkgs))
+	for i, p := range pkgs {
+		ids[i] = p.ID
+	}
+	names := models.ClosestNames(id, ids, maxSuggestions)
+	if len(names) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w. Did you mean: %s?", err, strings.Join(names, ", "))
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+)
+
+func TestClientFactoryGetsCommandContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantDeadline bool
+	}{
+		{name: "default timeout", args: []string{"list"}, wantDeadline: true},
+		{name: "timeout disabled", args: []string{"list", "--timeout", "0"}, wantDeadline: false},
+		{name: "verify unbounded by default", args: []string{"verify"}, wantDeadline: false},
+		{name: "verify explicit timeout", args: []string{"verify", "--timeout", "5m"}, wantDeadline: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var gotDeadline, called bool
+			cmd := newRootCmd("test", "abc123", "2025-01-01", deps{
+				newClient: func(ctx context.Context, _ *config.Config) (dolt.Client, error) {
+					called = true
+					_, gotDeadline = ctx.Deadline()
+					return dolt.NewMockClient(), nil
+				},
+			})
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			_ = cmd.Execute() // only the context matters here
+			if !called {
+				t.Fatal("client factory was not called")
+			}
+			if gotDeadline != tt.wantDeadline {
+				t.Errorf("factory context has deadline = %t, want %t", gotDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestSuggestPackages(t *testing.T) {
+	t.Parallel()
+	notFound := fmt.Errorf("package %q: %w", "commit-mgs", dolt.ErrNotFound)
+
+	m := dolt.NewMockClient()
+	m.AddPackage(dolt.NewTestPackage("commit-msg", "commit-msg", "1.0.0", nil))
+	m.AddPackage(dolt.NewTestPackage("sc-manage", "sc-manage", "1.0.0", nil))
+
+	t.Run("near miss", func(t *testing.T) {
+		t.Parallel()
+		err := suggestPackages(context.Background(), m, "commit-mgs", dolt.ListOptions{}, notFound)
+		want := `package "commit-mgs": not found. Did you mean: commit-msg?`
+		if err == nil || err.Error() != want {
+			t.Errorf("err = %v, want %s", err, want)
+		}
+		if ExitCode(classifyError(err)) != ExitNotFound {
+			t.Errorf("suggestion changed the exit code of %v", err)
+		}
+	})
+
+	t.Run("no close match", func(t *testing.T) {
+		t.Parallel()
+		if err := suggestPackages(context.Background(), m, "zzzzzzzzzz", dolt.ListOptions{}, notFound); err != notFound {
+			t.Errorf("err = %v, want the original error", err)
+		}
+	})
+
+	t.Run("other errors untouched", func(t *testing.T) {
+		t.Parallel()
+		boom := errors.New("boom")
+		if err := suggestPackages(context.Background(), m, "commit-mgs", dolt.ListOptions{}, boom); err != boom {
+			t.Errorf("err = %v, want boom", err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/spf13/cobra"
+)
+
+// defaultDumpLimit is the default value of sc debug sql-dump --limit.
+const defaultDumpLimit = 10
+
+// newDebugCmd creates the hidden "sc debug" command group, diagnostics for
+// maintainers that are not part of the supported interface.
+func newDebugCmd(newClient clientFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "debug",
+		Short:  "Diagnostics for maintainers",
+		Hidden: true,
+		Args:   usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(newSQLDumpCmd(newClient))
+	return cmd
+}
+
+// newSQLDumpCmd creates the "sc debug sql-dump" command.
+func newSQLDumpCmd(newClient clientFactory) *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "sql-dump <table>",
+		Short: "Print raw rows of a catalog table as JSON",
+		Long: `Print the first rows of a catalog table exactly as the server returns them,
+as JSON with the column names in server order, to diagnose scan errors and
+column mismatches. Only the catalog tables are accepted.`,
+		ValidArgs: dolt.CatalogTables,
+		Args:      usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, err := dolt.DumpTableQuery(args[0], limit)
+			if err != nil {
+				return usageError(err)
+			}
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd, cfg)
+			defer cancel()
+
+			client, err := connectClient(ctx, f, newClient, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			rows, err := client.QueryRaw(ctx, query)
+			if err != nil {
+				return fmt.Errorf("dumping %s: %w", args[0], err)
+			}
+			table, err := dolt.ReadRawRows(rows)
+			if err != nil {
+				return fmt.Errorf("dumping %s: %w", args[0], err)
+			}
+
+			if f.JSON {
+				if err := f.WriteJSON(table); err != nil {
+					return err
+				}
+				return f.Flush()
+			}
+			data, err := json.MarshalIndent(table, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding rows: %w", err)
+			}
+			f.Line(string(data))
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", defaultDumpLimit, "maximum number of rows to print")
+	return cmd
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+)
+
+func TestSQLDumpRejectsUnknownTable(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"debug", "sql-dump", "users"},
+		{"debug", "sql-dump", "packages; DROP TABLE packages"},
+		{"debug", "sql-dump", "packages", "--limit", "0"},
+		{"debug", "sql-dump"},
+	} {
+		exitErr := runExit(t, offlineDeps(t), args...)
+		if exitErr.Code != ExitUsage {
+			t.Errorf("sc %s: exit code = %d (%v), want %d", strings.Join(args, " "), exitErr.Code, exitErr, ExitUsage)
+		}
+	}
+}
+
+func TestSQLDumpQueryError(t *testing.T) {
+	t.Parallel()
+	m := dolt.NewMockClient()
+	m.RawErr = errors.New("boom")
+	exitErr := runExit(t, deps{newClient: mockFactory(m)}, "debug", "sql-dump", "packages")
+	if !strings.Contains(exitErr.Error(), "dumping packages: boom") {
+		t.Errorf("err = %v, want the query error", exitErr)
+	}
+}
+
+func TestDebugIsHidden(t *testing.T) {
+	t.Parallel()
+	out := runCmd(t, dolt.NewMockClient(), "--help")
+	if strings.Contains(out, "\n  debug ") {
+		t.Errorf("sc --help should not list debug:\n%s", out)
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// newDepsCmd creates the "sc deps" command.
+func newDepsCmd(newClient clientFactory) *cobra.Command {
+	var order bool
+
+	cmd := &cobra.Command{
+		Use:   "deps <id>",
+		Short: "Show a package's dependency tree or install order",
+		Long: `Show the catalog packages a package depends on (dep_type "skill"),
+transitively, as a tree. With --order, print a flat list in install order
+instead: every package after its dependencies, the package itself last.
+With --json, the install order is written as a JSON array. A dependency
+cycle is an error.`,
+		Args: usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd, cfg)
+			defer cancel()
+
+			client, err := connectClient(ctx, f, newClient, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			root := args[0]
+			graph, err := fetchDepGraph(ctx, client, root)
+			if err != nil {
+				return err
+			}
+			installOrder, err := models.ResolveInstallOrder(root, graph)
+			if err != nil {
+				return fmt.Errorf("resolving dependencies of %q: %w", root, err)
+			}
+
+			switch {
+			case f.JSON:
+				if err := f.WriteJSON(installOrder); err != nil {
+					return err
+				}
+				return f.Flush()
+			case order:
+				for _, id := range installOrder {
+					f.Line(id)
+				}
+				return nil
+			}
+			printDepTree(f, graph, root, "", "")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&order, "order", false, "print a flat list in install order")
+	return cmd
+}
+
+// fetchDepGraph walks the package dependencies of root through client and
+// returns them as a map from package ID to the IDs it depends on, with an
+// entry for every package reached. Each package must exist in the catalog.
+func fetchDepGraph(ctx context.Context, client dolt.Client, root string) (map[string][]string, error) {
+	graph := make(map[string][]string)
+	requiredBy := make(map[string]string)
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, seen := graph[id]; seen {
+			continue
+		}
+
+		if _, err := client.GetPackage(ctx, id); err != nil {
+			if id == root {
+				return nil, suggestPackages(ctx, client, id, dolt.ListOptions{}, err)
+			}
+			return nil, fmt.Errorf("dependency %q of %q: %w", id, requiredBy[id], err)
+		}
+		deps, err := client.GetPackageDeps(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("getting dependencies of %q: %w", id, err)
+		}
+
+		ids := models.PackageDepIDs(deps)
+		graph[id] = ids
+		for _, dep := range ids {
+			if _, ok := requiredBy[dep]; !ok {
+				requiredBy[dep] = id
+			}
+			queue = append(queue, dep)
+		}
+	}
+	return graph, nil
+}
+
+// printDepTree writes id and its dependencies below it as an indented tree.
+// graph must be acyclic. prefix is written before id; childPrefix before
+// the lines of its subtree.
+func printDepTree(f *output.Formatter, graph map[string][]string, id, prefix, childPrefix string) {
+	f.Line(prefix + id)
+	deps := graph[id]
+	for i, dep := range deps {
+		if i == len(deps)-1 {
+			printDepTree(f, graph, dep, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printDepTree(f, graph, dep, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
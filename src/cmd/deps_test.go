@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// depsFixture returns a catalog where app depends on web and cli, both of
+// which depend on util, plus a loop-a/loop-b cycle.
+func depsFixture() *dolt.MockClient {
+	m := dolt.NewMockClient()
+	for _, id := range []string{"app", "web", "cli", "util", "loop-a", "loop-b"} {
+		m.AddPackage(dolt.NewTestPackage(id, id, "1.0.0", nil))
+	}
+	skill := func(ids ...string) []models.PackageDep {
+		deps := []models.PackageDep{{DepType: models.DepTypeTool, DepName: "python3"}}
+		for _, id := range ids {
+			deps = append(deps, models.PackageDep{DepType: models.DepTypeSkill, DepName: id})
+		}
+		return deps
+	}
+	m.AddDeps("app", skill("web", "cli"))
+	m.AddDeps("web", skill("util"))
+	m.AddDeps("cli", skill("util"))
+	m.AddDeps("loop-a", skill("loop-b"))
+	m.AddDeps("loop-b", skill("loop-a"))
+	return m
+}
+
+func TestDepsTree(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, depsFixture(), "deps", "app")
+	want := strings.Join([]string{
+		"app",
+		"├── cli",
+		"│   └── util",
+		"└── web",
+		"    └── util",
+	}, "\n") + "\n"
+	if out != want {
+		t.Errorf("tree =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestDepsOrder(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, depsFixture(), "deps", "app", "--order")
+	if got := strings.Fields(out); fmt.Sprint(got) != "[util cli web app]" {
+		t.Errorf("order = %v, want [util cli web app]", got)
+	}
+
+	out = runCmd(t, depsFixture(), "deps", "app", "--json")
+	var env struct {
+		Data []string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &env); err != nil {
+		t.Fatalf("--json output should be valid JSON: %v\n%s", err, out)
+	}
+	if fmt.Sprint(env.Data) != "[util cli web app]" {
+		t.Errorf("JSON order = %v, want [util cli web app]", env.Data)
+	}
+}
+
+func TestDepsErrors(t *testing.T) {
+	t.Parallel()
+
+	d := deps{newClient: mockFactory(depsFixture())}
+	exitErr := runExit(t, d, "deps", "loop-a")
+	var cycleErr *models.CycleError
+	if exitErr == nil || exitErr.Code == 0 || !errors.As(exitErr, &cycleErr) {
+		t.Errorf("cycle: got %v, want a non-zero exit with a CycleError", exitErr)
+	}
+
+	if exitErr := runExit(t, d, "deps", "nope"); exitErr == nil || exitErr.Code != ExitNotFound {
+		t.Errorf("missing package: got %v, want not found", exitErr)
+	}
+
+	m := depsFixture()
+	m.AddDeps("util", []models.PackageDep{{DepType: models.DepTypeSkill, DepName: "gone"}})
+	exitErr = runExit(t, deps{newClient: mockFactory(m)}, "deps", "app")
+	if exitErr == nil || exitErr.Code != ExitNotFound || !strings.Contains(exitErr.Error(), `"gone"`) {
+		t.Errorf("missing dependency: got %v, want not found naming it", exitErr)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io/fs"
+	"net"
+	"os/exec"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/spf13/cobra"
+)
+
+// Process exit codes returned by sc. Scripts can rely on these.
+const (
+	// ExitFailure is an unexpected error.
+	ExitFailure = 1
+	// ExitUsage is a bad flag, argument, or flag combination.
+	ExitUsage = 2
+	// ExitIO is a failure to reach the database, a remote, or the filesystem.
+	ExitIO = 3
+	// ExitNotFound is a lookup of a package or variant that does not exist.
+	ExitNotFound = 4
+)
+
+// ExitError is an error carrying the process exit code for it. Execute
+// always returns one; main exits with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error implements error.
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// usageError marks err as a usage error.
+func usageError(err error) error {
+	return &ExitError{Code: ExitUsage, Err: err}
+}
+
+// ioError marks err as a connection or I/O failure.
+func ioError(err error) error {
+	return &ExitError{Code: ExitIO, Err: err}
+}
+
+// usageArgs wraps an argument validator so its errors are usage errors.
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, a []string) error {
+		if err := args(cmd, a); err != nil {
+			return usageError(err)
+		}
+		return nil
+	}
+}
+
+// classifyError wraps err in an ExitError. An ExitError already in the
+// chain decides the code; otherwise ErrNotFound maps to ExitNotFound,
+// connection, network, filesystem, and timeout errors to ExitIO, an unknown
+// --fields name to ExitUsage, and anything else to ExitFailure. A failed
+// query is ExitIO only when the connection failed; one the server rejected,
+// such as a SQL error, is ExitFailure.
+func classifyError(err error) *ExitError {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr == err {
+			return exitErr
+		}
+		return &ExitError{Code: exitErr.Code, Err: err}
+	}
+
+	code := ExitFailure
+	var (
+		netErr  net.Error
+		pathErr *fs.PathError
+		execErr *exec.Error
+	)
+	switch {
+	case errors.Is(err, dolt.ErrNotFound):
+		code = ExitNotFound
+	case errors.Is(err, output.ErrUnknownField):
+		code = ExitUsage
+	case errors.As(err, &netErr), errors.As(err, &pathErr), errors.As(err, &execErr),
+		errors.Is(err, driver.ErrBadConn), errors.Is(err, context.DeadlineExceeded):
+		code = ExitIO
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode returns the process exit code for err: 0 for nil, the code of an
+// ExitError in the chain, and ExitFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
+
+// flagError turns cobra flag parsing failures into usage errors.
+func flagError(_ *cobra.Command, err error) error {
+	return usageError(err)
+}
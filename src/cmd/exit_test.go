@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+)
+
+// runExit runs sc with args through execute and returns the ExitError it
+// produced, failing the test if the command succeeded.
+func runExit(t *testing.T, d deps, args ...string) *ExitError {
+	t.Helper()
+	cmd := newRootCmd("test", "abc123", "2025-01-01", d)
+	cmd.SetArgs(args)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := execute(cmd)
+	if err == nil {
+		t.Fatalf("sc %v succeeded, want an error", args)
+	}
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("sc %v returned %T, want *ExitError", args, err)
+	}
+	return exitErr
+}
+
+func TestExitCodes(t *testing.T) {
+	t.Parallel()
+
+	failingList := func(err error) deps {
+		m := dolt.NewMockClient()
+		m.ListErr = err
+		return deps{newClient: mockFactory(m), runner: &recordingRunner{}}
+	}
+	ok := failingList(nil)
+
+	tests := []struct {
+		name string
+		d    deps
+		args []string
+		want int
+	}{
+		{name: "unknown flag", d: ok, args: []string{"list", "--bogus"}, want: ExitUsage},
+		{name: "unexpected argument", d: ok, args: []string{"list", "extra"}, want: ExitUsage},
+		{name: "unknown command", d: ok, args: []string{"bogus"}, want: ExitUsage},
+		{name: "conflicting flags", d: ok, args: []string{"list", "--quiet", "--verbose"}, want: ExitUsage},
+		{name: "sync without dolt dir", d: ok, args: []string{"sync"}, want: ExitUsage},
+		{
+			name: "not found",
+			d:    failingList(fmt.Errorf("branch %q: %w", "nope", dolt.ErrNotFound)),
+			args: []string{"list"},
+			want: ExitNotFound,
+		},
+		{
+			name: "query connection failure",
+			d: failingList(&dolt.QueryError{Op: "ListPackages", Err: &net.OpError{
+				Op: "dial", Net: "tcp", Err: errors.New("connection refused"),
+			}}),
+			args: []string{"list"},
+			want: ExitIO,
+		},
+		{
+			name: "query bad connection",
+			d:    failingList(&dolt.QueryError{Op: "ListPackages", Err: driver.ErrBadConn}),
+			args: []string{"list"},
+			want: ExitIO,
+		},
+		{
+			name: "query rejected by server",
+			d:    failingList(&dolt.QueryError{Op: "ListPackages", Err: errors.New("Error 1064: syntax error")}),
+			args: []string{"list"},
+			want: ExitFailure,
+		},
+		{
+			name: "connection failure",
+			d: deps{newClient: func(context.Context, *config.Config) (dolt.Client, error) {
+				return nil, ioError(errors.New("dial tcp: connection refused"))
+			}},
+			args: []string{"list"},
+			want: ExitIO,
+		},
+		{
+			name: "pull failure",
+			d:    deps{runner: &recordingRunner{err: errors.New("remote unreachable")}},
+			args: []string{"sync", "--dolt-dir", "/tmp/catalog"},
+			want: ExitIO,
+		},
+		{name: "unexpected", d: failingList(errors.New("boom")), args: []string{"list"}, want: ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := runExit(t, tt.d, tt.args...); got.Code != tt.want {
+				t.Errorf("code = %d, want %d (err: %v)", got.Code, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecuteReportsError(t *testing.T) {
+	t.Parallel()
+
+	m := dolt.NewMockClient()
+	m.ListErr = errors.New("boom")
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "text", args: []string{"list"}, want: "Error: listing packages: boom\n"},
+		{name: "json", args: []string{"list", "--json"}, want: `{"error":"listing packages: boom"}` + "\n"},
+		{name: "ndjson", args: []string{"list", "--ndjson"}, want: `{"error":"listing packages: boom"}` + "\n"},
+		{name: "usage error", args: []string{"list", "--json", "--bogus"}, want: `{"error":"unknown flag: --bogus"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cmd := newRootCmd("test", "abc123", "2025-01-01", deps{newClient: mockFactory(m)})
+			cmd.SetArgs(tt.args)
+			var stderr bytes.Buffer
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&stderr)
+
+			if err := execute(cmd); err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := stderr.String(); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("stderr = %q, want it to end with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	if got := ExitCode(nil); got != 0 {
+		t.Errorf("ExitCode(nil) = %d, want 0", got)
+	}
+	if got := ExitCode(errors.New("plain")); got != ExitFailure {
+		t.Errorf("ExitCode(plain) = %d, want %d", got, ExitFailure)
+	}
+	wrapped := fmt.Errorf("context: %w", &ExitError{Code: ExitNotFound, Err: dolt.ErrNotFound})
+	if got := ExitCode(wrapped); got != ExitNotFound {
+		t.Errorf("ExitCode(wrapped) = %d, want %d", got, ExitNotFound)
+	}
+	if got := classifyError(wrapped); got.Code != ExitNotFound || got.Error() != wrapped.Error() {
+		t.Errorf("classifyError(wrapped) = %d %q, want the inner code and outer message", got.Code, got)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// newListCmd creates the "sc list" command.
+func newListCmd(newClient clientFactory) *cobra.Command {
+	var opts dolt.ListOptions
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List packages in the catalog",
+		Long: `List packages in the catalog, optionally filtered by branch and tags.
+With --quiet, only package IDs are printed, one per line. With --ndjson,
+packages are streamed as JSON Lines as they are read from the catalog.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd, cfg)
+			defer cancel()
+			opts.Branch = cfg.Branch
+
+			client, err := connectClient(ctx, f, newClient, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			if cfg.NDJSON && !cfg.Quiet {
+				return streamPackages(ctx, client, opts, f)
+			}
+
+			pkgs, err := client.ListPackages(ctx, opts)
+			if err != nil {
+				return fmt.Errorf("listing packages: %w", err)
+			}
+			return writePackages(f, pkgs)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.String("branch", "", "Dolt branch (channel) to list (default: $SC_BRANCH)")
+	flags.StringSliceVar(&opts.Tags, "tag", nil, "only packages carrying this tag (repeatable)")
+	return cmd
+}
+
+// writePackages writes pkgs as JSON, as bare IDs in quiet mode, as one
+// package object per line in NDJSON mode, or as a table of ID, name,
+// version, and install scope.
+func writePackages(f *output.Formatter, pkgs []models.Package) error {
+	switch {
+	case f.JSON:
+		if err := f.WriteJSON(pkgs); err != nil {
+			return err
+		}
+		return f.Flush()
+	case f.Quiet:
+		for _, p := range pkgs {
+			f.Line(p.ID)
+		}
+		return nil
+	case f.NDJSON:
+		items := make(chan any)
+		go func() {
+			defer close(items)
+			for _, p := range pkgs {
+				items <- p
+			}
+		}()
+		return f.WriteStream(items)
+	}
+
+	rows := make([][]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		rows = append(rows, []string{p.ID, p.Name, p.Version, string(p.InstallScope)})
+	}
+	return f.Table([]string{"ID", "NAME", "VERSION", "INSTALL SCOPE"}, rows)
+}
+
+// streamPackages writes the packages matching opts to f one at a time, as
+// they are read from the catalog, without buffering the full list.
+func streamPackages(ctx context.Context, client dolt.Client, opts dolt.ListOptions, f *output.Formatter) error {
+	it, err := client.ListPackagesIter(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("listing packages: %w", err)
+	}
+	defer func() { _ = it.Close() }()
+
+	items := make(chan any)
+	go func() {
+		defer close(items)
+		for it.Next() {
+			items <- it.Package()
+		}
+	}()
+	if err := f.WriteStream(items); err != nil {
+		return err
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("listing packages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// mockFactory returns a clientFactory that always hands out m.
+func mockFactory(m *dolt.MockClient) clientFactory {
+	return func(context.Context, *config.Config) (dolt.Client, error) { return m, nil }
+}
+
+// listFixture returns a mock catalog with two packages.
+func listFixture() *dolt.MockClient {
+	m := dolt.NewMockClient()
+	m.AddPackage(dolt.NewTestPackage("pkg-1", "alpha", "1.0.0", []string{"go"}))
+	m.AddPackage(dolt.NewTestPackage("pkg-2", "beta", "2.0.0", nil))
+	return m
+}
+
+// runCmd executes the command tree with args against m and returns stdout.
+func runCmd(t *testing.T, m *dolt.MockClient, args ...string) string {
+	t.Helper()
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{newClient: mockFactory(m)})
+	cmd.SetArgs(args)
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sc %s failed: %v\n%s", strings.Join(args, " "), err, errOut.String())
+	}
+	return out.String()
+}
+
+func TestListQuietPrintsIDs(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, listFixture(), "list", "--quiet")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	sort.Strings(lines)
+	if strings.Join(lines, ",") != "pkg-1,pkg-2" {
+		t.Errorf("quiet output = %q, want one ID per line", out)
+	}
+}
+
+func TestListTable(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, listFixture(), "list", "--tag", "go")
+	for _, want := range []string{"ID", "NAME", "pkg-1", "alpha"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "pkg-2") {
+		t.Errorf("--tag go should exclude pkg-2, got:\n%s", out)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, listFixture(), "list", "--json", "--quiet")
+	var env struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &env); err != nil {
+		t.Fatalf("--json output should be valid JSON: %v\n%s", err, out)
+	}
+	if len(env.Data) != 2 {
+		t.Errorf("got %d packages, want 2", len(env.Data))
+	}
+}
+
+func TestListNDJSON(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, listFixture(), "list", "--ndjson")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out)
+	}
+	for _, line := range lines {
+		var pkg struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(line), &pkg); err != nil || pkg.ID == "" {
+			t.Errorf("line %q is not a package object: %v", line, err)
+		}
+	}
+}
+
+func TestListFieldsSameInEveryMode(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range [][]string{nil, {"--json"}, {"--ndjson"}} {
+		args := append([]string{"list", "--fields", "install_scope,id"}, mode...)
+		out := runCmd(t, listFixture(), args...)
+		if !strings.Contains(out, "pkg-1") || strings.Contains(out, "alpha") {
+			t.Errorf("sc %v should show only the install scope and ID, got:\n%s", args, out)
+		}
+
+		args = append([]string{"list", "--fields", "scope"}, mode...)
+		if exitErr := runExit(t, deps{newClient: mockFactory(listFixture())}, args...); !errors.Is(exitErr, output.ErrUnknownField) {
+			t.Errorf("sc %v: err = %v, want ErrUnknownField", args, exitErr)
+		}
+	}
+}
+
+// slowClient is a catalog whose ListPackages blocks until the context ends.
+type slowClient struct {
+	*dolt.MockClient
+}
+
+func (slowClient) ListPackages(ctx context.Context, _ dolt.ListOptions) ([]models.Package, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestListTimeout(t *testing.T) {
+	t.Parallel()
+
+	slow := slowClient{dolt.NewMockClient()}
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{
+		newClient: func(context.Context, *config.Config) (dolt.Client, error) { return slow, nil },
+	})
+	cmd.SetArgs([]string{"list", "--timeout", "20ms"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestNegativeTimeoutRejected(t *testing.T) {
+	t.Parallel()
+
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{newClient: mockFactory(listFixture())})
+	cmd.SetArgs([]string{"list", "--timeout", "-1s"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--timeout") {
+		t.Fatalf("err = %v, want --timeout validation error", err)
+	}
+}
+
+func TestListFields(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, listFixture(), "list", "--ndjson", "--fields", "id,version")
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var pkg map[string]any
+		if err := json.Unmarshal([]byte(line), &pkg); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		if len(pkg) != 2 || pkg["id"] == nil || pkg["version"] == nil {
+			t.Errorf("line %q, want only id and version", line)
+		}
+	}
+
+	d := deps{newClient: mockFactory(listFixture())}
+	if exitErr := runExit(t, d, "list", "--json", "--fields", "id,nope"); exitErr == nil || exitErr.Code != ExitUsage {
+		t.Errorf("unknown field: got %v, want usage error", exitErr)
+	}
+}
@@ -1,21 +1,85 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"time"
 
 	"github.com/randlee/synaptic-canvas-dolt/internal/config"
 	"github.com/randlee/synaptic-canvas-dolt/internal/logging"
+	"github.com/randlee/synaptic-canvas-dolt/internal/output"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
 	"github.com/spf13/cobra"
 )
 
 // Execute creates the root command, configures it with version info, and runs it.
+// A non-nil error has already been reported on stderr and is always an
+// *ExitError carrying the process exit code.
 func Execute(version, commit, date string) error {
+	ctx, stop := interruptContext()
+	defer stop()
 	rootCmd := NewRootCmd(version, commit, date)
-	return rootCmd.Execute()
+	rootCmd.SetContext(ctx)
+	return execute(rootCmd)
+}
+
+// interruptContext returns a context cancelled by the first interrupt, so
+// that a command stops through its normal error path and deferred cleanup,
+// such as restoring the cursor hidden by a spinner, still runs. The first
+// interrupt also restores the default handling, so a second one kills the
+// process. The caller must call stop.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// execute runs rootCmd, classifies its error, and reports it through a
+// Formatter, so that under --json or --ndjson it is written as
+// {"error": ...} like every other error.
+func execute(rootCmd *cobra.Command) error {
+	if err := rootCmd.Execute(); err != nil {
+		exitErr := classifyError(err)
+		errorFormatter(rootCmd).Error(exitErr.Error())
+		return exitErr
+	}
+	return nil
+}
+
+// errorFormatter returns a Formatter for reporting a top-level error on
+// rootCmd's error stream. It reads the output flags directly, since the
+// error may have come from loading the rest of the configuration, and
+// never buffers into an envelope, since nothing would flush it.
+func errorFormatter(rootCmd *cobra.Command) *output.Formatter {
+	flags := rootCmd.PersistentFlags()
+	jsonMode, _ := flags.GetBool("json")    //nolint:errcheck // defined on the root command
+	ndjson, _ := flags.GetBool("ndjson")    //nolint:errcheck // defined on the root command
+	noColor, _ := flags.GetBool("no-color") //nolint:errcheck // defined on the root command
+	f := output.NewFormatter(jsonMode, false)
+	f.Envelope = false
+	f.NDJSON = ndjson
+	f.NoColor = noColor
+	f.ErrW = rootCmd.ErrOrStderr()
+	return f
 }
 
 // NewRootCmd creates and returns the root cobra.Command for the sc CLI.
 func NewRootCmd(version, commit, date string) *cobra.Command {
+	return newRootCmd(version, commit, date, deps{newClient: openClient, runner: dolt.ExecRunner{}})
+}
+
+// deps are the external dependencies of the command tree, replaced in tests.
+type deps struct {
+	// newClient opens the catalog for every command that reads it.
+	newClient clientFactory
+	// runner runs external programs such as dolt.
+	runner dolt.CommandRunner
+}
+
+// newRootCmd builds the command tree wired to d.
+func newRootCmd(version, commit, date string, d deps) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "sc",
 		Short: "Synaptic Canvas — Dolt-backed package manager for Claude Code skills",
@@ -25,6 +89,7 @@ stored in a Dolt database.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Version:       formatVersion(version, commit, date),
+		Args:          usageArgs(cobra.NoArgs),
 		// Show help when invoked with no subcommand.
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return cmd.Help()
@@ -35,7 +100,7 @@ stored in a Dolt database.`,
 				return fmt.Errorf("reading config flags: %w", err)
 			}
 			if err := cfg.Validate(); err != nil {
-				return fmt.Errorf("invalid configuration: %w", err)
+				return usageError(fmt.Errorf("invalid configuration: %w", err))
 			}
 			logger := logging.Setup(cfg.Verbose, cfg.Quiet)
 			logger = logging.WithContext(logger, "cli", "init")
@@ -47,9 +112,12 @@ stored in a Dolt database.`,
 			logger.Debug("configuration loaded",
 				"dolt_dir", doltDirDisplay,
 				"remote", cfg.Remote,
+				"branch", cfg.Branch,
 				"json", cfg.JSON,
+				"ndjson", cfg.NDJSON,
 				"verbose", cfg.Verbose,
 				"quiet", cfg.Quiet,
+				"timeout", cfg.Timeout,
 			)
 			return nil
 		},
@@ -57,18 +125,37 @@ stored in a Dolt database.`,
 
 	// Override the default version template to match the required format.
 	rootCmd.SetVersionTemplate("sc version {{.Version}}\n")
+	rootCmd.SetFlagErrorFunc(flagError)
 
 	// Register persistent (global) flags.
 	pf := rootCmd.PersistentFlags()
 	pf.String("dolt-dir", "", "Dolt database directory (default: auto-detect)")
 	pf.String("remote", "", "DoltHub remote name")
 	pf.Bool("json", false, "output as JSON")
+	pf.Bool("ndjson", false, "output as JSON Lines, one object per line")
 	pf.Bool("quiet", false, "suppress non-essential output")
 	pf.Bool("verbose", false, "enable debug logging")
+	pf.Bool("no-truncate", false, "do not truncate table cells to the terminal width")
+	pf.Bool("no-color", false, "disable colored output (also set by NO_COLOR)")
+	pf.Duration("timeout", defaultTimeout, "maximum run time per command; 0 disables the timeout (sync and verify are unbounded unless it is set)")
+	pf.StringSlice("fields", nil, "only output these fields (comma-separated JSON keys, e.g. id,name)")
+
+	rootCmd.AddCommand(newListCmd(d.newClient))
+	rootCmd.AddCommand(newSearchCmd(d.newClient))
+	rootCmd.AddCommand(newSyncCmd(d.runner))
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newDepsCmd(d.newClient))
+	rootCmd.AddCommand(newVerifyCmd(d.newClient))
+	rootCmd.AddCommand(newDebugCmd(d.newClient))
+	rootCmd.AddCommand(newVersionCmd(versionInfo{Version: version, Commit: commit, Date: date}))
 
 	return rootCmd
 }
 
+// defaultTimeout is the default value of --timeout.
+const defaultTimeout = 30 * time.Second
+
 // formatVersion returns a human-readable version string.
 func formatVersion(version, commit, date string) string {
 	return fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)
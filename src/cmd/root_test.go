@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -104,3 +107,24 @@ func TestConflictingFlagsError(t *testing.T) {
 		t.Errorf("error should mention flag conflict, got: %v", err)
 	}
 }
+
+func TestInterruptCancelsContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cannot send an interrupt to the own process on windows")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(os.Interrupt); err != nil {
+		t.Fatalf("sending interrupt: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by the interrupt")
+	}
+}
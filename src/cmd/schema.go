@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// schemas maps the names accepted by sc schema to their generators.
+var schemas = map[string]func() ([]byte, error){
+	"manifest": models.ManifestJSONSchema,
+}
+
+// newSchemaCmd creates the "sc schema" command.
+func newSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema manifest",
+		Short: "Print the JSON Schema of a catalog document",
+		Long: `Print the JSON Schema (draft-07) of a catalog document, for validating
+generated files with standard tooling. The only schema is "manifest", the
+JSON form of a package manifest. The schema is printed as-is; with --json
+it is the envelope's data.`,
+		ValidArgs: []string{"manifest"},
+		Args:      usageArgs(cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			data, err := schemas[args[0]]()
+			if err != nil {
+				return fmt.Errorf("generating %s schema: %w", args[0], err)
+			}
+			if f.JSON {
+				if err := f.WriteJSON(json.RawMessage(data)); err != nil {
+					return err
+				}
+				return f.Flush()
+			}
+			f.Line(string(data))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	t.Parallel()
+
+	run := func(args ...string) (string, error) {
+		cmd := newRootCmd("test", "abc123", "2025-01-01", offlineDeps(t))
+		cmd.SetArgs(args)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("schema", "manifest")
+	if err != nil {
+		t.Fatalf("schema manifest failed: %v", err)
+	}
+	var schema struct {
+		Schema     string         `json:"$schema"`
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if schema.Schema == "" || schema.Properties["artifacts"] == nil {
+		t.Errorf("output does not look like the manifest schema:\n%s", out)
+	}
+
+	out, err = run("schema", "manifest", "--json")
+	if err != nil {
+		t.Fatalf("schema manifest --json failed: %v", err)
+	}
+	var env struct {
+		Data struct {
+			Schema string `json:"$schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out), &env); err != nil || env.Data.Schema == "" {
+		t.Errorf("--json should wrap the schema in the envelope: %v\n%s", err, out)
+	}
+
+	if exitErr := runExit(t, offlineDeps(t), "schema", "package"); exitErr == nil || exitErr.Code != ExitUsage {
+		t.Errorf("unknown schema: got %v, want usage error", exitErr)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/spf13/cobra"
+)
+
+// newSearchCmd creates the "sc search" command.
+func newSearchCmd(newClient clientFactory) *cobra.Command {
+	var (
+		opts    dolt.SearchOptions
+		tagOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <term>",
+		Short: "Search packages by name, description, and tags",
+		Long: `Search the catalog for packages whose name or description contains term
+(case-insensitively) or that carry term as a tag. Packages named exactly
+term are listed first. With --tag, only tags are matched, and term must be
+a single tag without spaces or commas. A term with spaces is matched
+against names and descriptions only. Output is the same as sc list.`,
+		Args: usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			if tagOnly {
+				if err := dolt.ValidateSearchTag(args[0]); err != nil {
+					return usageError(err)
+				}
+				opts.Fields = []string{dolt.SearchFieldTags}
+			}
+			ctx, cancel := commandContext(cmd, cfg)
+			defer cancel()
+			opts.Branch = cfg.Branch
+
+			client, err := connectClient(ctx, f, newClient, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			pkgs, err := client.Search(ctx, args[0], opts)
+			if err != nil {
+				return fmt.Errorf("searching packages: %w", err)
+			}
+			return writePackages(f, pkgs)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.String("branch", "", "Dolt branch (channel) to search (default: $SC_BRANCH)")
+	flags.BoolVar(&tagOnly, "tag", false, "match tags only")
+	flags.IntVar(&opts.Limit, "limit", 0, "maximum number of results (0 for no limit)")
+	return cmd
+}
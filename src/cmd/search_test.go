@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestListAlias(t *testing.T) {
+	t.Parallel()
+
+	root := newRootCmd("test", "abc123", "2025-01-01", deps{newClient: mockFactory(listFixture())})
+	alias, _, err := root.Find([]string{"ls"})
+	if err != nil {
+		t.Fatalf("finding ls: %v", err)
+	}
+	list, _, err := root.Find([]string{"list"})
+	if err != nil {
+		t.Fatalf("finding list: %v", err)
+	}
+	if alias != list {
+		t.Errorf("ls resolved to %q, want the list command", alias.Name())
+	}
+
+	if got, want := runCmd(t, listFixture(), "ls", "--quiet"), runCmd(t, listFixture(), "list", "--quiet"); got != want {
+		t.Errorf("ls output = %q, want %q", got, want)
+	}
+}
+
+func searchFixture() *dolt.MockClient {
+	m := dolt.NewMockClient()
+	m.AddPackage(dolt.NewTestPackage("pkg-go", "go-tools", "1.0.0", nil))
+	m.AddPackage(dolt.NewTestPackage("pkg-lint", "linter", "1.0.0", []string{"go"}))
+	m.AddPackage(dolt.NewTestPackage("pkg-py", "py-tools", "1.0.0", nil))
+	return m
+}
+
+func TestSearchTagWithSpaceIsUsageError(t *testing.T) {
+	t.Parallel()
+	d := deps{newClient: mockFactory(searchFixture())}
+	if got := runExit(t, d, "search", "code review", "--tag"); got.Code != ExitUsage {
+		t.Errorf("code = %d, want %d (err: %v)", got.Code, ExitUsage, got)
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"name and tag", []string{"search", "go", "--quiet"}, "pkg-go pkg-lint"},
+		{"tag only", []string{"search", "go", "--tag", "--quiet"}, "pkg-lint"},
+		{"limit", []string{"search", "go", "--limit", "1", "--quiet"}, "pkg-go"},
+		{"no match", []string{"search", "rust", "--quiet"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := runCmd(t, searchFixture(), tt.args...)
+			if got := strings.Join(strings.Fields(out), " "); got != tt.want {
+				t.Errorf("IDs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchNDJSONWritesPackages(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, searchFixture(), "search", "tools", "--ndjson")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out)
+	}
+	for _, line := range lines {
+		var pkg models.Package
+		if err := json.Unmarshal([]byte(line), &pkg); err != nil || pkg.ID == "" || pkg.InstallScope == "" {
+			t.Errorf("line %q is not a package object: %v", line, err)
+		}
+		if strings.Contains(line, `"ID"`) {
+			t.Errorf("line %q uses table headers as keys", line)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/spf13/cobra"
+)
+
+// newSyncCmd creates the "sc sync" command.
+func newSyncCmd(runner dolt.CommandRunner) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Pull the latest catalog from the DoltHub remote",
+		Long: `Pull the latest catalog into the local Dolt clone given by --dolt-dir,
+from the remote named by --remote (default "origin"). Without --branch the
+clone's current branch is pulled; $SC_BRANCH is ignored, since dolt pull
+merges the named remote branch into whatever branch is checked out. Other
+commands never pull implicitly; run sync to refresh the catalog.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := longCommandContext(cmd, cfg)
+			defer cancel()
+
+			dir := cfg.DoltDirExpanded()
+			if dir == "" {
+				return usageError(fmt.Errorf("sc sync requires --dolt-dir"))
+			}
+			// cfg.Branch falls back to $SC_BRANCH, the default channel for
+			// reads; only an explicit --branch names what to pull.
+			var branch string
+			if cmd.Flags().Changed("branch") {
+				branch = cfg.Branch
+			}
+			if err := dolt.PullWith(ctx, runner, dir, cfg.Remote, branch); err != nil {
+				return ioError(err)
+			}
+			remote := cfg.Remote
+			if remote == "" {
+				remote = dolt.DefaultRemote
+			}
+			f.Success(fmt.Sprintf("Pulled latest catalog from %s", remote))
+			return f.Flush()
+		},
+	}
+
+	cmd.Flags().String("branch", "", "remote branch to pull (default: the clone's current branch)")
+	return cmd
+}
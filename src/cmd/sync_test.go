@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+)
+
+// recordingRunner records commands instead of running them.
+type recordingRunner struct {
+	calls []string
+	// deadlines records whether each call's context had a deadline.
+	deadlines []bool
+	err       error
+}
+
+func (r *recordingRunner) Run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, dir+": "+name+" "+strings.Join(args, " "))
+	_, ok := ctx.Deadline()
+	r.deadlines = append(r.deadlines, ok)
+	return nil, r.err
+}
+
+func TestSyncPullsRemote(t *testing.T) {
+	t.Parallel()
+
+	r := &recordingRunner{}
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{runner: r})
+	cmd.SetArgs([]string{"sync", "--dolt-dir", "/data/catalog", "--remote", "upstream", "--branch", "staging"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sc sync failed: %v", err)
+	}
+	if len(r.calls) != 1 || r.calls[0] != "/data/catalog: dolt pull upstream staging" {
+		t.Errorf("calls = %v, want one dolt pull upstream staging", r.calls)
+	}
+	if !strings.Contains(out.String(), "upstream") {
+		t.Errorf("output should name the remote, got %q", out.String())
+	}
+}
+
+func TestSyncIgnoresBranchEnv(t *testing.T) {
+	t.Setenv(config.BranchEnv, "staging")
+
+	r := &recordingRunner{}
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{runner: r})
+	cmd.SetArgs([]string{"sync", "--dolt-dir", "/data/catalog"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("sc sync failed: %v", err)
+	}
+	if len(r.calls) != 1 || r.calls[0] != "/data/catalog: dolt pull origin" {
+		t.Errorf("calls = %v, want dolt pull origin without $%s", r.calls, config.BranchEnv)
+	}
+}
+
+func TestSyncTimeoutOnlyWhenExplicit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantDeadline bool
+	}{
+		{name: "default", wantDeadline: false},
+		{name: "explicit", args: []string{"--timeout", "5m"}, wantDeadline: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &recordingRunner{}
+			cmd := newRootCmd("test", "abc123", "2025-01-01", deps{runner: r})
+			cmd.SetArgs(append([]string{"sync", "--dolt-dir", "/data/catalog", "--branch", "main"}, tt.args...))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("sc sync failed: %v", err)
+			}
+			if len(r.deadlines) != 1 || r.deadlines[0] != tt.wantDeadline {
+				t.Errorf("deadlines = %v, want [%t]", r.deadlines, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestSyncRequiresDoltDir(t *testing.T) {
+	t.Parallel()
+
+	r := &recordingRunner{}
+	cmd := newRootCmd("test", "abc123", "2025-01-01", deps{runner: r})
+	cmd.SetArgs([]string{"sync"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--dolt-dir") {
+		t.Fatalf("err = %v, want error mentioning --dolt-dir", err)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("no command should run, got %v", r.calls)
+	}
+}
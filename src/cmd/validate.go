@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/export"
+	"github.com/spf13/cobra"
+)
+
+// validateResult is the JSON output of sc validate.
+type validateResult struct {
+	File     string   `json:"file"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+// newValidateCmd creates the "sc validate" command.
+func newValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <manifest.yaml>",
+		Short: "Check a manifest.yaml file for problems",
+		Long: `Check a manifest.yaml file offline, without connecting to the catalog.
+Every problem found is reported: missing or malformed versions, an invalid
+install scope, duplicate or unsafe artifact paths, hooks whose script is not
+an artifact, and questions whose choices do not match their type. The exit
+status is non-zero if any problem is found.`,
+		Args: usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			path := args[0]
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading manifest: %w", err)
+			}
+			m, err := export.DecodeManifest(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			problems := m.Problems()
+			if f.JSON {
+				res := validateResult{File: path, Valid: len(problems) == 0, Problems: problems}
+				if res.Problems == nil {
+					res.Problems = []string{}
+				}
+				if err := f.WriteJSON(res); err != nil {
+					return err
+				}
+			}
+			msgs := make([]string, len(problems))
+			for i, p := range problems {
+				msgs[i] = fmt.Sprintf("%s: %s", path, p)
+			}
+			f.Errors(msgs)
+			if err := f.Flush(); err != nil {
+				return err
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%s: %d problem(s) found", path, len(problems))
+			}
+			f.Success(fmt.Sprintf("%s is valid", path))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+)
+
+const validManifest = `name: demo
+version: 1.0.0
+artifacts:
+  hooks:
+    - hooks/pre.sh
+hooks:
+  - event: PreToolUse
+    script_path: hooks/pre.sh
+`
+
+const invalidManifest = `name: demo
+version: 1.0.0
+install:
+  scope: global
+artifacts:
+  hooks:
+    - hooks/pre.sh
+hooks:
+  - event: PreToolUse
+    script_path: hooks/missing.sh
+`
+
+// offlineDeps fails the test if a command opens the catalog.
+func offlineDeps(t *testing.T) deps {
+	return deps{newClient: func(context.Context, *config.Config) (dolt.Client, error) {
+		t.Error("sc validate must not connect to the database")
+		return dolt.NewMockClient(), nil
+	}}
+}
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		path := writeManifest(t, validManifest)
+		cmd := newRootCmd("test", "abc123", "2025-01-01", offlineDeps(t))
+		cmd.SetArgs([]string{"validate", path})
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("validate failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "is valid") {
+			t.Errorf("output = %q, want a success message", out.String())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		path := writeManifest(t, invalidManifest)
+		cmd := newRootCmd("test", "abc123", "2025-01-01", offlineDeps(t))
+		cmd.SetArgs([]string{"validate", path})
+		var errOut bytes.Buffer
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&errOut)
+		err := execute(cmd)
+		if ExitCode(err) != ExitFailure {
+			t.Fatalf("exit code = %d (%v), want %d", ExitCode(err), err, ExitFailure)
+		}
+		if !strings.Contains(err.Error(), "2 problem(s) found") {
+			t.Errorf("err = %v, want a problem count", err)
+		}
+		for _, want := range []string{`invalid install scope "global"`, `script_path "hooks/missing.sh" is not listed in artifacts`} {
+			if !strings.Contains(errOut.String(), want) {
+				t.Errorf("stderr should report %q, got:\n%s", want, errOut.String())
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		path := writeManifest(t, invalidManifest)
+		cmd := newRootCmd("test", "abc123", "2025-01-01", offlineDeps(t))
+		cmd.SetArgs([]string{"validate", "--json", path})
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); err == nil {
+			t.Fatal("expected an error for an invalid manifest")
+		}
+		var env struct {
+			Data   validateResult `json:"data"`
+			Errors []string       `json:"errors"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &env); err != nil {
+			t.Fatalf("--json output should be valid JSON: %v\n%s", err, out.String())
+		}
+		if env.Data.Valid || len(env.Data.Problems) != 2 || len(env.Errors) != 2 {
+			t.Errorf("envelope = %+v, want two problems", env)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		exitErr := runExit(t, offlineDeps(t), "validate", filepath.Join(t.TempDir(), "nope.yaml"))
+		if exitErr.Code != ExitIO {
+			t.Errorf("exit code = %d, want %d", exitErr.Code, ExitIO)
+		}
+	})
+
+	t.Run("no argument", func(t *testing.T) {
+		t.Parallel()
+		if exitErr := runExit(t, offlineDeps(t), "validate"); exitErr.Code != ExitUsage {
+			t.Errorf("exit code = %d, want %d", exitErr.Code, ExitUsage)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// verifyFailure is one package that failed sc verify.
+type verifyFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// verifyResult is the JSON output of sc verify.
+type verifyResult struct {
+	Checked int             `json:"checked"`
+	Failed  []verifyFailure `json:"failed"`
+}
+
+// newVerifyCmd creates the "sc verify" command.
+func newVerifyCmd(newClient clientFactory) *cobra.Command {
+	var (
+		opts      dolt.ListOptions
+		packageID string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the stored SHA-256 of every package's files",
+		Long: `Check catalog integrity: for every package, recompute the SHA-256 of each
+file and the package's aggregate SHA and compare them with the stored
+values. Packages are checked one page at a time, so the catalog is never
+loaded whole. Failing packages are listed, and the exit status is non-zero
+if any fail. With --package, only that package is checked.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := longCommandContext(cmd, cfg)
+			defer cancel()
+			opts.Branch = cfg.Branch
+
+			client, err := connectClient(ctx, f, newClient, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = client.Close() }()
+
+			res := verifyResult{Failed: []verifyFailure{}}
+			check := func(id string) error {
+				failure, err := verifyPackage(ctx, client, id, opts)
+				if err != nil {
+					return err
+				}
+				res.Checked++
+				if failure != nil {
+					res.Failed = append(res.Failed, *failure)
+				}
+				return nil
+			}
+
+			if packageID != "" {
+				err = check(packageID)
+				err = suggestPackages(ctx, client, packageID, opts, err)
+			} else {
+				err = client.ForEachPackage(ctx, opts, 0, func(p models.Package) error {
+					return check(p.ID)
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("verifying packages: %w", err)
+			}
+
+			if f.JSON {
+				if err := f.WriteJSON(res); err != nil {
+					return err
+				}
+				if err := f.Flush(); err != nil {
+					return err
+				}
+			} else if len(res.Failed) > 0 {
+				rows := make([][]string, 0, len(res.Failed))
+				for _, fail := range res.Failed {
+					rows = append(rows, []string{fail.ID, fail.Error})
+				}
+				if err := f.Table([]string{"ID", "ERROR"}, rows); err != nil {
+					return err
+				}
+			}
+			if len(res.Failed) > 0 {
+				return fmt.Errorf("%d of %d package(s) failed verification", len(res.Failed), res.Checked)
+			}
+			f.Success(fmt.Sprintf("%d package(s) verified", res.Checked))
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.String("branch", "", "Dolt branch (channel) to verify (default: $SC_BRANCH)")
+	flags.StringVar(&packageID, "package", "", "verify only this package")
+	return cmd
+}
+
+// verifyPackage checks one package on opts.Branch with
+// models.VerifyPackage. A failed check is returned as a verifyFailure; err
+// is for failures to read the package at all.
+func verifyPackage(ctx context.Context, client dolt.Client, id string, opts dolt.ListOptions) (*verifyFailure, error) {
+	b, err := client.GetBundle(ctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.VerifyPackage(b.Package, b.Files); err != nil {
+		return &verifyFailure{ID: id, Error: err.Error()}, nil
+	}
+	return nil, nil
+}
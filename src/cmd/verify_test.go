@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/internal/config"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt/dolttest"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// verifyFixture returns a catalog with a clean package and one whose file
+// content no longer matches its stored SHA.
+func verifyFixture() *dolt.MockClient {
+	m := dolt.NewMockClient()
+	clean := dolt.NewTestPackage("clean", "clean", "1.0.0", nil)
+	cleanFiles := []models.PackageFile{dolttest.NewTestFile("clean", "skills/a.md", models.FileTypeSkill, "# A\n")}
+	sum := models.AggregateSHA256(cleanFiles)
+	clean.SHA256 = &sum
+	m.AddPackage(clean)
+	m.AddFiles("clean", cleanFiles)
+
+	m.AddPackage(dolt.NewTestPackage("corrupt", "corrupt", "1.0.0", nil))
+	bad := dolttest.NewTestFile("corrupt", "skills/b.md", models.FileTypeSkill, "# B\n")
+	bad.Content = "# tampered\n"
+	m.AddFiles("corrupt", []models.PackageFile{bad})
+	return m
+}
+
+func TestVerifyReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	d := deps{newClient: mockFactory(verifyFixture())}
+	if exitErr := runExit(t, d, "verify"); exitErr == nil || exitErr.Code != ExitFailure ||
+		!strings.Contains(exitErr.Error(), "1 of 2") {
+		t.Errorf("got %v, want a failure naming 1 of 2 packages", exitErr)
+	}
+
+	cmd := newRootCmd("test", "abc123", "2025-01-01", d)
+	cmd.SetArgs([]string{"verify", "--json"})
+	var out strings.Builder
+	cmd.SetOut(&out)
+	cmd.SetErr(&strings.Builder{})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected verify to fail")
+	}
+	var env struct {
+		Data verifyResult `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &env); err != nil {
+		t.Fatalf("--json output should be valid JSON: %v\n%s", err, out.String())
+	}
+	if env.Data.Checked != 2 || len(env.Data.Failed) != 1 || env.Data.Failed[0].ID != "corrupt" ||
+		!strings.Contains(env.Data.Failed[0].Error, "skills/b.md") {
+		t.Errorf("result = %+v, want only corrupt failing on skills/b.md", env.Data)
+	}
+}
+
+func TestVerifySinglePackage(t *testing.T) {
+	t.Parallel()
+
+	out := runCmd(t, verifyFixture(), "verify", "--package", "clean")
+	if !strings.Contains(out, "1 package(s) verified") {
+		t.Errorf("output = %q, want one package verified", out)
+	}
+
+	d := deps{newClient: mockFactory(verifyFixture())}
+	if exitErr := runExit(t, d, "verify", "--package", "corupt"); exitErr == nil || exitErr.Code != ExitNotFound ||
+		!strings.Contains(exitErr.Error(), "Did you mean: corrupt") {
+		t.Errorf("got %v, want not found with a suggestion", exitErr)
+	}
+}
+
+// bundleRecorder is a catalog that records the branch of every GetBundle.
+type bundleRecorder struct {
+	*dolt.MockClient
+	mu       sync.Mutex
+	branches []string
+}
+
+func (r *bundleRecorder) GetBundle(ctx context.Context, id string, opts dolt.ListOptions) (*models.PackageBundle, error) {
+	r.mu.Lock()
+	r.branches = append(r.branches, opts.Branch)
+	r.mu.Unlock()
+	return r.MockClient.GetBundle(ctx, id, opts)
+}
+
+func TestVerifyReadsTheRequestedBranch(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"verify", "--package", "clean", "--branch", "staging"},
+		{"verify", "--branch", "staging"},
+	} {
+		rec := &bundleRecorder{MockClient: verifyFixture()}
+		cmd := newRootCmd("test", "abc123", "2025-01-01", deps{newClient: func(context.Context, *config.Config) (dolt.Client, error) {
+			return rec, nil
+		}})
+		cmd.SetArgs(args)
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetErr(&strings.Builder{})
+		_ = cmd.Execute()
+
+		if len(rec.branches) == 0 {
+			t.Errorf("sc %v read no bundles", args)
+		}
+		for _, b := range rec.branches {
+			if b != "staging" {
+				t.Errorf("sc %v read a bundle on branch %q, want staging", args, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// versionInfo is the JSON output of sc version.
+type versionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// newVersionCmd creates the "sc version" command, reporting the build
+// information passed to Execute.
+func newVersionCmd(info versionInfo) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Long: `Print the version, commit, and build date of sc. The output matches
+sc --version; with --json it is an object with version, commit, and date
+fields for scripts.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, f, err := commandEnv(cmd)
+			if err != nil {
+				return err
+			}
+			if f.JSON || f.NDJSON {
+				if err := f.WriteJSON(info); err != nil {
+					return err
+				}
+				return f.Flush()
+			}
+			f.Line("sc version " + formatVersion(info.Version, info.Commit, info.Date))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+)
+
+func TestVersionCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("human", func(t *testing.T) {
+		t.Parallel()
+		out := runCmd(t, dolt.NewMockClient(), "version")
+		if want := "sc version test (commit: abc123, built: 2025-01-01)\n"; out != want {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		out := runCmd(t, dolt.NewMockClient(), "version", "--json")
+		var env struct {
+			Data versionInfo `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(out), &env); err != nil {
+			t.Fatalf("--json output should be valid JSON: %v\n%s", err, out)
+		}
+		want := versionInfo{Version: "test", Commit: "abc123", Date: "2025-01-01"}
+		if env.Data != want {
+			t.Errorf("version = %+v, want %+v", env.Data, want)
+		}
+	})
+}
@@ -2,11 +2,13 @@ module github.com/randlee/synaptic-canvas-dolt
 
 go 1.26
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/spf13/cobra v1.9.1
+)
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/go-sql-driver/mysql v1.9.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 )
@@ -5,17 +5,35 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// BranchEnv names the environment variable giving the default --branch.
+const BranchEnv = "SC_BRANCH"
+
 // Config holds the global configuration derived from CLI flags.
 type Config struct {
 	DoltDir string
 	Remote  string
+	// Branch is the Dolt branch (channel) to read: the command's --branch
+	// flag when given, otherwise $SC_BRANCH. Empty means the default branch.
+	Branch  string
 	JSON    bool
 	Quiet   bool
 	Verbose bool
+	// NDJSON selects JSON Lines output: one compact object per line.
+	NDJSON bool
+	// NoTruncate disables fitting tables to the terminal width.
+	NoTruncate bool
+	// NoColor disables colored output. It is set by --no-color or a
+	// non-empty NO_COLOR environment variable (see no-color.org).
+	NoColor bool
+	// Timeout bounds each command's run time. Zero disables the timeout.
+	Timeout time.Duration
+	// Fields limits output to the named fields. Empty means all fields.
+	Fields []string
 }
 
 // NewConfigFromFlags extracts global flag values from the given cobra command.
@@ -37,6 +55,11 @@ func NewConfigFromFlags(cmd *cobra.Command) (*Config, error) {
 		return nil, fmt.Errorf("reading --json: %w", err)
 	}
 
+	ndjson, err := flags.GetBool("ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("reading --ndjson: %w", err)
+	}
+
 	quiet, err := flags.GetBool("quiet")
 	if err != nil {
 		return nil, fmt.Errorf("reading --quiet: %w", err)
@@ -47,12 +70,43 @@ func NewConfigFromFlags(cmd *cobra.Command) (*Config, error) {
 		return nil, fmt.Errorf("reading --verbose: %w", err)
 	}
 
+	noTruncate, err := flags.GetBool("no-truncate")
+	if err != nil {
+		return nil, fmt.Errorf("reading --no-truncate: %w", err)
+	}
+
+	noColor, err := flags.GetBool("no-color")
+	if err != nil {
+		return nil, fmt.Errorf("reading --no-color: %w", err)
+	}
+
+	timeout, err := flags.GetDuration("timeout")
+	if err != nil {
+		return nil, fmt.Errorf("reading --timeout: %w", err)
+	}
+
+	branch := os.Getenv(BranchEnv)
+	if fl := cmd.Flags().Lookup("branch"); fl != nil && fl.Changed {
+		branch = fl.Value.String()
+	}
+
+	fields, err := flags.GetStringSlice("fields")
+	if err != nil {
+		return nil, fmt.Errorf("reading --fields: %w", err)
+	}
+
 	return &Config{
-		DoltDir: doltDir,
-		Remote:  remote,
-		JSON:    jsonMode,
-		Quiet:   quiet,
-		Verbose: verbose,
+		DoltDir:    doltDir,
+		Remote:     remote,
+		Branch:     branch,
+		JSON:       jsonMode,
+		NDJSON:     ndjson,
+		Quiet:      quiet,
+		Verbose:    verbose,
+		NoTruncate: noTruncate,
+		NoColor:    noColor || os.Getenv("NO_COLOR") != "",
+		Timeout:    timeout,
+		Fields:     fields,
 	}, nil
 }
 
@@ -61,6 +115,12 @@ func (c *Config) Validate() error {
 	if c.Verbose && c.Quiet {
 		return fmt.Errorf("--verbose and --quiet cannot be used together")
 	}
+	if c.JSON && c.NDJSON {
+		return fmt.Errorf("--json and --ndjson cannot be used together")
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative, got %s", c.Timeout)
+	}
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -22,8 +23,13 @@ func newTestCmd() *cobra.Command {
 	pf.String("dolt-dir", "", "Dolt database directory (default: auto-detect)")
 	pf.String("remote", "", "DoltHub remote name")
 	pf.Bool("json", false, "output as JSON")
+	pf.Bool("ndjson", false, "output as JSON Lines, one object per line")
 	pf.Bool("quiet", false, "suppress non-essential output")
 	pf.Bool("verbose", false, "enable debug logging")
+	pf.Bool("no-truncate", false, "do not truncate table cells to the terminal width")
+	pf.Bool("no-color", false, "disable colored output (also set by NO_COLOR)")
+	pf.Duration("timeout", 30*time.Second, "maximum run time per command; 0 disables the timeout")
+	pf.StringSlice("fields", nil, "only output these fields (comma-separated)")
 	return cmd
 }
 
@@ -36,6 +42,9 @@ func TestNewConfigFromFlags(t *testing.T) {
 		"--remote", "origin",
 		"--json",
 		"--verbose",
+		"--no-truncate",
+		"--timeout", "5s",
+		"--fields", "id,version",
 	})
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("command execution failed: %v", err)
@@ -61,6 +70,98 @@ func TestNewConfigFromFlags(t *testing.T) {
 	if cfg.Quiet {
 		t.Error("Quiet should be false")
 	}
+	if !cfg.NoTruncate {
+		t.Error("NoTruncate should be true")
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %s, want 5s", cfg.Timeout)
+	}
+	if strings.Join(cfg.Fields, ",") != "id,version" {
+		t.Errorf("Fields = %q, want [id version]", cfg.Fields)
+	}
+}
+
+// TestNoColor is not parallel: it sets NO_COLOR.
+func TestNoColor(t *testing.T) {
+	read := func(args ...string) bool {
+		t.Helper()
+		cmd := newTestCmd()
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("command execution failed: %v", err)
+		}
+		cfg, err := NewConfigFromFlags(cmd)
+		if err != nil {
+			t.Fatalf("NewConfigFromFlags failed: %v", err)
+		}
+		return cfg.NoColor
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if read() {
+		t.Error("NoColor should default to false")
+	}
+	if !read("--no-color") {
+		t.Error("--no-color should set NoColor")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if !read() {
+		t.Error("NO_COLOR should set NoColor")
+	}
+}
+
+// TestBranchFromEnv is not parallel: it sets SC_BRANCH.
+func TestBranchFromEnv(t *testing.T) {
+	read := func(args ...string) string {
+		t.Helper()
+		cmd := newTestCmd()
+		cmd.Flags().String("branch", "", "Dolt branch")
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("command execution failed: %v", err)
+		}
+		cfg, err := NewConfigFromFlags(cmd)
+		if err != nil {
+			t.Fatalf("NewConfigFromFlags failed: %v", err)
+		}
+		return cfg.Branch
+	}
+
+	t.Setenv(BranchEnv, "")
+	if got := read(); got != "" {
+		t.Errorf("Branch = %q, want empty by default", got)
+	}
+	t.Setenv(BranchEnv, "staging")
+	if got := read(); got != "staging" {
+		t.Errorf("Branch = %q, want staging from %s", got, BranchEnv)
+	}
+	if got := read("--branch", "main"); got != "main" {
+		t.Errorf("Branch = %q, want --branch to override %s", got, BranchEnv)
+	}
+}
+
+func TestTimeoutDefaultAndValidation(t *testing.T) {
+	t.Parallel()
+
+	cmd := newTestCmd()
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("command execution failed: %v", err)
+	}
+	cfg, err := NewConfigFromFlags(cmd)
+	if err != nil {
+		t.Fatalf("NewConfigFromFlags failed: %v", err)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("default Timeout = %s, want 30s", cfg.Timeout)
+	}
+
+	if err := (&Config{}).Validate(); err != nil {
+		t.Errorf("zero timeout should be valid: %v", err)
+	}
+	if err := (&Config{Timeout: -time.Second}).Validate(); err == nil {
+		t.Error("expected error for negative timeout")
+	}
 }
 
 func TestValidateConflictingFlags(t *testing.T) {
@@ -76,6 +177,17 @@ func TestValidateConflictingFlags(t *testing.T) {
 	}
 }
 
+func TestValidateJSONAndNDJSON(t *testing.T) {
+	t.Parallel()
+
+	if err := (&Config{JSON: true, NDJSON: true}).Validate(); err == nil {
+		t.Error("expected error for conflicting --json and --ndjson")
+	}
+	if err := (&Config{NDJSON: true}).Validate(); err != nil {
+		t.Errorf("--ndjson alone should be valid: %v", err)
+	}
+}
+
 func TestValidateNoConflict(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownField is returned when Formatter.Fields names a field the
+// output does not have.
+var ErrUnknownField = errors.New("unknown field")
+
+// columnField returns the field name of a table column: its header in
+// lower case with spaces as underscores, so "INSTALL SCOPE" is
+// install_scope. Commands choose headers whose field names are the JSON
+// keys of the same data, so one --fields value works in every mode.
+func columnField(header string) string {
+	return strings.ToLower(strings.ReplaceAll(header, " ", "_"))
+}
+
+// projectColumns selects the columns of a table named by f.Fields, in the
+// order given. Fields are matched exactly against columnField of each
+// header. An unknown field is an error listing the available ones.
+func (f *Formatter) projectColumns(headers []string, rows [][]string) ([]string, [][]string, error) {
+	if len(f.Fields) == 0 {
+		return headers, rows, nil
+	}
+	names := make([]string, len(headers))
+	for j, h := range headers {
+		names[j] = columnField(h)
+	}
+	index := make([]int, len(f.Fields))
+	for i, field := range f.Fields {
+		index[i] = -1
+		for j, name := range names {
+			if name == field {
+				index[i] = j
+				break
+			}
+		}
+		if index[i] < 0 {
+			return nil, nil, unknownField(field, names)
+		}
+	}
+	pick := func(cells []string) []string {
+		out := make([]string, len(index))
+		for i, j := range index {
+			if j < len(cells) {
+				out[i] = cells[j]
+			}
+		}
+		return out
+	}
+	projected := make([][]string, len(rows))
+	for i, row := range rows {
+		projected[i] = pick(row)
+	}
+	return pick(headers), projected, nil
+}
+
+// projectJSON reduces v, a JSON object or array of objects, to the keys
+// named by f.Fields. Keys are matched exactly. The known keys come from the
+// json tags of v's struct type, so fields omitted by omitempty are still
+// accepted; for maps they are the keys present, and an empty result accepts
+// any field. An unknown field is an error. With no Fields, v is returned
+// unchanged.
+func (f *Formatter) projectJSON(v any) (any, error) {
+	if len(f.Fields) == 0 {
+		return v, nil
+	}
+	known := jsonFieldNames(reflect.TypeOf(v))
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling JSON: %w", err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("projecting JSON: %w", err)
+	}
+	if known == nil {
+		known = mapKeys(generic)
+	}
+	for _, field := range f.Fields {
+		if len(known) > 0 && !known[field] {
+			names := make([]string, 0, len(known))
+			for k := range known {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			return nil, unknownField(field, names)
+		}
+	}
+
+	project := func(obj map[string]any) map[string]any {
+		out := make(map[string]any, len(f.Fields))
+		for _, field := range f.Fields {
+			if val, ok := obj[field]; ok {
+				out[field] = val
+			}
+		}
+		return out
+	}
+	switch g := generic.(type) {
+	case map[string]any:
+		return project(g), nil
+	case []any:
+		for i, item := range g {
+			if obj, ok := item.(map[string]any); ok {
+				g[i] = project(obj)
+			}
+		}
+		return g, nil
+	}
+	return nil, fmt.Errorf("--fields needs objects, got %T", generic)
+}
+
+// jsonFieldNames returns the JSON keys of the struct type underlying t,
+// through pointers, slices, and arrays, or nil if there is none.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	names := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = sf.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// mapKeys returns the keys of a decoded JSON object, or of every object in
+// a decoded array.
+func mapKeys(v any) map[string]bool {
+	keys := make(map[string]bool)
+	add := func(item any) {
+		if obj, ok := item.(map[string]any); ok {
+			for k := range obj {
+				keys[k] = true
+			}
+		}
+	}
+	if list, ok := v.([]any); ok {
+		for _, item := range list {
+			add(item)
+		}
+	} else {
+		add(v)
+	}
+	return keys
+}
+
+func unknownField(field string, available []string) error {
+	return fmt.Errorf("%w %q (available: %s)", ErrUnknownField, field, strings.Join(available, ", "))
+}
@@ -1,45 +1,156 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 )
 
 // Formatter controls how command output is rendered. It supports JSON mode,
 // quiet mode, and human-readable table output.
+//
+// When both JSON and Envelope are set, output is buffered instead of
+// streamed: the command result, warnings, and errors are collected and
+// written once by Flush as {"data": ..., "warnings": [...], "errors": [...]},
+// so stdout is always a single valid JSON document. Callers using envelope
+// mode must call Flush before exiting.
+//
+// NDJSON selects JSON Lines output instead: every table row or streamed
+// item is written as soon as it is available as one compact JSON object per
+// line, so large results can be piped into tools like jq -c. It takes
+// precedence over JSON and is never enveloped.
 type Formatter struct {
-	JSON   bool
-	Quiet  bool
-	Writer io.Writer
-	ErrW   io.Writer
+	JSON     bool
+	NDJSON   bool
+	Quiet    bool
+	Envelope bool
+	Writer   io.Writer
+	ErrW     io.Writer
+
+	// MaxWidth caps the width of table lines; longer cells are truncated
+	// with an ellipsis. Zero detects the terminal width when Writer is a
+	// terminal (and leaves other writers untouched); negative disables
+	// truncation. JSON output is never truncated.
+	MaxWidth int
+
+	// NoColor disables ANSI colors. Otherwise the "Warning:" and "Error:"
+	// prefixes are colored when ErrW is a terminal.
+	NoColor bool
+
+	// isTTY reports whether a writer is a terminal; nil means isTerminal.
+	isTTY func(io.Writer) bool
+
+	// Verbose reports that debug logs are written to ErrW, which disables
+	// the Spinner so that its redraws do not garble them.
+	Verbose bool
+
+	// Fields, when set, limits output to the named fields: the keys of JSON
+	// objects, and the table columns with the same names (see columnField),
+	// shown in the order given. An unknown field is an error.
+	Fields []string
+
+	// Buffered envelope contents; see Flush.
+	data     any
+	warnings []string
+	errors   []string
+}
+
+// envelope is the JSON document written by Flush in envelope mode.
+type envelope struct {
+	Data     any      `json:"data"`
+	Warnings []string `json:"warnings"`
+	Errors   []string `json:"errors"`
+}
+
+// enveloped reports whether output is buffered for Flush.
+func (f *Formatter) enveloped() bool {
+	return f.JSON && f.Envelope && !f.NDJSON
 }
 
 // NewFormatter creates a Formatter that writes to stdout and errors to stderr.
+// JSON mode uses the envelope, so the caller must call Flush when done.
 func NewFormatter(jsonMode, quiet bool) *Formatter {
 	return &Formatter{
-		JSON:   jsonMode,
-		Quiet:  quiet,
-		Writer: os.Stdout,
-		ErrW:   os.Stderr,
+		JSON:     jsonMode,
+		Quiet:    quiet,
+		Envelope: jsonMode,
+		Writer:   os.Stdout,
+		ErrW:     os.Stderr,
 	}
 }
 
+// WithWriter returns a copy of f that writes results to w, leaving f and
+// its ErrW untouched, e.g. to send a document to a file while status stays
+// on stderr. The copy starts with an empty envelope buffer of its own, so
+// in envelope mode it must be flushed separately.
+func (f *Formatter) WithWriter(w io.Writer) *Formatter {
+	c := *f
+	c.Writer = w
+	c.data, c.warnings, c.errors = nil, nil, nil
+	return &c
+}
+
+// Tee sends everything later written to Writer to w as well, e.g. to
+// capture command output in a log file. Quiet, JSON, and envelope behave as
+// before, since only the destination changes. Terminal detection, for
+// table width and colors, still looks at the original Writer.
+func (f *Formatter) Tee(w io.Writer) {
+	f.Writer = newTeeWriter(f.Writer, w)
+}
+
+// TeeErr is Tee for ErrW: warnings and errors are also written to w.
+func (f *Formatter) TeeErr(w io.Writer) {
+	f.ErrW = newTeeWriter(f.errWriter(), w)
+}
+
+// teeWriter writes to primary and a tee target, remembering primary for
+// terminal detection.
+type teeWriter struct {
+	io.Writer
+	primary io.Writer
+}
+
+// newTeeWriter returns a writer duplicating writes to primary and tee.
+func newTeeWriter(primary, tee io.Writer) *teeWriter {
+	return &teeWriter{Writer: io.MultiWriter(primary, tee), primary: primary}
+}
+
 // Table prints an aligned table with the given headers and rows.
 // In JSON mode, it marshals the data as a JSON array of objects keyed by header names.
+// In NDJSON mode, each row is written as one such object per line.
 // In quiet mode, table output is suppressed entirely.
 func (f *Formatter) Table(headers []string, rows [][]string) error {
 	if f.Quiet {
 		return nil
 	}
+	headers, rows, err := f.projectColumns(headers, rows)
+	if err != nil {
+		return err
+	}
 
+	if f.NDJSON {
+		for _, obj := range tableObjects(headers, rows) {
+			if err := f.writeLine(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	if f.JSON {
 		return f.tableAsJSON(headers, rows)
 	}
 
-	tw := tabwriter.NewWriter(f.Writer, 0, 0, 2, ' ', 0)
+	if width := f.tableWidth(); width > 0 {
+		headers, rows = fitTable(headers, rows, width)
+	}
+
+	tw := tabwriter.NewWriter(f.Writer, 0, 0, tablePadding, ' ', 0)
 
 	// Print headers.
 	for i, h := range headers {
@@ -75,8 +186,194 @@ func (f *Formatter) Table(headers []string, rows [][]string) error {
 	return tw.Flush()
 }
 
+// KeyValue prints pairs as aligned "key  value" lines for detail views,
+// with keys padded to the width of the longest. In JSON and NDJSON modes it
+// writes a single object with the keys in the given order. In quiet mode
+// output is suppressed.
+func (f *Formatter) KeyValue(pairs [][2]string) error {
+	if f.Quiet {
+		return nil
+	}
+	if f.NDJSON {
+		obj, err := f.projectJSON(keyValueObject(pairs))
+		if err != nil {
+			return err
+		}
+		return f.writeLine(obj)
+	}
+	if f.JSON {
+		return f.WriteJSON(keyValueObject(pairs))
+	}
+
+	width := 0
+	for _, kv := range pairs {
+		width = max(width, utf8.RuneCountInString(kv[0]))
+	}
+	for _, kv := range pairs {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(kv[0])+tablePadding)
+		if _, err := fmt.Fprintln(f.Writer, kv[0]+pad+kv[1]); err != nil {
+			return fmt.Errorf("writing key/value output: %w", err)
+		}
+	}
+	return nil
+}
+
+// keyValueObject marshals as a JSON object whose keys keep their order.
+type keyValueObject [][2]string
+
+// MarshalJSON implements json.Marshaler.
+func (o keyValueObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(kv[0])
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// tablePadding is the number of spaces between table columns.
+const tablePadding = 2
+
+// minColumnWidth is the narrowest a column is truncated to, ellipsis included.
+const minColumnWidth = 4
+
+// tableWidth resolves MaxWidth to the line width tables must fit, or 0 for
+// no limit.
+func (f *Formatter) tableWidth() int {
+	if f.MaxWidth != 0 {
+		return max(f.MaxWidth, 0)
+	}
+	return terminalWidth(f.Writer)
+}
+
+// terminalWidth returns the width of w if it is a terminal, taken from
+// $COLUMNS and defaulting to 80, or 0 if w is not a terminal.
+func terminalWidth(w io.Writer) int {
+	if !isTerminal(w) {
+		return 0
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
+}
+
+// isTerminal reports whether w is a file open on a terminal. A tee is a
+// terminal if the writer it wraps is.
+func isTerminal(w io.Writer) bool {
+	for {
+		t, ok := w.(*teeWriter)
+		if !ok {
+			break
+		}
+		w = t.primary
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ANSI escape sequences for the colored message prefixes.
+const (
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// paint wraps s in the given ANSI color when colors are enabled for w: NoColor
+// is unset and w is a terminal.
+func (f *Formatter) paint(w io.Writer, color, s string) string {
+	if f.NoColor {
+		return s
+	}
+	isTTY := f.isTTY
+	if isTTY == nil {
+		isTTY = isTerminal
+	}
+	if !isTTY(w) {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// fitTable truncates cells so each line of the rendered table fits within
+// width. Columns are narrowed from the last one backwards, so earlier
+// columns keep their full values for as long as possible.
+func fitTable(headers []string, rows [][]string, width int) ([]string, [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, col := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(col))
+			}
+		}
+	}
+
+	total := tablePadding * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for i := len(widths) - 1; i >= 0 && total > width; i-- {
+		cut := min(total-width, widths[i]-minColumnWidth)
+		if cut > 0 {
+			widths[i] -= cut
+			total -= cut
+		}
+	}
+
+	fit := func(cells []string) []string {
+		out := make([]string, len(cells))
+		for i, c := range cells {
+			out[i] = c
+			if i < len(widths) {
+				out[i] = truncate(c, widths[i])
+			}
+		}
+		return out
+	}
+	fitted := make([][]string, len(rows))
+	for i, row := range rows {
+		fitted[i] = fit(row)
+	}
+	return fit(headers), fitted
+}
+
+// truncate shortens s to at most n runes, ending in an ellipsis if cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
 // tableAsJSON converts table data to a JSON array of objects.
 func (f *Formatter) tableAsJSON(headers []string, rows [][]string) error {
+	return f.writeJSON(tableObjects(headers, rows))
+}
+
+// tableObjects converts each row to an object keyed by header names.
+func tableObjects(headers []string, rows [][]string) []map[string]string {
 	result := make([]map[string]string, 0, len(rows))
 	for _, row := range rows {
 		obj := make(map[string]string, len(headers))
@@ -87,11 +384,74 @@ func (f *Formatter) tableAsJSON(headers []string, rows [][]string) error {
 		}
 		result = append(result, obj)
 	}
-	return f.WriteJSON(result)
+	return result
+}
+
+// WriteStream writes items as they arrive until the channel is closed. In
+// NDJSON mode each item is written immediately as one compact JSON line;
+// otherwise the items are collected and written with WriteJSON as an
+// array. Quiet mode suppresses NDJSON output. The channel is always read to
+// the end, even after a write error, so the sender never blocks; the first
+// error is returned.
+func (f *Formatter) WriteStream(items <-chan any) error {
+	if !f.NDJSON {
+		all := []any{}
+		for item := range items {
+			all = append(all, item)
+		}
+		return f.WriteJSON(all)
+	}
+
+	var firstErr error
+	for item := range items {
+		if f.Quiet || firstErr != nil {
+			continue
+		}
+		item, err := f.projectJSON(item)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		firstErr = f.writeLine(item)
+	}
+	return firstErr
+}
+
+// writeLine marshals v to compact JSON and writes it as a single line.
+func (f *Formatter) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling JSON: %w", err)
+	}
+	if _, err := fmt.Fprintln(f.Writer, string(data)); err != nil {
+		return fmt.Errorf("writing JSON output: %w", err)
+	}
+	return nil
 }
 
 // WriteJSON marshals v to indented JSON and writes it to the formatter's writer.
+// In envelope mode v is buffered as the envelope's data instead, replacing any
+// earlier result. With Fields set, v must be an object or a list of objects
+// and is projected to those keys first.
 func (f *Formatter) WriteJSON(v any) error {
+	v, err := f.projectJSON(v)
+	if err != nil {
+		return err
+	}
+	return f.writeJSON(v)
+}
+
+// writeJSON is WriteJSON without the Fields projection.
+func (f *Formatter) writeJSON(v any) error {
+	if f.enveloped() {
+		f.data = v
+		return nil
+	}
+	return f.writeIndented(v)
+}
+
+// writeIndented marshals v to indented JSON and writes it to f.Writer.
+func (f *Formatter) writeIndented(v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling JSON: %w", err)
@@ -103,19 +463,108 @@ func (f *Formatter) WriteJSON(v any) error {
 	return nil
 }
 
-// Success prints a success message. Suppressed in quiet mode.
+// Success prints a success message. Suppressed in quiet mode and in
+// envelope mode, where it would corrupt the JSON document.
 func (f *Formatter) Success(msg string) {
-	if f.Quiet {
+	if f.Quiet || f.enveloped() {
 		return
 	}
 	_, _ = fmt.Fprintln(f.Writer, msg) //nolint:errcheck // best-effort output
 }
 
+// Line prints a single bare value, such as a package ID, with no decoration.
+// Unlike Success and Table it is written even in quiet mode, so scripts can
+// consume minimal output (like git rev-parse). It is suppressed in envelope
+// mode, where the result belongs in the JSON document.
+func (f *Formatter) Line(s string) {
+	if f.enveloped() {
+		return
+	}
+	_, _ = fmt.Fprintln(f.Writer, s) //nolint:errcheck // best-effort output
+}
+
+// Warning prints a warning message to stderr, or collects it into the
+// envelope in envelope mode. Shown regardless of quiet mode.
+func (f *Formatter) Warning(msg string) {
+	if f.enveloped() {
+		f.warnings = append(f.warnings, msg)
+		return
+	}
+	w := f.errWriter()
+	_, _ = fmt.Fprintln(w, f.paint(w, ansiYellow, "Warning:")+" "+msg) //nolint:errcheck // best-effort warning output
+}
+
 // Error prints an error message to stderr. Always shown regardless of quiet mode.
+// In envelope mode it is collected into the envelope instead, and in other
+// JSON modes it is written as {"error": msg} so stderr stays parseable.
 func (f *Formatter) Error(msg string) {
+	if f.enveloped() {
+		f.errors = append(f.errors, msg)
+		return
+	}
+	if f.JSON || f.NDJSON {
+		data, _ := json.Marshal(struct { //nolint:errcheck // a string field cannot fail to marshal
+			Error string `json:"error"`
+		}{msg})
+		_, _ = fmt.Fprintln(f.errWriter(), string(data)) //nolint:errcheck // best-effort error output
+		return
+	}
+	w := f.errWriter()
+	_, _ = fmt.Fprintln(w, f.paint(w, ansiRed, "Error:")+" "+msg) //nolint:errcheck // best-effort error output
+}
+
+// Errors prints msgs as a numbered list under an "Errors:" heading on
+// stderr, for commands such as validation that report many problems at
+// once. In envelope mode they are collected into the envelope like Error;
+// in other JSON modes they are written as {"errors": [...]}. Always shown
+// regardless of quiet mode. An empty list prints nothing.
+func (f *Formatter) Errors(msgs []string) {
+	if len(msgs) == 0 {
+		return
+	}
+	if f.enveloped() {
+		f.errors = append(f.errors, msgs...)
+		return
+	}
+	w := f.errWriter()
+	if f.JSON || f.NDJSON {
+		data, _ := json.Marshal(struct { //nolint:errcheck // a string slice cannot fail to marshal
+			Errors []string `json:"errors"`
+		}{msgs})
+		_, _ = fmt.Fprintln(w, string(data)) //nolint:errcheck // best-effort error output
+		return
+	}
+	var b strings.Builder
+	b.WriteString(f.paint(w, ansiRed, "Errors:") + "\n")
+	width := len(strconv.Itoa(len(msgs)))
+	for i, msg := range msgs {
+		fmt.Fprintf(&b, "  %*d. %s\n", width, i+1, msg)
+	}
+	_, _ = io.WriteString(w, b.String()) //nolint:errcheck // best-effort error output
+}
+
+// Flush writes the buffered envelope and resets it. It is a no-op outside
+// envelope mode, so commands can call it unconditionally.
+func (f *Formatter) Flush() error {
+	if !f.enveloped() {
+		return nil
+	}
+	env := envelope{Data: f.data, Warnings: f.warnings, Errors: f.errors}
+	if env.Warnings == nil {
+		env.Warnings = []string{}
+	}
+	if env.Errors == nil {
+		env.Errors = []string{}
+	}
+	f.data, f.warnings, f.errors = nil, nil, nil
+	return f.writeIndented(env)
+}
+
+// errWriter returns ErrW, defaulting to stderr.
+func (f *Formatter) errWriter() io.Writer {
 	w := f.ErrW
 	if w == nil {
 		w = os.Stderr
 	}
-	_, _ = fmt.Fprintln(w, "Error: "+msg) //nolint:errcheck // best-effort error output
+	return w
 }
@@ -3,8 +3,11 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestTableOutput(t *testing.T) {
@@ -132,6 +135,23 @@ func TestSuccessMessageQuiet(t *testing.T) {
 	}
 }
 
+func TestLineWritesInQuietMode(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{Quiet: true, Writer: &buf}
+	f.Success("listed 2 packages")
+	if err := f.Table([]string{"ID"}, [][]string{{"pkg-1"}}); err != nil {
+		t.Fatalf("Table returned error: %v", err)
+	}
+	f.Line("pkg-1")
+	f.Line("pkg-2")
+
+	if got := buf.String(); got != "pkg-1\npkg-2\n" {
+		t.Errorf("quiet output = %q, want only the bare lines", got)
+	}
+}
+
 func TestErrorMessage(t *testing.T) {
 	t.Parallel()
 
@@ -181,6 +201,9 @@ func TestNewFormatter(t *testing.T) {
 	if !f.Quiet {
 		t.Error("Quiet should be true")
 	}
+	if !f.Envelope {
+		t.Error("Envelope should follow JSON mode")
+	}
 	if f.Writer == nil {
 		t.Error("Writer should not be nil")
 	}
@@ -188,3 +211,589 @@ func TestNewFormatter(t *testing.T) {
 		t.Error("ErrW should not be nil")
 	}
 }
+
+func TestWarningMessage(t *testing.T) {
+	t.Parallel()
+
+	var stdBuf, errBuf bytes.Buffer
+	f := &Formatter{JSON: true, Quiet: true, Writer: &stdBuf, ErrW: &errBuf}
+	f.Warning("cache disabled")
+
+	if stdBuf.Len() > 0 {
+		t.Error("warning should not write to stdout writer")
+	}
+	if !strings.Contains(errBuf.String(), "Warning: cache disabled") {
+		t.Errorf("warning should write to stderr writer, got: %q", errBuf.String())
+	}
+}
+
+func TestEnvelopeCollectsWarningsAndErrors(t *testing.T) {
+	t.Parallel()
+
+	var stdBuf, errBuf bytes.Buffer
+	f := &Formatter{JSON: true, Envelope: true, Writer: &stdBuf, ErrW: &errBuf}
+
+	f.Warning("package pkg-1 has no sha256")
+	if err := f.Table([]string{"Name"}, [][]string{{"foo"}}); err != nil {
+		t.Fatalf("Table returned error: %v", err)
+	}
+	f.Success("listed 1 package")
+	f.Error("package pkg-2 not found")
+
+	if stdBuf.Len() > 0 {
+		t.Fatalf("envelope mode should buffer until Flush, got %q", stdBuf.String())
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if errBuf.Len() > 0 {
+		t.Errorf("envelope mode should not write to stderr, got %q", errBuf.String())
+	}
+
+	var env struct {
+		Data     []map[string]string `json:"data"`
+		Warnings []string            `json:"warnings"`
+		Errors   []string            `json:"errors"`
+	}
+	if err := json.Unmarshal(stdBuf.Bytes(), &env); err != nil {
+		t.Fatalf("stdout should be a single valid JSON document: %v\n%s", err, stdBuf.String())
+	}
+	if len(env.Data) != 1 || env.Data[0]["Name"] != "foo" {
+		t.Errorf("data = %v, want one row with Name=foo", env.Data)
+	}
+	if len(env.Warnings) != 1 || env.Warnings[0] != "package pkg-1 has no sha256" {
+		t.Errorf("warnings = %v", env.Warnings)
+	}
+	if len(env.Errors) != 1 || env.Errors[0] != "package pkg-2 not found" {
+		t.Errorf("errors = %v", env.Errors)
+	}
+}
+
+func TestEnvelopeEmptyLists(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{JSON: true, Envelope: true, Writer: &buf}
+	if err := f.WriteJSON(map[string]int{"count": 2}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"warnings": []`) || !strings.Contains(buf.String(), `"errors": []`) {
+		t.Errorf("empty warnings and errors should be arrays, got %s", buf.String())
+	}
+}
+
+func TestFlushWithoutEnvelope(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{JSON: true, Writer: &buf}
+	if err := f.WriteJSON([]string{"a"}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	before := buf.Len()
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if buf.Len() != before {
+		t.Error("Flush should be a no-op outside envelope mode")
+	}
+}
+
+func TestTableTruncatesToMaxWidth(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{Writer: &buf, MaxWidth: 30}
+	long := "A very long description that would wrap"
+	if err := f.Table([]string{"ID", "DESCRIPTION"}, [][]string{{"pkg-1", long}}); err != nil {
+		t.Fatalf("Table returned error: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if n := utf8.RuneCountInString(strings.TrimRight(line, " ")); n > 30 {
+			t.Errorf("line %q is %d runes, want <= 30", line, n)
+		}
+	}
+	if !strings.Contains(buf.String(), "pkg-1") {
+		t.Error("earlier columns should keep their full values")
+	}
+	if !strings.Contains(buf.String(), "A very long descriptio…") {
+		t.Errorf("expected description cut with an ellipsis, got:\n%s", buf.String())
+	}
+}
+
+func TestTableNoTruncation(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100)
+	tests := []struct {
+		name string
+		f    Formatter
+	}{
+		{name: "negative MaxWidth", f: Formatter{MaxWidth: -1}},
+		{name: "json mode", f: Formatter{JSON: true, MaxWidth: 10}},
+		{name: "non-terminal writer", f: Formatter{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			f := tt.f
+			f.Writer = &buf
+			if err := f.Table([]string{"ID"}, [][]string{{long}}); err != nil {
+				t.Fatalf("Table returned error: %v", err)
+			}
+			if !strings.Contains(buf.String(), long) {
+				t.Errorf("full value should survive, got:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestWithWriter(t *testing.T) {
+	t.Parallel()
+
+	var orig, other, errBuf bytes.Buffer
+	f := &Formatter{Writer: &orig, ErrW: &errBuf}
+	c := f.WithWriter(&other)
+
+	c.Line("to the copy")
+	c.Warning("status")
+	f.Line("to the original")
+
+	if f.Writer != &orig {
+		t.Error("WithWriter changed the original's Writer")
+	}
+	if c.ErrW != &errBuf {
+		t.Error("WithWriter should keep ErrW")
+	}
+	if other.String() != "to the copy\n" || orig.String() != "to the original\n" {
+		t.Errorf("copy wrote %q, original wrote %q", other.String(), orig.String())
+	}
+	if errBuf.String() != "Warning: status\n" {
+		t.Errorf("stderr = %q", errBuf.String())
+	}
+}
+
+func TestWithWriterEnvelopeIsSeparate(t *testing.T) {
+	t.Parallel()
+
+	var orig, other bytes.Buffer
+	f := &Formatter{JSON: true, Envelope: true, Writer: &orig}
+	f.Warning("original warning")
+	c := f.WithWriter(&other)
+	if err := c.WriteJSON(map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if strings.Contains(other.String(), "original warning") || !strings.Contains(other.String(), `"k": "v"`) {
+		t.Errorf("copy envelope = %s", other.String())
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !strings.Contains(orig.String(), "original warning") || strings.Contains(orig.String(), `"k"`) {
+		t.Errorf("original envelope = %s", orig.String())
+	}
+}
+
+func TestErrorMessageJSON(t *testing.T) {
+	t.Parallel()
+
+	var errBuf bytes.Buffer
+	f := &Formatter{JSON: true, Writer: &bytes.Buffer{}, ErrW: &errBuf}
+	f.Error(`bad "quote"`)
+
+	var got map[string]string
+	if err := json.Unmarshal(errBuf.Bytes(), &got); err != nil {
+		t.Fatalf("stderr is not valid JSON: %v\n%s", err, errBuf.String())
+	}
+	if got["error"] != `bad "quote"` || len(got) != 1 {
+		t.Errorf("stderr = %v, want only the error key", got)
+	}
+
+	errBuf.Reset()
+	f.JSON = false
+	f.Error("plain")
+	if errBuf.String() != "Error: plain\n" {
+		t.Errorf("plain stderr = %q", errBuf.String())
+	}
+}
+
+func TestWriteStreamNDJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{NDJSON: true, Writer: &buf}
+	items := make(chan any)
+	go func() {
+		defer close(items)
+		for i := range 3 {
+			items <- map[string]any{"n": i, "nested": map[string]string{"k": "v"}}
+		}
+	}()
+	if err := f.WriteStream(items); err != nil {
+		t.Fatalf("WriteStream returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var obj struct {
+			N int `json:"n"`
+		}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Errorf("line %d is not valid JSON: %v", i, err)
+		}
+		if obj.N != i {
+			t.Errorf("line %d has n=%d", i, obj.N)
+		}
+	}
+}
+
+func TestWriteStreamQuietDrains(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{NDJSON: true, Quiet: true, Writer: &buf}
+	items := make(chan any, 2)
+	items <- 1
+	items <- 2
+	close(items)
+	if err := f.WriteStream(items); err != nil {
+		t.Fatalf("WriteStream returned error: %v", err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("quiet NDJSON output should be suppressed, got %q", buf.String())
+	}
+	if len(items) != 0 {
+		t.Error("WriteStream should drain the channel")
+	}
+}
+
+func TestWriteStreamJSONArray(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{JSON: true, Writer: &buf}
+	items := make(chan any, 2)
+	items <- "a"
+	items <- "b"
+	close(items)
+	if err := f.WriteStream(items); err != nil {
+		t.Fatalf("WriteStream returned error: %v", err)
+	}
+	var got []string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got) != 2 {
+		t.Errorf("got %q (%v), want a two-element array", buf.String(), err)
+	}
+}
+
+func TestTableNDJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	f := &Formatter{NDJSON: true, JSON: true, Envelope: true, Writer: &buf}
+	if err := f.Table([]string{"Name"}, [][]string{{"foo"}, {"bar"}}); err != nil {
+		t.Fatalf("Table returned error: %v", err)
+	}
+	if got := buf.String(); got != "{\"Name\":\"foo\"}\n{\"Name\":\"bar\"}\n" {
+		t.Errorf("NDJSON table = %q", got)
+	}
+}
+
+func TestFieldsProjection(t *testing.T) {
+	t.Parallel()
+
+	type pkg struct {
+		ID          string `json:"id"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+	pkgs := []pkg{{ID: "a", Version: "1.0.0"}, {ID: "b", Version: "2.0.0"}}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"all fields by default", nil, `[{"id":"a","version":"1.0.0"},{"id":"b","version":"2.0.0"}]`},
+		{"selected fields", []string{"id"}, `[{"id":"a"},{"id":"b"}]`},
+		{"omitempty field accepted", []string{"id", "description"}, `[{"id":"a"},{"id":"b"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			f := &Formatter{JSON: true, Writer: &buf, Fields: tt.fields}
+			if err := f.WriteJSON(pkgs); err != nil {
+				t.Fatalf("WriteJSON: %v", err)
+			}
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, buf.Bytes()); err != nil {
+				t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+			}
+			if compact.String() != tt.want {
+				t.Errorf("got %s, want %s", compact.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsProjectionTable(t *testing.T) {
+	t.Parallel()
+
+	headers := []string{"ID", "NAME", "INSTALL SCOPE"}
+	rows := [][]string{{"a", "alpha", "user"}}
+
+	var buf bytes.Buffer
+	f := &Formatter{Writer: &buf, MaxWidth: -1, Fields: []string{"install_scope", "id"}}
+	if err := f.Table(headers, rows); err != nil {
+		t.Fatalf("Table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(strings.Fields(lines[0])) != 3 || !strings.HasPrefix(lines[0], "INSTALL SCOPE") {
+		t.Errorf("header = %q, want INSTALL SCOPE then ID only", lines[0])
+	}
+	if strings.Contains(buf.String(), "alpha") {
+		t.Errorf("unselected column rendered:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	f.JSON = true
+	if err := f.Table(headers, rows); err != nil {
+		t.Fatalf("Table JSON: %v", err)
+	}
+	var got []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if len(got) != 1 || len(got[0]) != 2 || got[0]["INSTALL SCOPE"] != "user" {
+		t.Errorf("got %v, want only INSTALL SCOPE and ID", got)
+	}
+
+	f.Fields = []string{"NAME"}
+	if err := f.Table(headers, rows); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Table err = %v, want headers themselves rejected as field names", err)
+	}
+}
+
+func TestFieldsUnknown(t *testing.T) {
+	t.Parallel()
+
+	f := &Formatter{JSON: true, Writer: &bytes.Buffer{}, Fields: []string{"id", "bogus"}}
+	err := f.WriteJSON([]struct {
+		ID string `json:"id"`
+	}{{ID: "a"}})
+	if !errors.Is(err, ErrUnknownField) || !strings.Contains(err.Error(), `"bogus"`) {
+		t.Errorf("WriteJSON err = %v, want unknown field bogus", err)
+	}
+
+	f.JSON = false
+	err = f.Table([]string{"ID"}, [][]string{{"a"}})
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Table err = %v, want ErrUnknownField", err)
+	}
+}
+
+func TestColoredPrefixes(t *testing.T) {
+	t.Parallel()
+
+	tty := func(io.Writer) bool { return true }
+	tests := []struct {
+		name      string
+		f         Formatter
+		wantColor bool
+	}{
+		{"terminal", Formatter{isTTY: tty}, true},
+		{"no-color on a terminal", Formatter{isTTY: tty, NoColor: true}, false},
+		{"not a terminal", Formatter{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var errBuf bytes.Buffer
+			f := tt.f
+			f.ErrW = &errBuf
+			f.Warning("careful")
+			f.Error("broken")
+			out := errBuf.String()
+			if got := strings.Contains(out, "\x1b["); got != tt.wantColor {
+				t.Errorf("ANSI codes present = %v, want %v: %q", got, tt.wantColor, out)
+			}
+			if !strings.Contains(out, "careful") || !strings.Contains(out, "Error:") {
+				t.Errorf("messages missing: %q", out)
+			}
+		})
+	}
+}
+
+func TestKeyValue(t *testing.T) {
+	t.Parallel()
+
+	pairs := [][2]string{{"ID", "pkg-1"}, {"Name", "alpha"}, {"Version", "1.0.0"}}
+
+	t.Run("human", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		f := &Formatter{Writer: &buf}
+		if err := f.KeyValue(pairs); err != nil {
+			t.Fatalf("KeyValue: %v", err)
+		}
+		want := "ID       pkg-1\nName     alpha\nVersion  1.0.0\n"
+		if buf.String() != want {
+			t.Errorf("output =\n%q\nwant\n%q", buf.String(), want)
+		}
+	})
+
+	t.Run("json keeps order", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		f := &Formatter{JSON: true, Writer: &buf}
+		if err := f.KeyValue(pairs); err != nil {
+			t.Fatalf("KeyValue: %v", err)
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, buf.Bytes()); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+		}
+		if want := `{"ID":"pkg-1","Name":"alpha","Version":"1.0.0"}`; compact.String() != want {
+			t.Errorf("output = %s, want %s", compact.String(), want)
+		}
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		f := &Formatter{Quiet: true, Writer: &buf}
+		if err := f.KeyValue(pairs); err != nil || buf.Len() != 0 {
+			t.Errorf("quiet KeyValue wrote %q, %v", buf.String(), err)
+		}
+	})
+}
+
+func TestErrorsList(t *testing.T) {
+	t.Parallel()
+
+	msgs := []string{"first problem", "second problem"}
+
+	t.Run("human", func(t *testing.T) {
+		t.Parallel()
+		var out, errBuf bytes.Buffer
+		f := &Formatter{Quiet: true, Writer: &out, ErrW: &errBuf}
+		f.Errors(msgs)
+		want := "Errors:\n  1. first problem\n  2. second problem\n"
+		if errBuf.String() != want {
+			t.Errorf("stderr =\n%q\nwant\n%q", errBuf.String(), want)
+		}
+		if out.Len() != 0 {
+			t.Errorf("stdout should be empty, got %q", out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		var errBuf bytes.Buffer
+		f := &Formatter{JSON: true, ErrW: &errBuf}
+		f.Errors(msgs)
+		var got struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.Unmarshal(errBuf.Bytes(), &got); err != nil {
+			t.Fatalf("stderr is not JSON: %v\n%s", err, errBuf.String())
+		}
+		if len(got.Errors) != 2 || got.Errors[1] != "second problem" {
+			t.Errorf("errors = %v, want both messages", got.Errors)
+		}
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		t.Parallel()
+		var out bytes.Buffer
+		f := &Formatter{JSON: true, Envelope: true, Writer: &out}
+		f.Errors(msgs)
+		if err := f.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		if !strings.Contains(out.String(), `"second problem"`) {
+			t.Errorf("envelope lacks the errors:\n%s", out.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		var errBuf bytes.Buffer
+		for _, f := range []*Formatter{{ErrW: &errBuf}, {JSON: true, ErrW: &errBuf}} {
+			f.Errors(nil)
+		}
+		if errBuf.Len() != 0 {
+			t.Errorf("empty list printed %q", errBuf.String())
+		}
+	})
+}
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	t.Run("human", func(t *testing.T) {
+		t.Parallel()
+		var out, errBuf, outTee, errTee bytes.Buffer
+		f := &Formatter{Writer: &out, ErrW: &errBuf}
+		f.Tee(&outTee)
+		f.TeeErr(&errTee)
+
+		f.Success("done")
+		if err := f.Table([]string{"ID"}, [][]string{{"pkg-1"}}); err != nil {
+			t.Fatal(err)
+		}
+		f.Warning("careful")
+
+		if out.String() == "" || out.String() != outTee.String() {
+			t.Errorf("stdout = %q, tee = %q, want the same content", out.String(), outTee.String())
+		}
+		if !strings.Contains(outTee.String(), "pkg-1") {
+			t.Errorf("tee should receive the table: %q", outTee.String())
+		}
+		if errBuf.String() != "Warning: careful\n" || errTee.String() != errBuf.String() {
+			t.Errorf("stderr = %q, tee = %q", errBuf.String(), errTee.String())
+		}
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		t.Parallel()
+		var out, outTee bytes.Buffer
+		f := &Formatter{Writer: &out, ErrW: &bytes.Buffer{}, Quiet: true}
+		f.Tee(&outTee)
+		f.Success("hidden")
+		f.Line("pkg-1")
+		if out.String() != "pkg-1\n" || outTee.String() != "pkg-1\n" {
+			t.Errorf("stdout = %q, tee = %q, want only the bare line", out.String(), outTee.String())
+		}
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		t.Parallel()
+		var out, outTee bytes.Buffer
+		f := &Formatter{Writer: &out, ErrW: &bytes.Buffer{}, JSON: true, Envelope: true}
+		f.Tee(&outTee)
+		if err := f.WriteJSON(map[string]int{"n": 1}); err != nil {
+			t.Fatal(err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("envelope output should wait for Flush, got %q", out.String())
+		}
+		if err := f.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(out.Bytes()) || out.String() != outTee.String() {
+			t.Errorf("stdout = %q, tee = %q, want the same JSON document", out.String(), outTee.String())
+		}
+	})
+}
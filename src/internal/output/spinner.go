@@ -0,0 +1,106 @@
+package output
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are the animation frames of a Spinner.
+var spinnerFrames = []string{"-", "\\", "|", "/"}
+
+// spinnerInterval is the time between Spinner frames.
+const spinnerInterval = 100 * time.Millisecond
+
+// ANSI escape sequences used by Spinner.
+const (
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiClearLine  = "\r\x1b[K"
+)
+
+// Spinner animates a single-line progress indicator on a terminal during
+// waits of unknown length, such as connecting to a server. Create it with
+// Formatter.Spinner. It is a no-op unless ErrW is a terminal and output is
+// neither quiet, JSON, nor verbose, so it never mixes with machine-readable
+// output or debug logs.
+type Spinner struct {
+	w       io.Writer
+	enabled bool
+	// ticker returns the channel driving the frames and the function that
+	// stops it; replaced in tests.
+	ticker func() (<-chan time.Time, func())
+
+	// mu guards stop and done, which are non-nil while running.
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Spinner returns a Spinner writing to f's ErrW.
+func (f *Formatter) Spinner() *Spinner {
+	w := f.errWriter()
+	isTTY := f.isTTY
+	if isTTY == nil {
+		isTTY = isTerminal
+	}
+	return &Spinner{
+		w:       w,
+		enabled: !f.Quiet && !f.JSON && !f.NDJSON && !f.Verbose && isTTY(w),
+		ticker: func() (<-chan time.Time, func()) {
+			t := time.NewTicker(spinnerInterval)
+			return t.C, t.Stop
+		},
+	}
+}
+
+// Start hides the cursor and animates label until Stop is called. Starting
+// a running spinner does nothing.
+func (s *Spinner) Start(label string) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	ticks, stopTicker := s.ticker()
+	_, _ = io.WriteString(s.w, ansiHideCursor) //nolint:errcheck // best-effort progress output
+	s.draw(0, label)
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		defer stopTicker()
+		for frame := 1; ; frame++ {
+			select {
+			case <-ticks:
+				s.draw(frame, label)
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop, s.done)
+}
+
+// draw writes frame n of the animation over the current line.
+func (s *Spinner) draw(n int, label string) {
+	frame := spinnerFrames[n%len(spinnerFrames)]
+	_, _ = io.WriteString(s.w, ansiClearLine+frame+" "+label) //nolint:errcheck // best-effort progress output
+}
+
+// Stop ends the animation, clears the line, and restores the cursor. It is
+// safe to call more than once and on a spinner that never started.
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+	_, _ = io.WriteString(s.w, ansiClearLine+ansiShowCursor) //nolint:errcheck // best-effort progress output
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeTicker returns a Spinner ticker driven by ticks, and a flag set when
+// the spinner stops it.
+func fakeTicker(ticks chan time.Time) (func() (<-chan time.Time, func()), *bool) {
+	stopped := new(bool)
+	return func() (<-chan time.Time, func()) {
+		return ticks, func() { *stopped = true }
+	}, stopped
+}
+
+func TestSpinnerAdvancesFrames(t *testing.T) {
+	t.Parallel()
+
+	var errBuf bytes.Buffer
+	f := &Formatter{ErrW: &errBuf, isTTY: func(io.Writer) bool { return true }}
+	s := f.Spinner()
+	ticks := make(chan time.Time)
+	var stopped *bool
+	s.ticker, stopped = fakeTicker(ticks)
+
+	s.Start("connecting")
+	ticks <- time.Time{}
+	ticks <- time.Time{}
+	s.Stop()
+	s.Stop() // a second Stop is a no-op
+
+	out := errBuf.String()
+	want := ansiHideCursor +
+		ansiClearLine + "- connecting" +
+		ansiClearLine + "\\ connecting" +
+		ansiClearLine + "| connecting" +
+		ansiClearLine + ansiShowCursor
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if !*stopped {
+		t.Error("Stop should stop the ticker")
+	}
+}
+
+func TestSpinnerDisabled(t *testing.T) {
+	t.Parallel()
+
+	tty := func(io.Writer) bool { return true }
+	tests := []struct {
+		name string
+		f    Formatter
+	}{
+		{"not a terminal", Formatter{}},
+		{"quiet", Formatter{Quiet: true, isTTY: tty}},
+		{"json", Formatter{JSON: true, isTTY: tty}},
+		{"ndjson", Formatter{NDJSON: true, isTTY: tty}},
+		{"verbose", Formatter{Verbose: true, isTTY: tty}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var errBuf bytes.Buffer
+			f := tt.f
+			f.ErrW = &errBuf
+			s := f.Spinner()
+			s.ticker = func() (<-chan time.Time, func()) {
+				t.Error("a disabled spinner should not start a ticker")
+				return nil, func() {}
+			}
+			s.Start("connecting")
+			s.Stop()
+			if errBuf.Len() != 0 {
+				t.Errorf("wrote %q, want nothing", errBuf.String())
+			}
+		})
+	}
+}
+
+func TestSpinnerStopWithoutStart(t *testing.T) {
+	t.Parallel()
+	var errBuf bytes.Buffer
+	f := &Formatter{ErrW: &errBuf, isTTY: func(io.Writer) bool { return true }}
+	f.Spinner().Stop()
+	if errBuf.Len() != 0 {
+		t.Errorf("wrote %q, want nothing", errBuf.String())
+	}
+}
@@ -17,6 +17,6 @@ var (
 
 func main() {
 	if err := cmd.Execute(version, commit, date); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,179 @@
+// Package cache stores built package manifests on disk so repeated exports
+// and installs can skip re-querying Dolt, and can work offline.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// ManifestCache stores manifests as JSON files under Dir, one per
+// branch/id/sha key. Because the key includes the package's aggregate SHA,
+// a changed package never hits a stale entry; older entries for the same
+// branch and ID are removed when a new SHA is seen.
+type ManifestCache struct {
+	// Dir is the cache root, typically DefaultDir().
+	Dir string
+	// TTL bounds how long an entry is served. Zero means entries never expire.
+	TTL time.Duration
+
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// entry is the on-disk format of a cached manifest.
+type entry struct {
+	StoredAt time.Time        `json:"stored_at"`
+	Manifest *models.Manifest `json:"manifest"`
+	// ConfigFiles is stored alongside the manifest because the field is
+	// excluded from the manifest's own JSON encoding.
+	ConfigFiles []string `json:"config_files,omitempty"`
+}
+
+// NewManifestCache returns a cache rooted at dir.
+func NewManifestCache(dir string, ttl time.Duration) *ManifestCache {
+	return &ManifestCache{Dir: dir, TTL: ttl, now: time.Now}
+}
+
+// DefaultDir returns ~/.sc/cache.
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating manifest cache: %w", err)
+	}
+	return filepath.Join(home, ".sc", "cache"), nil
+}
+
+// packageDir returns the directory holding every cached SHA of one package.
+// Components are path-escaped so branch names like "feature/x" stay one level.
+func (c *ManifestCache) packageDir(branch, id string) string {
+	if branch == "" {
+		branch = "_default"
+	}
+	return filepath.Join(c.Dir, url.PathEscape(branch), url.PathEscape(id))
+}
+
+func (c *ManifestCache) path(branch, id, sha string) string {
+	return filepath.Join(c.packageDir(branch, id), url.PathEscape(sha)+".json")
+}
+
+// Get returns the cached manifest for branch/id at sha. Entries for other
+// SHAs of the same package are invalidated, and expired or unreadable
+// entries are removed and reported as a miss. An empty sha is always a miss.
+func (c *ManifestCache) Get(branch, id, sha string) (*models.Manifest, bool) {
+	if sha == "" {
+		return nil, false
+	}
+	c.invalidateOthers(branch, id, sha)
+
+	path := c.path(branch, id, sha)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Manifest == nil {
+		slog.Debug("discarding unreadable manifest cache entry", "path", path, "error", err)
+		_ = os.Remove(path)
+		return nil, false
+	}
+	if c.expired(e.StoredAt) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	e.Manifest.ConfigFiles = e.ConfigFiles
+	return e.Manifest, true
+}
+
+// Put stores m as the manifest for branch/id at sha, replacing entries for
+// any other SHA. Packages without a SHA are not cached, since a stale entry
+// could not be detected.
+func (c *ManifestCache) Put(branch, id, sha string, m *models.Manifest) error {
+	if sha == "" || m == nil {
+		return nil
+	}
+	c.invalidateOthers(branch, id, sha)
+
+	data, err := json.Marshal(entry{StoredAt: c.now(), Manifest: m, ConfigFiles: m.ConfigFiles})
+	if err != nil {
+		return fmt.Errorf("encoding cached manifest %q: %w", id, err)
+	}
+	dir := c.packageDir(branch, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating manifest cache directory: %w", err)
+	}
+	// Write to a temp file and rename so readers never see a partial entry.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("writing cached manifest %q: %w", id, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("writing cached manifest %q: %w", id, err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("writing cached manifest %q: %w", id, err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(branch, id, sha)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("writing cached manifest %q: %w", id, err)
+	}
+	return nil
+}
+
+// Evict removes every expired entry under Dir. It is a no-op when TTL is zero.
+func (c *ManifestCache) Evict() error {
+	if c.TTL <= 0 {
+		return nil
+	}
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e entry
+		if json.Unmarshal(data, &e) != nil || c.expired(e.StoredAt) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("evicting manifest cache: %w", err)
+	}
+	return nil
+}
+
+// invalidateOthers removes entries for branch/id whose SHA is not sha.
+func (c *ManifestCache) invalidateOthers(branch, id, sha string) {
+	dir := c.packageDir(branch, id)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	keep := url.PathEscape(sha) + ".json"
+	for _, e := range entries {
+		if e.Name() != keep && strings.HasSuffix(e.Name(), ".json") {
+			slog.Debug("invalidating stale manifest cache entry", "id", id, "entry", e.Name())
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+func (c *ManifestCache) expired(storedAt time.Time) bool {
+	return c.TTL > 0 && c.now().Sub(storedAt) > c.TTL
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestManifestCacheHitAndMiss(t *testing.T) {
+	t.Parallel()
+	c := NewManifestCache(t.TempDir(), time.Hour)
+
+	if _, ok := c.Get("main", "pkg-1", "sha-1"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	if err := c.Put("main", "pkg-1", "sha-1", &models.Manifest{ID: "pkg-1", Name: "alpha"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	m, ok := c.Get("main", "pkg-1", "sha-1")
+	if !ok {
+		t.Fatal("expected hit after Put")
+	}
+	if m.Name != "alpha" {
+		t.Errorf("Name = %q, want %q", m.Name, "alpha")
+	}
+	if _, ok := c.Get("staging", "pkg-1", "sha-1"); ok {
+		t.Error("entries must be keyed by branch")
+	}
+}
+
+func TestManifestCacheKeepsConfigFiles(t *testing.T) {
+	t.Parallel()
+	c := NewManifestCache(t.TempDir(), 0)
+
+	want := &models.Manifest{ID: "pkg-1", ConfigFiles: []string{"plugin.json"}}
+	if err := c.Put("main", "pkg-1", "sha-1", want); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	m, ok := c.Get("main", "pkg-1", "sha-1")
+	if !ok {
+		t.Fatal("expected hit after Put")
+	}
+	if len(m.ConfigFiles) != 1 || m.ConfigFiles[0] != "plugin.json" {
+		t.Errorf("ConfigFiles = %v, want [plugin.json]", m.ConfigFiles)
+	}
+}
+
+func TestManifestCacheShaMismatchInvalidates(t *testing.T) {
+	t.Parallel()
+	c := NewManifestCache(t.TempDir(), 0)
+
+	if err := c.Put("main", "pkg-1", "sha-old", &models.Manifest{ID: "pkg-1"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := c.Get("main", "pkg-1", "sha-new"); ok {
+		t.Fatal("expected miss for a different sha")
+	}
+	if _, err := os.Stat(c.path("main", "pkg-1", "sha-old")); !os.IsNotExist(err) {
+		t.Errorf("stale entry should be removed, stat err = %v", err)
+	}
+}
+
+func TestManifestCacheTTL(t *testing.T) {
+	t.Parallel()
+	c := NewManifestCache(t.TempDir(), time.Minute)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	if err := c.Put("main", "pkg-1", "sha-1", &models.Manifest{ID: "pkg-1"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := c.Put("main", "pkg-2", "sha-2", &models.Manifest{ID: "pkg-2"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("main", "pkg-1", "sha-1"); ok {
+		t.Error("expected expired entry to miss")
+	}
+	if err := c.Evict(); err != nil {
+		t.Fatalf("Evict failed: %v", err)
+	}
+	if _, err := os.Stat(c.path("main", "pkg-2", "sha-2")); !os.IsNotExist(err) {
+		t.Errorf("Evict should remove expired entries, stat err = %v", err)
+	}
+}
+
+func TestManifestCacheNoSha(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	c := NewManifestCache(dir, 0)
+
+	if err := c.Put("main", "pkg-1", "", &models.Manifest{ID: "pkg-1"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := c.Get("main", "pkg-1", ""); ok {
+		t.Error("packages without a sha must not be served from cache")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "main")); len(entries) != 0 {
+		t.Errorf("expected nothing written, found %d entries", len(entries))
+	}
+}
+
+func TestManifestCacheBranchWithSlash(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	c := NewManifestCache(dir, 0)
+
+	if err := c.Put("feature/x", "pkg-1", "sha-1", &models.Manifest{ID: "pkg-1"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := c.Get("feature/x", "pkg-1", "sha-1"); !ok {
+		t.Error("expected hit for branch containing a slash")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feature")); !os.IsNotExist(err) {
+		t.Error("branch name should not create nested directories")
+	}
+}
@@ -4,15 +4,25 @@
 package dolt
 
 import (
+	"container/list"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	// MySQL driver for database/sql — Dolt exposes a MySQL-compatible interface.
 	_ "github.com/go-sql-driver/mysql"
 
+	"github.com/randlee/synaptic-canvas-dolt/pkg/cache"
 	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
 )
 
@@ -21,22 +31,198 @@ type ListOptions struct {
 	// Branch specifies the Dolt branch (channel) to query.
 	// Empty string means use the current/default branch.
 	Branch string
+
+	// Tags restricts results to packages carrying the listed tags, combined
+	// according to TagMatch. Empty means no tag filtering.
+	Tags []string
+
+	// TagMatch selects whether a package must carry all of Tags (the
+	// default) or any one of them.
+	TagMatch TagMatch
+
+	// InstallScope restricts results to packages with this install scope.
+	// Empty means no scope filtering; any other value must be valid.
+	InstallScope models.InstallScope
+
+	// IncludeAnyScope widens an InstallScope filter to also match packages
+	// whose scope is "any", since those install in every scope.
+	IncludeAnyScope bool
+
+	// AsOfTime, if set, reads the packages as they were at that time on the
+	// current branch, using Dolt's AS OF. It applies to ListPackages,
+	// ListPackagesIter, SearchByTags, CountPackages, GetPackages, and
+	// GetStats, and cannot be combined with Branch.
+	AsOfTime time.Time
+
+	// Limit caps the number of packages returned and Offset skips that many
+	// first, for paging through ListPackages, ListPackagesIter, and
+	// SearchByTags. Zero means no limit and no offset. Results are ordered
+	// by name then ID, so pages are stable.
+	Limit  int
+	Offset int
+}
+
+// validate reports options that cannot be turned into a query.
+func (o ListOptions) validate() error {
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative, got %d and %d", o.Limit, o.Offset)
+	}
+	if !o.AsOfTime.IsZero() && o.Branch != "" {
+		return fmt.Errorf("as-of time and branch %q cannot be combined", o.Branch)
+	}
+	if o.InstallScope != "" && !o.InstallScope.IsValid() {
+		return fmt.Errorf("invalid install scope %q: must be %q or %q",
+			o.InstallScope, models.InstallScopeAny, models.InstallScopeLocalOnly)
+	}
+	return nil
+}
+
+// TagMatch controls how multiple tags in ListOptions.Tags are combined.
+type TagMatch int
+
+const (
+	// TagMatchAll requires a package to carry every requested tag.
+	TagMatchAll TagMatch = iota
+	// TagMatchAny requires a package to carry at least one requested tag.
+	TagMatchAny
+)
+
+// Searchable fields for SearchOptions.Fields.
+const (
+	SearchFieldName        = "name"
+	SearchFieldDescription = "description"
+	SearchFieldTags        = "tags"
+)
+
+// SearchOptions controls Search.
+type SearchOptions struct {
+	// Branch specifies the Dolt branch (channel) to search. Empty string
+	// means use the current/default branch.
+	Branch string
+
+	// Fields restricts the search to some of SearchFieldName,
+	// SearchFieldDescription, and SearchFieldTags. Empty means all three.
+	Fields []string
+
+	// Limit caps the number of results. Zero means no limit.
+	Limit int
+}
+
+// validate reports options that cannot be turned into a query.
+func (o SearchOptions) validate() error {
+	if o.Limit < 0 {
+		return fmt.Errorf("invalid search limit %d: must not be negative", o.Limit)
+	}
+	for _, f := range o.Fields {
+		switch f {
+		case SearchFieldName, SearchFieldDescription, SearchFieldTags:
+		default:
+			return fmt.Errorf("invalid search field %q: must be %q, %q, or %q",
+				f, SearchFieldName, SearchFieldDescription, SearchFieldTags)
+		}
+	}
+	return nil
+}
+
+// checkTerm rejects a term that no searched field could match: one that
+// cannot be a tag, when only tags are searched.
+func (o SearchOptions) checkTerm(term string) error {
+	if o.searches(SearchFieldName) || o.searches(SearchFieldDescription) {
+		return nil
+	}
+	return ValidateSearchTag(term)
+}
+
+// searchesTag reports whether term is matched against tags under o. A term
+// that cannot be a tag, such as one with a space, is only matched against
+// the other fields.
+func (o SearchOptions) searchesTag(term string) bool {
+	return o.searches(SearchFieldTags) && ValidateSearchTag(term) == nil
+}
+
+// searches reports whether field is searched under o.
+func (o SearchOptions) searches(field string) bool {
+	if len(o.Fields) == 0 {
+		return true
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
 }
 
+// getPackagesChunkSize caps the number of IDs in one GetPackages IN list.
+const getPackagesChunkSize = 500
+
+// statsTopTags is the number of tags reported in CatalogStats.TopTags.
+const statsTopTags = 10
+
 // Client defines the interface for querying the Synaptic Canvas Dolt database.
 // All methods accept a context for cancellation and timeout support.
 type Client interface {
 	// ListPackages returns all packages, optionally filtered by branch.
 	ListPackages(ctx context.Context, opts ListOptions) ([]models.Package, error)
 
-	// GetPackage retrieves a single package by ID.
+	// ListPackagesIter returns an iterator over the same packages as
+	// ListPackages without buffering them. The caller must Close it.
+	ListPackagesIter(ctx context.Context, opts ListOptions) (*PackageIterator, error)
+
+	// ForEachPackage calls fn for every package ListPackages would return,
+	// fetching pageSize at a time so the full list is never buffered. It
+	// stops at the first error from fn or the context. opts.Limit and
+	// opts.Offset are ignored; a pageSize of zero or less uses
+	// DefaultPageSize.
+	ForEachPackage(ctx context.Context, opts ListOptions, pageSize int, fn func(models.Package) error) error
+
+	// SearchByTags returns the packages carrying tags, all of them or any
+	// per opts.TagMatch, ordered by name. At least one tag is required.
+	SearchByTags(ctx context.Context, tags []string, opts ListOptions) ([]models.Package, error)
+
+	// Search returns the packages whose name or description contains term,
+	// or that carry term as a tag, within opts.Fields. Packages named
+	// exactly term come first, then the rest by name.
+	Search(ctx context.Context, term string, opts SearchOptions) ([]models.Package, error)
+
+	// CountPackages returns the number of packages ListPackages would return
+	// for the same options.
+	CountPackages(ctx context.Context, opts ListOptions) (int, error)
+
+	// GetPackage retrieves a single package by ID. Returns ErrNotFound if
+	// no package has that ID.
 	GetPackage(ctx context.Context, id string) (*models.Package, error)
 
+	// GetPackages retrieves the packages with the given IDs that match opts,
+	// in the order requested. IDs with no matching package are omitted.
+	GetPackages(ctx context.Context, ids []string, opts ListOptions) ([]models.Package, error)
+
+	// GetManifest returns the assembled manifest of a package on
+	// opts.Branch. Returns ErrNotFound if no package has that ID.
+	GetManifest(ctx context.Context, id string, opts ListOptions) (*models.Manifest, error)
+
+	// GetBundle returns a package and the raw rows of its files, deps,
+	// hooks and questions on opts.Branch. Unlike GetManifest, file Content
+	// is included. Returns ErrNotFound if no package has that ID.
+	GetBundle(ctx context.Context, id string, opts ListOptions) (*models.PackageBundle, error)
+
+	// WithDatabase returns a client for database name on the same server,
+	// leaving the receiver on its own database. Names failing
+	// ValidateDatabaseName are rejected. The returned client must be
+	// closed separately.
+	WithDatabase(name string) (Client, error)
+
 	// GetPackageFiles retrieves all files belonging to a package.
 	GetPackageFiles(ctx context.Context, packageID string) ([]models.PackageFile, error)
+	// GetPackageFileMeta retrieves the files of a package without their
+	// content; Content is left empty in the returned files.
+	GetPackageFileMeta(ctx context.Context, packageID string, opts ListOptions) ([]models.PackageFile, error)
 
 	// GetPackageDeps retrieves all dependencies for a package.
 	GetPackageDeps(ctx context.Context, packageID string) ([]models.PackageDep, error)
+	// GetDependents returns the sorted IDs of the packages on opts.Branch
+	// that depend on package id, e.g. to check before removing it.
+	GetDependents(ctx context.Context, id string, opts ListOptions) ([]string, error)
 
 	// GetPackageHooks retrieves all hooks for a package.
 	GetPackageHooks(ctx context.Context, packageID string) ([]models.PackageHook, error)
@@ -45,17 +231,99 @@ type Client interface {
 	GetPackageQuestions(ctx context.Context, packageID string) ([]models.PackageQuestion, error)
 
 	// ResolveVariant resolves a logical package ID and agent profile to a
-	// concrete variant package ID. Returns empty string if no variant exists.
+	// concrete variant package ID. Returns ErrNotFound if no variant exists.
 	ResolveVariant(ctx context.Context, logicalID, agentProfile string) (string, error)
 
+	// ResolveVariantChain tries each profile in order and returns the
+	// variant for the first one that exists, e.g. "claude-code-opus" then
+	// "claude-code". Returns ErrNotFound if none match.
+	ResolveVariantChain(ctx context.Context, logicalID string, profiles []string) (string, error)
+
+	// ListVariants returns every variant of a logical package ordered by
+	// agent profile, on the branch selected by opts.
+	ListVariants(ctx context.Context, logicalID string, opts ListOptions) ([]models.PackageVariant, error)
+
+	// GetStats summarizes the packages matching opts: totals per install
+	// scope, file counts per file type, and the most used tags.
+	GetStats(ctx context.Context, opts ListOptions) (*models.CatalogStats, error)
+
+	// ListTags returns every tag used by the packages matching opts with
+	// the number of packages carrying it, by descending count then tag.
+	ListTags(ctx context.Context, opts ListOptions) ([]models.TagCount, error)
+
+	// CurrentBranch returns the Dolt branch the session is on.
+	CurrentBranch(ctx context.Context) (string, error)
+
+	// ChangedPackagesSince returns the sorted IDs of the packages added,
+	// modified, or removed between sinceCommit and the tip of branch,
+	// counting changes to their files, deps, hooks, and questions. An
+	// empty branch means the session's HEAD.
+	ChangedPackagesSince(ctx context.Context, sinceCommit, branch string) ([]string, error)
+
+	// GetStatus returns the tables with uncommitted changes on the current
+	// branch, from dolt_status. A clean working set yields an empty slice.
+	GetStatus(ctx context.Context) ([]models.StatusEntry, error)
+
+	// GetConflicts returns the tables with unresolved merge conflicts on
+	// the current branch. A repository mid-merge may read inconsistently.
+	GetConflicts(ctx context.Context) ([]models.Conflict, error)
+	// HasConflicts reports whether any table has unresolved conflicts.
+	HasConflicts(ctx context.Context) (bool, error)
+
+	// QueryRaw runs a read-only SELECT, SHOW, DESCRIBE, or WITH statement.
+	// The caller must Close the returned rows.
+	QueryRaw(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+
 	// Close releases database resources.
 	Close() error
 }
 
 // SQLClient implements Client using database/sql with a MySQL-compatible driver.
 type SQLClient struct {
-	db       *sql.DB
+	cfg      Config
 	database string
+	observer Observer
+	// log receives the client's debug and warning logs.
+	log *slog.Logger
+	// queryTimeout bounds each method call whose context has no deadline.
+	queryTimeout time.Duration
+	// open creates a new *sql.DB for cfg. It is used to reconnect after the
+	// server drops the connection and is replaceable in tests.
+	open func(Config) (*sql.DB, error)
+
+	// cache holds built manifests when Config.CacheEnabled is set.
+	cache *cache.ManifestCache
+
+	// mu guards db, stmts, and branch, which are replaced on reconnect and
+	// on a branch switch.
+	mu sync.Mutex
+	db *sql.DB
+	// stmts caches prepared statements by query text, pointing into
+	// stmtLRU, which orders them from most to least recently used.
+	// Statements belong to db and are closed whenever it is replaced.
+	stmts   map[string]*list.Element
+	stmtLRU *list.List
+	// maxStmts bounds the statement cache; the least recently used
+	// statement is closed when it is exceeded.
+	maxStmts int
+	// branch is the branch db was opened on, as part of its database name,
+	// so every pooled connection is on it. Empty means the default branch.
+	branch string
+	// switchMu serializes switchBranch so that concurrent callers cannot
+	// interleave the compare against branch with the pool swap.
+	switchMu sync.Mutex
+
+	// inflightMu guards inflight and idle, the registry of open iterators
+	// that CloseContext drains.
+	inflightMu sync.Mutex
+	// inflight counts open iterators.
+	inflight int
+	// idle, when non-nil, is closed as inflight drops to zero.
+	idle chan struct{}
+
+	// onClose, if set, runs after the connection is closed, e.g. to stop
+	// the embedded server opened by OpenEmbedded.
+	onClose func() error
 }
 
 // Config holds connection parameters for the Dolt SQL server.
@@ -65,6 +333,61 @@ type Config struct {
 	User     string
 	Password string //nolint:gosec // Not a hardcoded credential; holds runtime config.
 	Database string
+
+	// Socket, if set, is the path of the server's Unix socket. Host and
+	// Port are ignored when it is non-empty.
+	Socket string
+
+	// Params are extra driver parameters appended to the DSN query string
+	// in sorted key order, e.g. charset, readTimeout, or interpolateParams.
+	// A key that is also a default, such as parseTime, overrides it.
+	// multiStatements and allowAllFiles are rejected by Open: the first
+	// would let QueryRaw run a write after a SELECT, the second would let
+	// the server read any local file.
+	Params map[string]string
+
+	// Logger, if set, receives the client's logs, so callers can attach
+	// attributes such as component=dolt. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Observer, if set, is notified of the duration and outcome of every
+	// query the client issues. Defaults to NopObserver.
+	Observer Observer
+
+	// QueryTimeout, when positive, is applied to every client method whose
+	// context carries no deadline, so a hung connection cannot block forever.
+	// A deadline supplied by the caller always takes precedence.
+	QueryTimeout time.Duration
+
+	// LogQueries logs the text and arguments of every statement at Debug
+	// before it runs, for debugging. Secret-looking arguments are redacted.
+	LogQueries bool
+
+	// StrictValidation makes ListPackages and GetPackage reject rows that
+	// fail models.Package.Validate instead of returning them.
+	StrictValidation bool
+
+	// SessionVars are system variables set on every pooled connection, e.g.
+	// foreign_key_checks. Like ReadOnly they are sent in the DSN; values are
+	// quoted as SQL string literals unless numeric. Names must be plain
+	// identifiers that are neither driver options nor Params keys.
+	SessionVars map[string]string
+
+	// ReadOnly marks every pooled connection read-only, so any accidental
+	// write is rejected by the server. It is sent in the DSN, which the
+	// driver applies to each new connection, and overrides a
+	// transaction_read_only key in Params. The sc CLI only reads the
+	// catalog; admin tooling that writes must opt out explicitly.
+	ReadOnly bool
+
+	// CacheEnabled makes GetManifest consult an on-disk manifest cache
+	// before querying, keyed by branch, package ID, and package SHA.
+	CacheEnabled bool
+	// CacheDir is the manifest cache root. Empty means ~/.sc/cache.
+	CacheDir string
+	// CacheTTL bounds the age of cached manifests. Zero means no expiry;
+	// entries are still invalidated when the package SHA changes.
+	CacheTTL time.Duration
 }
 
 // DefaultConfig returns a Config with Dolt's default local settings.
@@ -75,108 +398,874 @@ func DefaultConfig() Config {
 		User:     "root",
 		Password: "",
 		Database: "synaptic_canvas",
+		ReadOnly: true,
 	}
 }
 
-// DSN returns the MySQL-format data source name for the configuration.
+// DSN returns the MySQL-format data source name for the configuration,
+// connecting over Socket when it is set and over TCP otherwise. The query
+// string holds parseTime=true, then transaction_read_only=1 when ReadOnly
+// is set, then Params and SessionVars in sorted order; values are
+// query-escaped. DSN does not validate c; Open does.
 func (c Config) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
-		c.User, c.Password, c.Host, c.Port, c.Database)
+	addr := fmt.Sprintf("tcp(%s:%d)", c.Host, c.Port)
+	if c.Socket != "" {
+		addr = fmt.Sprintf("unix(%s)", c.Socket)
+	}
+	return fmt.Sprintf("%s:%s@%s/%s?%s",
+		c.User, c.Password, addr, url.PathEscape(c.Database), c.dsnParams())
+}
+
+// readOnlyParam is the DSN parameter ReadOnly sets. The driver runs
+// unknown parameters as SET statements on every new connection.
+const readOnlyParam = "transaction_read_only"
+
+// dsnParams returns the DSN query string: the defaults not overridden by
+// Params, the read-only setting, then Params and SessionVars sorted by key.
+func (c Config) dsnParams() string {
+	var parts []string
+	if _, ok := c.Params["parseTime"]; !ok {
+		parts = append(parts, "parseTime=true")
+	}
+	if c.ReadOnly {
+		parts = append(parts, readOnlyParam+"=1")
+	}
+	values := make(map[string]string, len(c.Params)+len(c.SessionVars))
+	for k, v := range c.Params {
+		values[k] = v
+	}
+	for k, v := range c.SessionVars {
+		values[k] = sqlLiteral(v)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if c.ReadOnly && k == readOnlyParam {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+url.QueryEscape(values[k]))
+	}
+	return strings.Join(parts, "&")
+}
+
+// sessionVarName matches a system variable name that is safe to splice into
+// the SET statement the driver builds from the DSN.
+var sessionVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// numericLiteral matches a value sent to the server unquoted, so that
+// variables such as foreign_key_checks receive a number, not a string.
+var numericLiteral = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// driverParams are the DSN parameters the MySQL driver consumes as its own
+// options instead of sending them to the server as SET statements.
+var driverParams = map[string]bool{
+	"allowAllFiles": true, "allowCleartextPasswords": true, "allowFallbackToPlaintext": true,
+	"allowNativePasswords": true, "allowOldPasswords": true, "charset": true,
+	"checkConnLiveness": true, "clientFoundRows": true, "collation": true,
+	"columnsWithAlias": true, "compress": true, "connectionAttributes": true,
+	"interpolateParams": true, "loc": true, "maxAllowedPacket": true,
+	"multiStatements": true, "parseTime": true, "readTimeout": true,
+	"rejectReadOnly": true, "serverPubKey": true, "strict": true,
+	"timeTruncate": true, "timeout": true, "tls": true, "writeTimeout": true,
+}
+
+// unsafeParams are driver options Open refuses in Params, with the reason.
+var unsafeParams = map[string]string{
+	"multiStatements": "it would let one query run several statements",
+	"allowAllFiles":   "it would let the server read any local file",
+}
+
+// sqlLiteral returns v as the SQL literal the driver sends for a session
+// variable: unchanged if numeric, otherwise single-quoted with quotes
+// doubled and backslashes escaped.
+func sqlLiteral(v string) string {
+	if numericLiteral.MatchString(v) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+// validate checks the parts of c that DSN splices into SQL or that would
+// weaken the connection, so that a bad Config fails Open before anything is
+// sent.
+func (c Config) validate() error {
+	for name := range c.Params {
+		if reason, ok := unsafeParams[name]; ok {
+			return fmt.Errorf("driver parameter %s is not allowed: %s", name, reason)
+		}
+	}
+	for name := range c.SessionVars {
+		if !sessionVarName.MatchString(name) || driverParams[name] {
+			return fmt.Errorf("invalid session variable name %q", name)
+		}
+		if _, ok := c.Params[name]; ok {
+			return fmt.Errorf("session variable %q is also set in Params", name)
+		}
+	}
+	return nil
+}
+
+// redactedPassword replaces a set password wherever a Config is serialized.
+const redactedPassword = "***"
+
+// maskedPassword returns redactedPassword if Password is set, else "".
+func (c Config) maskedPassword() string {
+	if c.Password == "" {
+		return ""
+	}
+	return redactedPassword
+}
+
+// configJSON is the JSON form of a Config. Logger and Observer are
+// omitted, and Password is masked. Every other Config field must have a
+// field of the same name here; TestConfigJSONFieldParity checks.
+type configJSON struct {
+	Host             string            `json:"host"`
+	Port             int               `json:"port"`
+	User             string            `json:"user"`
+	Password         string            `json:"password,omitempty"`
+	Database         string            `json:"database"`
+	Socket           string            `json:"socket,omitempty"`
+	Params           map[string]string `json:"params,omitempty"`
+	QueryTimeout     time.Duration     `json:"query_timeout,omitempty"`
+	LogQueries       bool              `json:"log_queries"`
+	StrictValidation bool              `json:"strict_validation"`
+	SessionVars      map[string]string `json:"session_vars,omitempty"`
+	ReadOnly         bool              `json:"read_only"`
+	CacheEnabled     bool              `json:"cache_enabled"`
+	CacheDir         string            `json:"cache_dir,omitempty"`
+	CacheTTL         time.Duration     `json:"cache_ttl,omitempty"`
+}
+
+// MarshalJSON encodes the configuration with Password replaced by "***",
+// so a Config written as JSON, for example by a diagnostics command,
+// cannot leak the password.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSON{
+		Host:             c.Host,
+		Port:             c.Port,
+		User:             c.User,
+		Password:         c.maskedPassword(),
+		Database:         c.Database,
+		Socket:           c.Socket,
+		Params:           c.Params,
+		QueryTimeout:     c.QueryTimeout,
+		LogQueries:       c.LogQueries,
+		StrictValidation: c.StrictValidation,
+		SessionVars:      c.SessionVars,
+		ReadOnly:         c.ReadOnly,
+		CacheEnabled:     c.CacheEnabled,
+		CacheDir:         c.CacheDir,
+		CacheTTL:         c.CacheTTL,
+	})
+}
+
+// String formats the configuration for %v and text logs, with Password
+// replaced by "***".
+func (c Config) String() string {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return "dolt.Config{}"
+	}
+	return "dolt.Config" + string(data)
+}
+
+// GoString formats the configuration for %#v the same way as String, so
+// that it cannot print Password either.
+func (c Config) GoString() string {
+	return c.String()
+}
+
+// LogValue implements slog.LogValuer, logging the connection settings with
+// Password replaced by "***".
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("host", c.Host),
+		slog.Int("port", c.Port),
+		slog.String("user", c.User),
+		slog.String("password", c.maskedPassword()),
+		slog.String("database", c.Database),
+		slog.String("socket", c.Socket),
+		slog.Bool("read_only", c.ReadOnly),
+	)
 }
 
 // NewSQLClient creates a new SQLClient connected to the Dolt SQL server.
+// cfg supplies the database name and client options, and is retained so the
+// client can reconnect if the server drops the connection. Session settings
+// come from the DSN db was opened with; use Open for a fully initialized
+// client.
 // The caller must call Close() when done.
-func NewSQLClient(db *sql.DB, database string) *SQLClient {
-	return &SQLClient{db: db, database: database}
+func NewSQLClient(db *sql.DB, cfg Config) *SQLClient {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	c := &SQLClient{
+		cfg:      cfg,
+		database: cfg.Database,
+		log:      logger,
+		open:     openDB,
+		db:       db,
+		stmts:    make(map[string]*list.Element),
+		stmtLRU:  list.New(),
+		maxStmts: defaultMaxStmts,
+		cache:    newManifestCache(cfg, logger),
+	}
+	c.SetObserver(cfg.Observer)
+	c.SetQueryTimeout(cfg.QueryTimeout)
+	return c
+}
+
+// Open creates a new SQLClient by opening a database connection using the
+// provided Config. The caller must call Close() when done.
+func Open(cfg Config) (*SQLClient, error) {
+	return OpenContext(context.Background(), cfg)
+}
+
+// OpenContext is Open with ctx bounding the initial connection, so that a
+// command's deadline or cancellation also covers connecting. QueryTimeout
+// further bounds it when ctx has no deadline.
+func OpenContext(ctx context.Context, cfg Config) (*SQLClient, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := connect(ctx, db, cfg)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// openDB validates cfg and opens a MySQL-driver handle for it without
+// connecting.
+func openDB(cfg Config) (*sql.DB, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("opening dolt connection: %w", err)
+	}
+	return db, nil
+}
+
+// connect verifies db is reachable and wraps it in an SQLClient. Session
+// settings from cfg are already in the DSN db was opened with. It does not
+// close db on failure.
+func connect(ctx context.Context, db *sql.DB, cfg Config) (*SQLClient, error) {
+	client := NewSQLClient(db, cfg)
+	ctx, cancel := client.withTimeout(ctx)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("pinging dolt server: %w", err)
+	}
+	return client, nil
+}
+
+// ensureAlive pings the server and, if the connection is gone, re-opens it
+// on the current branch from the stored Config.
+func (c *SQLClient) ensureAlive(ctx context.Context) error {
+	if err := c.handle().PingContext(ctx); err == nil {
+		return nil
+	}
+	c.log.Debug("dolt connection lost, reconnecting")
+
+	c.mu.Lock()
+	branch := c.branch
+	c.mu.Unlock()
+	db, err := c.open(c.branchConfig(branch))
+	if err != nil {
+		return fmt.Errorf("reconnecting to dolt: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("reconnecting to dolt: pinging server: %w", err)
+	}
+
+	c.replaceDB(db, branch)
+	c.log.Debug("reconnected to dolt", "branch", branch)
+	return nil
+}
+
+// branchConfig returns the stored Config with its database narrowed to
+// branch using Dolt's "database/branch" form, so that every connection
+// opened from it starts on branch. An empty branch leaves it unchanged.
+func (c *SQLClient) branchConfig(branch string) Config {
+	cfg := c.cfg
+	if branch != "" {
+		cfg.Database = c.database + "/" + branch
+	}
+	return cfg
+}
+
+// replaceDB makes db, opened on branch, the current handle, dropping the
+// statements prepared on the old one and closing it. Open rows on the old
+// handle keep their connections until they are closed.
+func (c *SQLClient) replaceDB(db *sql.DB, branch string) {
+	c.mu.Lock()
+	old := c.db
+	c.db = db
+	c.branch = branch
+	c.closeStmtsLocked()
+	c.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+}
+
+// withReconnect runs fn and, if it fails because the driver reports a bad
+// connection, reconnects once via ensureAlive and retries fn.
+func (c *SQLClient) withReconnect(ctx context.Context, fn func() error) error {
+	err := fn()
+	if !errors.Is(err, driver.ErrBadConn) {
+		return err
+	}
+	if rerr := c.ensureAlive(ctx); rerr != nil {
+		return rerr
+	}
+	return fn()
+}
+
+// handle returns the current database handle.
+func (c *SQLClient) handle() *sql.DB {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.db
+}
+
+// SetObserver installs o to receive query timing events. A nil Observer
+// restores the default NopObserver.
+func (c *SQLClient) SetObserver(o Observer) {
+	if o == nil {
+		o = NopObserver{}
+	}
+	c.observer = o
+}
+
+// SetQueryTimeout sets the default per-call timeout applied when the caller's
+// context has no deadline. Zero or negative disables the default.
+func (c *SQLClient) SetQueryTimeout(d time.Duration) {
+	c.queryTimeout = d
+}
+
+// withTimeout derives a context bounded by the client's query timeout. The
+// incoming context is returned unchanged if it already has a deadline or no
+// timeout is configured. Callers must always invoke the returned cancel func.
+func (c *SQLClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.queryTimeout)
+}
+
+// defaultMaxStmts is the default bound on cached prepared statements.
+// Query builders with variable filters produce many distinct texts, so the
+// cache must not grow with every one.
+const defaultMaxStmts = 64
+
+// cachedStmt is a stmtLRU element: a prepared statement and its text.
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+// prepared returns the cached prepared statement for query, preparing and
+// caching it on first use. The statement is prepared without holding c.mu,
+// so a slow prepare does not stall other queries; if another caller cached
+// the same query meanwhile, that statement wins. Evicted statements are
+// closed, which database/sql defers until their open rows are closed.
+func (c *SQLClient) prepared(ctx context.Context, query string) (*sql.Stmt, error) {
+	for {
+		c.mu.Lock()
+		if el, ok := c.stmts[query]; ok {
+			c.stmtLRU.MoveToFront(el)
+			c.mu.Unlock()
+			return el.Value.(*cachedStmt).stmt, nil
+		}
+		db := c.db
+		c.mu.Unlock()
+
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("preparing statement: %w", err)
+		}
+
+		c.mu.Lock()
+		if c.db != db {
+			// A reconnect replaced db while preparing; prepare again on
+			// the new handle.
+			c.mu.Unlock()
+			_ = stmt.Close()
+			continue
+		}
+		if el, ok := c.stmts[query]; ok {
+			c.stmtLRU.MoveToFront(el)
+			c.mu.Unlock()
+			_ = stmt.Close()
+			return el.Value.(*cachedStmt).stmt, nil
+		}
+		c.stmts[query] = c.stmtLRU.PushFront(&cachedStmt{query: query, stmt: stmt})
+		var evicted []*sql.Stmt
+		for c.stmtLRU.Len() > c.maxStmts {
+			oldest := c.stmtLRU.Remove(c.stmtLRU.Back()).(*cachedStmt)
+			delete(c.stmts, oldest.query)
+			evicted = append(evicted, oldest.stmt)
+		}
+		c.mu.Unlock()
+		for _, old := range evicted {
+			_ = old.Close()
+		}
+		return stmt, nil
+	}
+}
+
+// closeStmtsLocked closes and forgets every cached statement, returning the
+// first close error. c.mu must be held.
+func (c *SQLClient) closeStmtsLocked() error {
+	var firstErr error
+	for query, el := range c.stmts {
+		if err := el.Value.(*cachedStmt).stmt.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("closing prepared statement: %w", err)
+		}
+		delete(c.stmts, query)
+	}
+	c.stmtLRU.Init()
+	return firstErr
+}
+
+// queryContext runs a multi-row query through the statement cache and
+// reports its timing to the observer.
+func (c *SQLClient) queryContext(ctx context.Context, name, query string, args ...any) (*sql.Rows, error) {
+	return c.runQuery(ctx, name, query, true, args)
+}
+
+// queryUncached is queryContext without the statement cache, for query
+// text built per call, such as an IN list sized to its arguments, that
+// would only churn the cache.
+func (c *SQLClient) queryUncached(ctx context.Context, name, query string, args ...any) (*sql.Rows, error) {
+	return c.runQuery(ctx, name, query, false, args)
+}
+
+// runQuery runs a multi-row query, through the statement cache if cached
+// is set, and reports its timing to the observer.
+func (c *SQLClient) runQuery(ctx context.Context, name, query string, cached bool, args []any) (*sql.Rows, error) {
+	c.logQuery(name, query, args)
+	start := time.Now()
+	var rows *sql.Rows
+	err := c.withReconnect(ctx, func() error {
+		if !cached {
+			var err error
+			rows, err = c.handle().QueryContext(ctx, query, args...)
+			return err
+		}
+		stmt, err := c.prepared(ctx, query)
+		if err != nil {
+			return err
+		}
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	c.observer.OnQuery(name, time.Since(start), err)
+	if err != nil {
+		return nil, &QueryError{Op: name, Query: query, Err: err}
+	}
+	return rows, nil
+}
+
+// queryRowContext runs a single-row query, scans it into dest, and reports
+// its timing to the observer. sql.ErrNoRows is returned to the caller
+// unwrapped but is not reported as a failure, since not-found is a successful
+// query. Other failures are returned as a *QueryError.
+func (c *SQLClient) queryRowContext(ctx context.Context, name, query string, args []any, dest ...any) error {
+	c.logQuery(name, query, args)
+	start := time.Now()
+	err := c.withReconnect(ctx, func() error {
+		stmt, err := c.prepared(ctx, query)
+		if err != nil {
+			return err
+		}
+		return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		c.observer.OnQuery(name, time.Since(start), nil)
+		return err
+	}
+	c.observer.OnQuery(name, time.Since(start), err)
+	if err != nil {
+		return &QueryError{Op: name, Query: query, Err: err}
+	}
+	return nil
+}
+
+// track registers an in-flight iterator and returns the function that
+// releases it. The release function may be called more than once.
+func (c *SQLClient) track() func() {
+	c.inflightMu.Lock()
+	c.inflight++
+	c.inflightMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.inflightMu.Lock()
+			defer c.inflightMu.Unlock()
+			c.inflight--
+			if c.inflight == 0 && c.idle != nil {
+				close(c.idle)
+				c.idle = nil
+			}
+		})
+	}
+}
+
+// newIterator wraps rows in a PackageIterator registered with the client
+// until it is closed, so CloseContext can wait for it.
+func (c *SQLClient) newIterator(rows *sql.Rows, cancel context.CancelFunc) *PackageIterator {
+	release := c.track()
+	return newRowsPackageIterator(rows, func() {
+		cancel()
+		release()
+	})
+}
+
+// waitIdle blocks until no iterators are in flight or ctx is done.
+func (c *SQLClient) waitIdle(ctx context.Context) error {
+	c.inflightMu.Lock()
+	if c.inflight == 0 {
+		c.inflightMu.Unlock()
+		return nil
+	}
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+	}
+	idle := c.idle
+	c.inflightMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close waits for every open iterator to be closed and then releases the
+// client, like CloseContext with a background context. An iterator that is
+// never closed makes Close block; use CloseContext to bound the wait.
+func (c *SQLClient) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext waits until every iterator from ListPackagesIter has been
+// closed, or ctx is done, and then releases all cached prepared statements
+// and the database connection. If ctx ends first the client is closed
+// anyway, aborting the remaining iterators, and ctx's error is returned.
+// A client from OpenEmbedded stops its server last.
+func (c *SQLClient) CloseContext(ctx context.Context) error {
+	waitErr := c.waitIdle(ctx)
+	if waitErr != nil {
+		c.log.Warn("closing dolt client with open iterators", "error", waitErr)
+	}
+	closeErr := c.closeNow()
+	if c.onClose != nil {
+		if err := c.onClose(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("waiting for open iterators: %w", waitErr)
+	}
+	return nil
+}
+
+// closeNow releases all cached prepared statements and then the database
+// connection.
+func (c *SQLClient) closeNow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stmtErr := c.closeStmtsLocked()
+	if c.db == nil {
+		return stmtErr
+	}
+	if err := c.db.Close(); err != nil {
+		return err
+	}
+	return stmtErr
+}
+
+// switchBranch moves the client to the specified Dolt branch by opening a
+// new pool on the branch's "database/branch" name and swapping it in, so
+// the branch holds on every pooled connection and across reconnects. If
+// branch is empty or already current, this is a no-op. Names failing
+// ValidateBranchName are rejected before anything is opened.
+func (c *SQLClient) switchBranch(ctx context.Context, branch string) error {
+	if branch == "" {
+		return nil
+	}
+	if err := ValidateBranchName(branch); err != nil {
+		return err
+	}
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	c.mu.Lock()
+	current := c.branch
+	c.mu.Unlock()
+	if branch == current {
+		return nil
+	}
+
+	c.log.Debug("switching dolt branch", "from", current, "to", branch)
+	db, err := c.open(c.branchConfig(branch))
+	if err != nil {
+		return fmt.Errorf("switching to branch %q: %w", branch, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("switching to branch %q: %w", branch, err)
+	}
+	c.replaceDB(db, branch)
+	return nil
+}
+
+// CurrentBranch returns the Dolt branch the session is on.
+func (c *SQLClient) CurrentBranch(ctx context.Context) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var branch string
+	if err := c.queryRowContext(ctx, "CurrentBranch", CurrentBranchQuery(), nil, &branch); err != nil {
+		return "", fmt.Errorf("reading current branch: %w", err)
+	}
+	return branch, nil
 }
 
-// Open creates a new SQLClient by opening a database connection using the
-// provided Config. The caller must call Close() when done.
-func Open(cfg Config) (*SQLClient, error) {
-	db, err := sql.Open("mysql", cfg.DSN())
+// ListPackages returns all packages, optionally filtered by branch.
+func (c *SQLClient) ListPackages(ctx context.Context, opts ListOptions) ([]models.Package, error) {
+	it, err := c.ListPackagesIter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = it.Close() }()
+
+	var packages []models.Package
+	for it.Next() {
+		p := it.Package()
+		if c.cfg.StrictValidation {
+			if err := p.Validate(); err != nil {
+				return nil, fmt.Errorf("listing packages: %w", err)
+			}
+		}
+		packages = append(packages, p)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	c.log.Debug("listed packages", "count", len(packages))
+	return packages, nil
+}
+
+// SearchByTags returns the packages carrying tags, combined per
+// opts.TagMatch, ordered by name. Any tags already in opts are replaced.
+func (c *SQLClient) SearchByTags(ctx context.Context, tags []string, opts ListOptions) ([]models.Package, error) {
+	if !hasTag(tags) {
+		return nil, errors.New("searching by tags: no tags given")
+	}
+	opts.Tags = tags
+	packages, err := c.ListPackages(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("searching by tags %v: %w", tags, err)
+	}
+	return packages, nil
+}
+
+// Search returns the packages matching term in the fields selected by opts,
+// exact name matches first.
+func (c *SQLClient) Search(ctx context.Context, term string, opts SearchOptions) ([]models.Package, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil, errors.New("searching packages: empty search term")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.checkTerm(term); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.log.Debug("searching packages", "branch", opts.Branch, "term", term, "fields", opts.Fields)
+	query, args := SearchQuery(term, opts)
+	rows, err := c.queryContext(ctx, "Search", query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("opening dolt connection: %w", err)
+		cancel()
+		return nil, fmt.Errorf("searching packages for %q: %w", term, err)
 	}
-	if err := db.Ping(); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("pinging dolt server: %w", err)
+	it := c.newIterator(rows, cancel)
+	defer func() { _ = it.Close() }()
+
+	var packages []models.Package
+	for it.Next() {
+		packages = append(packages, it.Package())
 	}
-	return NewSQLClient(db, cfg.Database), nil
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("searching packages for %q: %w", term, err)
+	}
+	c.log.Debug("searched packages", "term", term, "count", len(packages))
+	return packages, nil
 }
 
-// Close releases the database connection.
-func (c *SQLClient) Close() error {
-	if c.db == nil {
-		return nil
-	}
-	return c.db.Close()
+// ForEachPackage calls fn for every package matching opts, one page at a
+// time. See Client.ForEachPackage.
+func (c *SQLClient) ForEachPackage(ctx context.Context, opts ListOptions, pageSize int, fn func(models.Package) error) error {
+	return forEachPackage(ctx, c.ListPackages, opts, pageSize, fn)
 }
 
-// switchBranch executes a USE statement to switch to the specified Dolt branch.
-// If branch is empty, this is a no-op.
-func (c *SQLClient) switchBranch(ctx context.Context, branch string) error {
-	stmt := UseBranchQuery(c.database, branch)
-	if stmt == "" {
-		return nil
-	}
-	slog.Debug("switching dolt branch", "branch", branch)
-	if _, err := c.db.ExecContext(ctx, stmt); err != nil {
-		return fmt.Errorf("switching to branch %q: %w", branch, err)
+// ListPackagesIter returns an iterator over the packages matching opts.
+// Rows are scanned lazily as the caller advances the iterator.
+func (c *SQLClient) ListPackagesIter(ctx context.Context, opts ListOptions) (*PackageIterator, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
-	return nil
-}
+	ctx, cancel := c.withTimeout(ctx)
 
-// ListPackages returns all packages, optionally filtered by branch.
-func (c *SQLClient) ListPackages(ctx context.Context, opts ListOptions) ([]models.Package, error) {
 	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		cancel()
 		return nil, err
 	}
 
-	slog.Debug("listing packages", "branch", opts.Branch)
-	rows, err := c.db.QueryContext(ctx, ListPackagesQuery())
+	c.log.Debug("listing packages", "branch", opts.Branch, "tags", opts.Tags)
+	query, args := ListPackagesQuery(opts)
+	rows, err := c.queryContext(ctx, "ListPackages", query, args...)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("listing packages: %w", err)
 	}
-	defer func() { _ = rows.Close() }()
+	return c.newIterator(rows, cancel), nil
+}
 
-	var packages []models.Package
-	for rows.Next() {
-		var p models.Package
-		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &p.Description, &p.Tags, &p.InstallScope); err != nil {
-			return nil, fmt.Errorf("scanning package row: %w", err)
-		}
-		packages = append(packages, p)
+// CountPackages returns the number of packages matching opts.
+func (c *SQLClient) CountPackages(ctx context.Context, opts ListOptions) (int, error) {
+	if err := opts.validate(); err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterating packages: %w", err)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return 0, err
 	}
-	slog.Debug("listed packages", "count", len(packages))
-	return packages, nil
+
+	c.log.Debug("counting packages", "branch", opts.Branch, "tags", opts.Tags)
+	query, args := CountPackagesQuery(opts)
+	var count int
+	if err := c.queryRowContext(ctx, "CountPackages", query, args, &count); err != nil {
+		return 0, fmt.Errorf("counting packages: %w", err)
+	}
+	return count, nil
 }
 
 // GetPackage retrieves a single package by ID.
 func (c *SQLClient) GetPackage(ctx context.Context, id string) (*models.Package, error) {
-	slog.Debug("getting package", "id", id)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting package", "id", id)
 	var p models.Package
-	err := c.db.QueryRowContext(ctx, GetPackageQuery(), id).Scan(
-		&p.ID, &p.Name, &p.Version, &p.Description, &p.AgentVariant,
-		&p.Author, &p.License, &p.Tags, &p.InstallScope,
-		&p.Variables, &p.Options, &p.SHA256, &p.MinClaudeVer,
-	)
+	err := c.queryRowContext(ctx, "GetPackage", GetPackageQuery(), []any{id}, scanPackageDest(&p)...)
 	if errors.Is(err, sql.ErrNoRows) {
-		slog.Debug("package not found", "id", id)
-		return nil, nil
+		c.log.Debug("package not found", "id", id)
+		return nil, fmt.Errorf("package %q: %w", id, ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("getting package %q: %w", id, err)
 	}
+	if c.cfg.StrictValidation {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("getting package %q: %w", id, err)
+		}
+	}
 	return &p, nil
 }
 
+// GetPackages retrieves the packages with the given IDs that match opts, in
+// the order requested. IDs with no matching package are omitted, and
+// duplicate IDs are returned once. Large inputs are fetched in chunks of
+// getPackagesChunkSize to stay under server query-length limits.
+func (c *SQLClient) GetPackages(ctx context.Context, ids []string, opts ListOptions) ([]models.Package, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("getting packages", "count", len(ids), "branch", opts.Branch)
+	found := make(map[string]models.Package, len(ids))
+	for start := 0; start < len(ids); start += getPackagesChunkSize {
+		chunk := ids[start:min(start+getPackagesChunkSize, len(ids))]
+		if err := c.getPackagesChunk(ctx, chunk, opts, found); err != nil {
+			return nil, fmt.Errorf("getting packages: %w", err)
+		}
+	}
+	return orderedPackages(ids, found), nil
+}
+
+// getPackagesChunk fetches one chunk of GetPackages into found, keyed by ID.
+func (c *SQLClient) getPackagesChunk(ctx context.Context, ids []string, opts ListOptions, found map[string]models.Package) error {
+	query, args := GetPackagesQuery(ids, opts)
+	rows, err := c.queryUncached(ctx, "GetPackages", query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var p models.Package
+		if err := rows.Scan(scanPackageDest(&p)...); err != nil {
+			return fmt.Errorf("scanning package row: %w", err)
+		}
+		found[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating packages: %w", err)
+	}
+	return nil
+}
+
 // GetPackageFiles retrieves all files belonging to a package.
 func (c *SQLClient) GetPackageFiles(ctx context.Context, packageID string) ([]models.PackageFile, error) {
-	slog.Debug("getting package files", "package_id", packageID)
-	rows, err := c.db.QueryContext(ctx, GetPackageFilesQuery(), packageID)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting package files", "package_id", packageID)
+	rows, err := c.queryContext(ctx, "GetPackageFiles", GetPackageFilesQuery(), packageID)
 	if err != nil {
 		return nil, fmt.Errorf("getting files for package %q: %w", packageID, err)
 	}
@@ -187,7 +1276,47 @@ func (c *SQLClient) GetPackageFiles(ctx context.Context, packageID string) ([]mo
 		var f models.PackageFile
 		if err := rows.Scan(
 			&f.PackageID, &f.DestPath, &f.Content, &f.SHA256,
-			&f.FileType, &f.ContentType, &f.IsTemplate, &f.Frontmatter,
+			&f.FileType, &f.ContentType, &f.IsTemplate, rawJSON{&f.Frontmatter},
+			&f.FMName, &f.FMDescription, &f.FMVersion, &f.FMModel,
+		); err != nil {
+			return nil, fmt.Errorf("scanning file row: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating files: %w", err)
+	}
+	c.log.Debug("got package files", "package_id", packageID, "count", len(files))
+	return files, nil
+}
+
+// GetPackageFileMeta retrieves the files of a package on opts.Branch with
+// every column except content, for callers that only list files. Content is
+// left empty in the returned files.
+func (c *SQLClient) GetPackageFileMeta(ctx context.Context, packageID string, opts ListOptions) ([]models.PackageFile, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("getting package file metadata", "package_id", packageID, "branch", opts.Branch)
+	rows, err := c.queryContext(ctx, "GetPackageFileMeta", GetPackageFileMetaQuery(opts), packageID)
+	if err != nil {
+		return nil, fmt.Errorf("getting file metadata for package %q: %w", packageID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var files []models.PackageFile
+	for rows.Next() {
+		var f models.PackageFile
+		if err := rows.Scan(
+			&f.PackageID, &f.DestPath, &f.SHA256,
+			&f.FileType, &f.ContentType, &f.IsTemplate, rawJSON{&f.Frontmatter},
 			&f.FMName, &f.FMDescription, &f.FMVersion, &f.FMModel,
 		); err != nil {
 			return nil, fmt.Errorf("scanning file row: %w", err)
@@ -197,14 +1326,16 @@ func (c *SQLClient) GetPackageFiles(ctx context.Context, packageID string) ([]mo
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating files: %w", err)
 	}
-	slog.Debug("got package files", "package_id", packageID, "count", len(files))
 	return files, nil
 }
 
 // GetPackageDeps retrieves all dependencies for a package.
 func (c *SQLClient) GetPackageDeps(ctx context.Context, packageID string) ([]models.PackageDep, error) {
-	slog.Debug("getting package deps", "package_id", packageID)
-	rows, err := c.db.QueryContext(ctx, GetPackageDepsQuery(), packageID)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting package deps", "package_id", packageID)
+	rows, err := c.queryContext(ctx, "GetPackageDeps", GetPackageDepsQuery(), packageID)
 	if err != nil {
 		return nil, fmt.Errorf("getting deps for package %q: %w", packageID, err)
 	}
@@ -224,14 +1355,52 @@ func (c *SQLClient) GetPackageDeps(ctx context.Context, packageID string) ([]mod
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating deps: %w", err)
 	}
-	slog.Debug("got package deps", "package_id", packageID, "count", len(deps))
+	c.log.Debug("got package deps", "package_id", packageID, "count", len(deps))
 	return deps, nil
 }
 
+// GetDependents returns the IDs of the packages on opts.Branch with a skill
+// dependency on id. Only skill dependencies name packages; tool and cli
+// dependencies name programs.
+func (c *SQLClient) GetDependents(ctx context.Context, id string, opts ListOptions) ([]string, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("getting dependents", "package_id", id, "branch", opts.Branch)
+	rows, err := c.queryContext(ctx, "GetDependents", GetDependentsQuery(opts), string(models.DepTypeSkill), id)
+	if err != nil {
+		return nil, fmt.Errorf("getting dependents of package %q: %w", id, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []string{}
+	for rows.Next() {
+		var dependent string
+		if err := rows.Scan(&dependent); err != nil {
+			return nil, fmt.Errorf("scanning dependent row: %w", err)
+		}
+		ids = append(ids, dependent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating dependents: %w", err)
+	}
+	return ids, nil
+}
+
 // GetPackageHooks retrieves all hooks for a package.
 func (c *SQLClient) GetPackageHooks(ctx context.Context, packageID string) ([]models.PackageHook, error) {
-	slog.Debug("getting package hooks", "package_id", packageID)
-	rows, err := c.db.QueryContext(ctx, GetPackageHooksQuery(), packageID)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting package hooks", "package_id", packageID)
+	rows, err := c.queryContext(ctx, "GetPackageHooks", GetPackageHooksQuery(), packageID)
 	if err != nil {
 		return nil, fmt.Errorf("getting hooks for package %q: %w", packageID, err)
 	}
@@ -251,14 +1420,17 @@ func (c *SQLClient) GetPackageHooks(ctx context.Context, packageID string) ([]mo
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating hooks: %w", err)
 	}
-	slog.Debug("got package hooks", "package_id", packageID, "count", len(hooks))
+	c.log.Debug("got package hooks", "package_id", packageID, "count", len(hooks))
 	return hooks, nil
 }
 
 // GetPackageQuestions retrieves all questions for a package.
 func (c *SQLClient) GetPackageQuestions(ctx context.Context, packageID string) ([]models.PackageQuestion, error) {
-	slog.Debug("getting package questions", "package_id", packageID)
-	rows, err := c.db.QueryContext(ctx, GetPackageQuestionsQuery(), packageID)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting package questions", "package_id", packageID)
+	rows, err := c.queryContext(ctx, "GetPackageQuestions", GetPackageQuestionsQuery(), packageID)
 	if err != nil {
 		return nil, fmt.Errorf("getting questions for package %q: %w", packageID, err)
 	}
@@ -267,33 +1439,348 @@ func (c *SQLClient) GetPackageQuestions(ctx context.Context, packageID string) (
 	var questions []models.PackageQuestion
 	for rows.Next() {
 		var q models.PackageQuestion
+		var choices sql.NullString
 		if err := rows.Scan(
 			&q.PackageID, &q.QuestionID, &q.Prompt, &q.Type,
-			&q.DefaultVal, &q.Choices, &q.SortOrder,
+			&q.DefaultVal, &choices, &q.SortOrder,
 		); err != nil {
 			return nil, fmt.Errorf("scanning question row: %w", err)
 		}
+		q.Choices = choices.String
 		questions = append(questions, q)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating questions: %w", err)
 	}
-	slog.Debug("got package questions", "package_id", packageID, "count", len(questions))
+	c.log.Debug("got package questions", "package_id", packageID, "count", len(questions))
 	return questions, nil
 }
 
 // ResolveVariant resolves a logical package ID and agent profile to a
-// concrete variant package ID. Returns empty string if no variant exists.
+// concrete variant package ID. Returns ErrNotFound if no variant exists.
 func (c *SQLClient) ResolveVariant(ctx context.Context, logicalID, agentProfile string) (string, error) {
-	slog.Debug("resolving variant", "logical_id", logicalID, "agent_profile", agentProfile)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("resolving variant", "logical_id", logicalID, "agent_profile", agentProfile)
 	var variantID string
-	err := c.db.QueryRowContext(ctx, ResolveVariantQuery(), logicalID, agentProfile).Scan(&variantID)
+	err := c.queryRowContext(ctx, "ResolveVariant", ResolveVariantQuery(), []any{logicalID, agentProfile}, &variantID)
 	if errors.Is(err, sql.ErrNoRows) {
-		slog.Debug("variant not found", "logical_id", logicalID, "agent_profile", agentProfile)
-		return "", nil
+		c.log.Debug("variant not found", "logical_id", logicalID, "agent_profile", agentProfile)
+		return "", fmt.Errorf("variant %q/%q: %w", logicalID, agentProfile, ErrNotFound)
 	}
 	if err != nil {
 		return "", fmt.Errorf("resolving variant %q/%q: %w", logicalID, agentProfile, err)
 	}
 	return variantID, nil
 }
+
+// ResolveVariantChain resolves logicalID against profiles in preference
+// order using a single query. Blank and repeated profiles are dropped first,
+// and an empty list returns ErrNotFound without querying. Returns
+// ErrNotFound if no profile matches.
+func (c *SQLClient) ResolveVariantChain(ctx context.Context, logicalID string, profiles []string) (string, error) {
+	profiles = uniqueIDs(profiles)
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("variant of %q: no profiles given: %w", logicalID, ErrNotFound)
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("resolving variant chain", "logical_id", logicalID, "profiles", profiles)
+	query, args := ResolveVariantChainQuery(logicalID, profiles)
+	var variantID string
+	err := c.queryRowContext(ctx, "ResolveVariantChain", query, args, &variantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.log.Debug("no variant in chain", "logical_id", logicalID, "profiles", profiles)
+		return "", fmt.Errorf("variant of %q for profiles %v: %w", logicalID, profiles, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolving variant chain for %q: %w", logicalID, err)
+	}
+	return variantID, nil
+}
+
+// ListVariants returns every variant of logicalID ordered by agent profile.
+func (c *SQLClient) ListVariants(ctx context.Context, logicalID string, opts ListOptions) ([]models.PackageVariant, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("listing variants", "logical_id", logicalID, "branch", opts.Branch)
+	rows, err := c.queryContext(ctx, "ListVariants", ListVariantsQuery(), logicalID)
+	if err != nil {
+		return nil, fmt.Errorf("listing variants of %q: %w", logicalID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var variants []models.PackageVariant
+	for rows.Next() {
+		var v models.PackageVariant
+		if err := rows.Scan(&v.LogicalID, &v.AgentProfile, &v.VariantPackageID); err != nil {
+			return nil, fmt.Errorf("scanning variant row: %w", err)
+		}
+		variants = append(variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating variants: %w", err)
+	}
+	c.log.Debug("listed variants", "logical_id", logicalID, "count", len(variants))
+	return variants, nil
+}
+
+// GetStatus returns the uncommitted changes on the session's branch.
+func (c *SQLClient) GetStatus(ctx context.Context) ([]models.StatusEntry, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting working set status")
+	rows, err := c.queryContext(ctx, "GetStatus", StatusQuery())
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []models.StatusEntry{}
+	for rows.Next() {
+		var e models.StatusEntry
+		if err := rows.Scan(&e.TableName, &e.Staged, &e.Status); err != nil {
+			return nil, fmt.Errorf("scanning status row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating status: %w", err)
+	}
+	c.log.Debug("got working set status", "changes", len(entries))
+	return entries, nil
+}
+
+// GetConflicts returns the tables with unresolved merge conflicts on the
+// session's branch.
+func (c *SQLClient) GetConflicts(ctx context.Context) ([]models.Conflict, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("getting merge conflicts")
+	rows, err := c.queryContext(ctx, "GetConflicts", ConflictsQuery())
+	if err != nil {
+		return nil, fmt.Errorf("getting conflicts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	conflicts := []models.Conflict{}
+	for rows.Next() {
+		var cf models.Conflict
+		if err := rows.Scan(&cf.Table, &cf.NumConflicts); err != nil {
+			return nil, fmt.Errorf("scanning conflict row: %w", err)
+		}
+		conflicts = append(conflicts, cf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating conflicts: %w", err)
+	}
+	c.log.Debug("got merge conflicts", "tables", len(conflicts))
+	return conflicts, nil
+}
+
+// HasConflicts reports whether any table has unresolved merge conflicts.
+func (c *SQLClient) HasConflicts(ctx context.Context) (bool, error) {
+	conflicts, err := c.GetConflicts(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(conflicts) > 0, nil
+}
+
+// ChangedPackagesSince returns the IDs of the packages that differ between
+// sinceCommit and branch, using DOLT_DIFF. Both revisions are bound as
+// arguments; branch must also pass ValidateBranchName.
+func (c *SQLClient) ChangedPackagesSince(ctx context.Context, sinceCommit, branch string) ([]string, error) {
+	if strings.TrimSpace(sinceCommit) == "" {
+		return nil, errors.New("changed packages: no commit given")
+	}
+	to := "HEAD"
+	if branch != "" {
+		if err := ValidateBranchName(branch); err != nil {
+			return nil, err
+		}
+		to = branch
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	c.log.Debug("listing changed packages", "since", sinceCommit, "to", to)
+	query, pairs := ChangedPackagesSinceQuery()
+	args := make([]any, 0, 2*pairs)
+	for range pairs {
+		args = append(args, sinceCommit, to)
+	}
+	rows, err := c.queryContext(ctx, "ChangedPackagesSince", query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("diffing packages since %q: %w", sinceCommit, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	changed := make(map[string]bool)
+	for rows.Next() {
+		var fromID, toID sql.NullString
+		if err := rows.Scan(&fromID, &toID); err != nil {
+			return nil, fmt.Errorf("scanning diff row: %w", err)
+		}
+		for _, id := range []sql.NullString{fromID, toID} {
+			if id.Valid && id.String != "" {
+				changed[id.String] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating diff: %w", err)
+	}
+	return sortedKeys(changed), nil
+}
+
+// sortedKeys returns the keys of set in ascending order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetStats summarizes the packages matching opts.
+func (c *SQLClient) GetStats(ctx context.Context, opts ListOptions) (*models.CatalogStats, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("getting catalog stats", "branch", opts.Branch)
+	query, args := ScopeStatsQuery(opts)
+	byScope, err := c.countGroups(ctx, "GetStatsByScope", query, args)
+	if err != nil {
+		return nil, fmt.Errorf("counting packages by scope: %w", err)
+	}
+	query, args = FileTypeStatsQuery(opts)
+	byType, err := c.countGroups(ctx, "GetStatsFilesByType", query, args)
+	if err != nil {
+		return nil, fmt.Errorf("counting files by type: %w", err)
+	}
+	tags, err := c.countTags(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("counting tags: %w", err)
+	}
+
+	stats := &models.CatalogStats{
+		ByScope:     byScope,
+		FilesByType: byType,
+		TopTags:     models.TopTags(tags, statsTopTags),
+	}
+	for _, n := range byScope {
+		stats.TotalPackages += n
+	}
+	return stats, nil
+}
+
+// countGroups runs a two-column "key, COUNT(*)" query and collects the
+// result into a map.
+func (c *SQLClient) countGroups(ctx context.Context, name, query string, args []any) (map[string]int, error) {
+	rows, err := c.queryContext(ctx, name, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, fmt.Errorf("scanning count row: %w", err)
+		}
+		counts[key] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating counts: %w", err)
+	}
+	return counts, nil
+}
+
+// ListTags returns the tags of the packages matching opts with their
+// package counts. Tags are stored as comma-separated strings (or JSON
+// arrays), which SQL cannot split portably, so only the tags column is
+// queried and the aggregation happens client-side with TagsList.
+func (c *SQLClient) ListTags(ctx context.Context, opts ListOptions) ([]models.TagCount, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+
+	c.log.Debug("listing tags", "branch", opts.Branch)
+	counts, err := c.countTags(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	return models.TopTags(counts, 0), nil
+}
+
+// countTags tallies how many packages matching opts carry each tag. A tag
+// repeated within one package counts once.
+func (c *SQLClient) countTags(ctx context.Context, opts ListOptions) (map[string]int, error) {
+	query, args := TagStatsQuery(opts)
+	rows, err := c.queryContext(ctx, "GetStatsTags", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		// The tags column is nullable; NULL means no tags.
+		var tags sql.NullString
+		if err := rows.Scan(&tags); err != nil {
+			return nil, fmt.Errorf("scanning tags row: %w", err)
+		}
+		if err := addTagCounts(counts, &models.Package{Tags: tags.String}); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tags: %w", err)
+	}
+	return counts, nil
+}
+
+// addTagCounts increments counts once for each distinct tag of p.
+func addTagCounts(counts map[string]int, p *models.Package) error {
+	tags, err := p.TagsList()
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			counts[tag]++
+		}
+	}
+	return nil
+}
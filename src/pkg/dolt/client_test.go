@@ -1,10 +1,19 @@
 package dolt
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
 )
@@ -26,6 +35,161 @@ func TestMockClientListPackages(t *testing.T) {
 	}
 }
 
+func TestMockClientCountPackages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", []string{"go", "cli"}))
+	m.AddPackage(NewTestPackage("pkg-2", "beta", "2.0.0", []string{"go"}))
+	m.AddPackage(NewTestPackage("pkg-3", "gamma", "3.0.0", nil))
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want int
+	}{
+		{name: "unfiltered", opts: ListOptions{}, want: 3},
+		{name: "single tag", opts: ListOptions{Tags: []string{"go"}}, want: 2},
+		{name: "all tags required", opts: ListOptions{Tags: []string{"go", "cli"}}, want: 1},
+		{name: "unknown tag", opts: ListOptions{Tags: []string{"rust"}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := m.CountPackages(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountPackages = %d, want %d", got, tt.want)
+			}
+			listed, err := m.ListPackages(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(listed) != got {
+				t.Errorf("ListPackages returned %d rows but CountPackages = %d", len(listed), got)
+			}
+		})
+	}
+}
+
+func TestMockClientListPackagesTagMatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-go", "alpha", "1.0.0", []string{"go"}))
+	m.AddPackage(NewTestPackage("pkg-both", "beta", "1.0.0", []string{"go", "cli"}))
+	m.AddPackage(NewTestPackage("pkg-none", "gamma", "1.0.0", []string{"rust"}))
+
+	tests := []struct {
+		name  string
+		match TagMatch
+		want  int
+	}{
+		{name: "all requires both tags", match: TagMatchAll, want: 1},
+		{name: "any accepts either tag", match: TagMatchAny, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pkgs, err := m.ListPackages(ctx, ListOptions{Tags: []string{"go", "cli"}, TagMatch: tt.match})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(pkgs) != tt.want {
+				t.Errorf("got %d packages, want %d", len(pkgs), tt.want)
+			}
+			for _, p := range pkgs {
+				if p.ID == "pkg-none" {
+					t.Error("package without any requested tag should not match")
+				}
+			}
+		})
+	}
+}
+
+func TestMockClientListPackagesInstallScope(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-any", "alpha", "1.0.0", nil))
+	local := NewTestPackage("pkg-local", "beta", "1.0.0", nil)
+	local.InstallScope = models.InstallScopeLocalOnly
+	m.AddPackage(local)
+
+	tests := []struct {
+		name    string
+		opts    ListOptions
+		want    int
+		wantErr bool
+	}{
+		{name: "no scope filter", opts: ListOptions{}, want: 2},
+		{name: "any only", opts: ListOptions{InstallScope: models.InstallScopeAny}, want: 1},
+		{name: "local-only only", opts: ListOptions{InstallScope: models.InstallScopeLocalOnly}, want: 1},
+		{name: "local-only including any", opts: ListOptions{InstallScope: models.InstallScopeLocalOnly, IncludeAnyScope: true}, want: 2},
+		{name: "invalid scope", opts: ListOptions{InstallScope: "global"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pkgs, err := m.ListPackages(ctx, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(pkgs) != tt.want {
+				t.Errorf("got %d packages, want %d", len(pkgs), tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLClientListPackagesInvalidScope(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	if _, err := c.ListPackages(context.Background(), ListOptions{InstallScope: "global"}); err == nil {
+		t.Fatal("expected error for invalid install scope")
+	}
+	if _, err := c.CountPackages(context.Background(), ListOptions{InstallScope: "local"}); err == nil {
+		t.Fatal("expected error for invalid install scope")
+	}
+	if calls := fc.recorded(); len(calls) != 0 {
+		t.Errorf("invalid options should not reach the database, got %d calls", len(calls))
+	}
+}
+
+func TestSQLClientCountPackages(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	opts := ListOptions{Tags: []string{"go"}}
+	query, _ := CountPackagesQuery(opts)
+	fc.setRows(query, []string{"COUNT(*)"}, []driver.Value{int64(7)})
+
+	c := NewSQLClient(db, DefaultConfig())
+	got, err := c.CountPackages(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 7 {
+		t.Errorf("CountPackages = %d, want 7", got)
+	}
+	calls := fc.recorded()
+	if len(calls) != 1 || len(calls[0].args) != 1 || calls[0].args[0] != "go" {
+		t.Errorf("calls = %+v, want one query with arg \"go\"", calls)
+	}
+}
+
 func TestMockClientListPackagesError(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -63,8 +227,8 @@ func TestMockClientGetPackage(t *testing.T) {
 	t.Run("missing package", func(t *testing.T) {
 		t.Parallel()
 		p, err := m.GetPackage(ctx, "nonexistent")
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
 		}
 		if p != nil {
 			t.Errorf("expected nil, got %+v", p)
@@ -122,7 +286,7 @@ func TestMockClientGetPackageDeps(t *testing.T) {
 
 	m := NewMockClient()
 	m.AddDeps("pkg-1", []models.PackageDep{
-		{PackageID: "pkg-1", DepType: models.DepTypeTool, DepName: "other-pkg", DepSpec: ">=1.0.0"},
+		{PackageID: "pkg-1", DepType: models.DepTypeTool, DepName: "other-pkg", DepSpec: strPtr(">=1.0.0")},
 	})
 
 	deps, err := m.GetPackageDeps(ctx, "pkg-1")
@@ -137,6 +301,84 @@ func TestMockClientGetPackageDeps(t *testing.T) {
 	}
 }
 
+func TestSQLClientGetDependents(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	query := GetDependentsQuery(ListOptions{})
+	fc.setRows(query, []string{"package_id"},
+		[]driver.Value{"pkg-a"},
+		[]driver.Value{"pkg-b"},
+	)
+	ids, err := c.GetDependents(ctx, "pkg-base", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(ids) != "[pkg-a pkg-b]" {
+		t.Errorf("dependents = %v, want [pkg-a pkg-b]", ids)
+	}
+	calls := fc.recorded()
+	last := calls[len(calls)-1]
+	if fmt.Sprint(last.args) != "[skill pkg-base]" {
+		t.Errorf("args = %v, want [skill pkg-base]", last.args)
+	}
+
+	fc.setRows(query, []string{"package_id"})
+	ids, err = c.GetDependents(ctx, "pkg-leaf", ListOptions{})
+	if err != nil || ids == nil || len(ids) != 0 {
+		t.Errorf("dependents = %#v, %v; want an empty slice", ids, err)
+	}
+}
+
+func TestMockClientGetDependents(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMockClient()
+	m.AddDeps("pkg-b", []models.PackageDep{{PackageID: "pkg-b", DepType: models.DepTypeSkill, DepName: "pkg-base"}})
+	m.AddDeps("pkg-a", []models.PackageDep{
+		{PackageID: "pkg-a", DepType: models.DepTypeTool, DepName: "jq"},
+		{PackageID: "pkg-a", DepType: models.DepTypeSkill, DepName: "pkg-base"},
+	})
+	m.AddDeps("pkg-c", []models.PackageDep{{PackageID: "pkg-c", DepType: models.DepTypeTool, DepName: "pkg-base"}})
+
+	ids, err := m.GetDependents(ctx, "pkg-base", ListOptions{})
+	if err != nil || fmt.Sprint(ids) != "[pkg-a pkg-b]" {
+		t.Errorf("dependents = %v, %v; want [pkg-a pkg-b]", ids, err)
+	}
+	ids, err = m.GetDependents(ctx, "pkg-a", ListOptions{})
+	if err != nil || len(ids) != 0 {
+		t.Errorf("dependents = %v, %v; want none", ids, err)
+	}
+}
+
+func TestSQLClientGetPackageDepsNullColumns(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	fc.setRows(GetPackageDepsQuery(),
+		[]string{"package_id", "dep_type", "dep_name", "dep_spec", "install_cmd", "cmd_sha256"},
+		[]driver.Value{"pkg-1", "tool", "jq", nil, nil, nil},
+		[]driver.Value{"pkg-1", "cli", "gh", "^2.0", "brew install gh", "abc123"},
+	)
+
+	deps, err := c.GetPackageDeps(context.Background(), "pkg-1")
+	if err != nil {
+		t.Fatalf("NULL dep columns should scan cleanly: %v", err)
+	}
+	if len(deps) != 2 {
+		t.Fatalf("got %d deps, want 2", len(deps))
+	}
+	if deps[0].DepSpec != nil || deps[0].InstallCmd != nil || deps[0].CmdSHA256 != nil {
+		t.Errorf("NULL columns should scan to nil, got %+v", deps[0])
+	}
+	if deps[1].DepSpec == nil || *deps[1].DepSpec != "^2.0" {
+		t.Errorf("DepSpec = %v, want ^2.0", deps[1].DepSpec)
+	}
+}
+
 func TestMockClientGetPackageDepsError(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -236,11 +478,11 @@ func TestMockClientResolveVariant(t *testing.T) {
 		}
 	})
 
-	t.Run("missing variant returns empty", func(t *testing.T) {
+	t.Run("missing variant returns ErrNotFound", func(t *testing.T) {
 		t.Parallel()
 		id, err := m.ResolveVariant(ctx, "nonexistent", "profile")
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
 		}
 		if id != "" {
 			t.Errorf("got %q, want empty string", id)
@@ -258,97 +500,1845 @@ func TestMockClientResolveVariant(t *testing.T) {
 	})
 }
 
-func TestMockClientClose(t *testing.T) {
+func TestMockClientResolveVariantChain(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddVariant("logical-1", "claude-code-opus", "variant-opus")
+	m.AddVariant("logical-1", "claude-code", "variant-base")
+
+	tests := []struct {
+		name         string
+		profiles     []string
+		want         string
+		wantNotFound bool
+	}{
+		{name: "first match", profiles: []string{"claude-code-opus", "claude-code"}, want: "variant-opus"},
+		{name: "fallback match", profiles: []string{"claude-code-haiku", "claude-code"}, want: "variant-base"},
+		{name: "no match", profiles: []string{"codex", "gemini"}, wantNotFound: true},
+		{name: "no profiles", profiles: nil, wantNotFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := m.ResolveVariantChain(ctx, "logical-1", tt.profiles)
+			if tt.wantNotFound {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("err = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLClientResolveVariantChain(t *testing.T) {
 	t.Parallel()
+	profiles := []string{"claude-code-opus", "claude-code"}
+	query, _ := ResolveVariantChainQuery("logical-1", profiles)
 
-	t.Run("successful close", func(t *testing.T) {
+	t.Run("fallback match", func(t *testing.T) {
 		t.Parallel()
-		m := NewMockClient()
-		if m.Closed {
-			t.Error("expected Closed=false before close")
+		db, fc := newFakeDB(t)
+		fc.setRows(query, []string{"variant_package_id"}, []driver.Value{"variant-base"})
+		c := NewSQLClient(db, DefaultConfig())
+
+		got, err := c.ResolveVariantChain(context.Background(), "logical-1", profiles)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if err := m.Close(); err != nil {
+		if got != "variant-base" {
+			t.Errorf("got %q, want %q", got, "variant-base")
+		}
+		calls := fc.recorded()
+		if len(calls) != 1 {
+			t.Fatalf("expected a single query, got %d", len(calls))
+		}
+		if len(calls[0].args) != 5 {
+			t.Errorf("got %d args, want 5", len(calls[0].args))
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(query, []string{"variant_package_id"})
+		c := NewSQLClient(db, DefaultConfig())
+
+		got, err := c.ResolveVariantChain(context.Background(), "logical-1", profiles)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+		if got != "" {
+			t.Errorf("got %q, want empty string", got)
+		}
+	})
+}
+
+func TestMockClientListVariants(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddVariant("logical-1", "codex", "variant-codex")
+	m.AddVariant("logical-1", "claude-code", "variant-claude")
+	m.AddVariant("logical-10", "claude-code", "other-logical")
+
+	t.Run("multiple variants", func(t *testing.T) {
+		t.Parallel()
+		variants, err := m.ListVariants(ctx, "logical-1", ListOptions{})
+		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if !m.Closed {
-			t.Error("expected Closed=true after close")
+		if len(variants) != 2 {
+			t.Fatalf("got %d variants, want 2", len(variants))
+		}
+		if variants[0].AgentProfile != "claude-code" || variants[1].AgentProfile != "codex" {
+			t.Errorf("variants not ordered by profile: %+v", variants)
+		}
+		if variants[0].VariantPackageID != "variant-claude" {
+			t.Errorf("VariantPackageID = %q, want %q", variants[0].VariantPackageID, "variant-claude")
 		}
 	})
 
-	t.Run("close error", func(t *testing.T) {
+	t.Run("no variants", func(t *testing.T) {
 		t.Parallel()
-		m := NewMockClient()
-		m.CloseErr = errors.New("close failed")
-		if err := m.Close(); err == nil {
-			t.Fatal("expected error, got nil")
+		variants, err := m.ListVariants(ctx, "logical-2", ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(variants) != 0 {
+			t.Errorf("got %d variants, want 0", len(variants))
 		}
 	})
 }
 
-func TestNewTestPackage(t *testing.T) {
+func TestSQLClientListVariants(t *testing.T) {
 	t.Parallel()
+	columns := []string{"logical_id", "agent_profile", "variant_package_id"}
 
-	t.Run("with tags", func(t *testing.T) {
+	t.Run("multiple variants", func(t *testing.T) {
 		t.Parallel()
-		p := NewTestPackage("id-1", "test", "1.0.0", []string{"a", "b"})
-		if p.ID != "id-1" {
-			t.Errorf("ID = %q, want %q", p.ID, "id-1")
+		db, fc := newFakeDB(t)
+		fc.setRows(ListVariantsQuery(), columns,
+			[]driver.Value{"logical-1", "claude-code", "variant-claude"},
+			[]driver.Value{"logical-1", "codex", "variant-codex"},
+		)
+		c := NewSQLClient(db, DefaultConfig())
+
+		variants, err := c.ListVariants(context.Background(), "logical-1", ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if p.InstallScope != "any" {
-			t.Errorf("InstallScope = %q, want %q", p.InstallScope, "any")
+		if len(variants) != 2 {
+			t.Fatalf("got %d variants, want 2", len(variants))
 		}
-		tags := p.TagsList()
-		if len(tags) != 2 {
-			t.Fatalf("got %d tags, want 2", len(tags))
+		if variants[1].VariantPackageID != "variant-codex" {
+			t.Errorf("VariantPackageID = %q, want %q", variants[1].VariantPackageID, "variant-codex")
 		}
 	})
 
-	t.Run("without tags", func(t *testing.T) {
+	t.Run("invalid options", func(t *testing.T) {
 		t.Parallel()
-		p := NewTestPackage("id-2", "test", "1.0.0", nil)
-		if p.Tags != "" {
-			t.Errorf("expected empty Tags, got %q", p.Tags)
+		db, fc := newFakeDB(t)
+		c := NewSQLClient(db, DefaultConfig())
+
+		opts := ListOptions{AsOfTime: time.Now(), Branch: "beta"}
+		if _, err := c.ListVariants(context.Background(), "logical-1", opts); err == nil {
+			t.Error("expected error combining AsOfTime and Branch")
+		}
+		if _, err := NewMockClient().ListVariants(context.Background(), "logical-1", opts); err == nil {
+			t.Error("mock should reject the same options")
+		}
+		if calls := fc.recorded(); len(calls) != 0 {
+			t.Errorf("calls = %v, want none before validation", calls)
+		}
+	})
+
+	t.Run("no variants", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(ListVariantsQuery(), columns)
+		c := NewSQLClient(db, DefaultConfig())
+
+		variants, err := c.ListVariants(context.Background(), "logical-2", ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(variants) != 0 {
+			t.Errorf("got %d variants, want 0", len(variants))
 		}
 	})
 }
 
-func TestDefaultConfig(t *testing.T) {
+// statsFixture returns a mock with two "any" packages and one local-only
+// package, carrying files of several types.
+func statsFixture() *MockClient {
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", []string{"go", "cli"}))
+	m.AddPackage(NewTestPackage("pkg-2", "beta", "1.0.0", []string{"go"}))
+	local := NewTestPackage("pkg-3", "gamma", "1.0.0", []string{"git"})
+	local.InstallScope = models.InstallScopeLocalOnly
+	m.AddPackage(local)
+	m.AddFiles("pkg-1", []models.PackageFile{
+		{PackageID: "pkg-1", DestPath: "skills/a/SKILL.md", FileType: models.FileTypeSkill},
+		{PackageID: "pkg-1", DestPath: "scripts/a.py", FileType: models.FileTypeScript},
+	})
+	m.AddFiles("pkg-3", []models.PackageFile{
+		{PackageID: "pkg-3", DestPath: "skills/c/SKILL.md", FileType: models.FileTypeSkill},
+	})
+	return m
+}
+
+func TestMockClientGetStats(t *testing.T) {
 	t.Parallel()
-	cfg := DefaultConfig()
-	if cfg.Host != "127.0.0.1" {
-		t.Errorf("Host = %q, want %q", cfg.Host, "127.0.0.1")
+	ctx := context.Background()
+
+	t.Run("whole catalog", func(t *testing.T) {
+		t.Parallel()
+		stats, err := statsFixture().GetStats(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.TotalPackages != 3 {
+			t.Errorf("TotalPackages = %d, want 3", stats.TotalPackages)
+		}
+		if stats.ByScope["any"] != 2 || stats.ByScope["local-only"] != 1 {
+			t.Errorf("ByScope = %v, want any:2 local-only:1", stats.ByScope)
+		}
+		if stats.FilesByType["skill"] != 2 || stats.FilesByType["script"] != 1 {
+			t.Errorf("FilesByType = %v, want skill:2 script:1", stats.FilesByType)
+		}
+		if len(stats.TopTags) == 0 || stats.TopTags[0] != (models.TagCount{Tag: "go", Count: 2}) {
+			t.Errorf("TopTags = %v, want go:2 first", stats.TopTags)
+		}
+	})
+
+	t.Run("filtered by scope", func(t *testing.T) {
+		t.Parallel()
+		stats, err := statsFixture().GetStats(ctx, ListOptions{InstallScope: models.InstallScopeLocalOnly})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.TotalPackages != 1 || stats.FilesByType["skill"] != 1 || stats.FilesByType["script"] != 0 {
+			t.Errorf("unexpected stats for local-only: %+v", stats)
+		}
+	})
+}
+
+func TestSQLClientGetStats(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	opts := ListOptions{}
+	scopeQuery, _ := ScopeStatsQuery(opts)
+	typeQuery, _ := FileTypeStatsQuery(opts)
+	tagQuery, _ := TagStatsQuery(opts)
+	fc.setRows(scopeQuery, []string{"install_scope", "COUNT(*)"},
+		[]driver.Value{"any", int64(2)},
+		[]driver.Value{"local-only", int64(1)},
+	)
+	fc.setRows(typeQuery, []string{"file_type", "COUNT(*)"},
+		[]driver.Value{"skill", int64(2)},
+		[]driver.Value{"script", int64(1)},
+	)
+	fc.setRows(tagQuery, []string{"tags"},
+		[]driver.Value{"go, cli"},
+		[]driver.Value{"go"},
+		[]driver.Value{"git"},
+	)
+	c := NewSQLClient(db, DefaultConfig())
+
+	stats, err := c.GetStats(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if cfg.Port != 3306 {
-		t.Errorf("Port = %d, want %d", cfg.Port, 3306)
+	if stats.TotalPackages != 3 {
+		t.Errorf("TotalPackages = %d, want 3", stats.TotalPackages)
 	}
-	if cfg.Database != "synaptic_canvas" {
-		t.Errorf("Database = %q, want %q", cfg.Database, "synaptic_canvas")
+	if stats.ByScope["any"] != 2 || stats.ByScope["local-only"] != 1 {
+		t.Errorf("ByScope = %v, want any:2 local-only:1", stats.ByScope)
+	}
+	if stats.FilesByType["skill"] != 2 || stats.FilesByType["script"] != 1 {
+		t.Errorf("FilesByType = %v, want skill:2 script:1", stats.FilesByType)
+	}
+	want := []models.TagCount{{Tag: "go", Count: 2}, {Tag: "cli", Count: 1}, {Tag: "git", Count: 1}}
+	if fmt.Sprint(stats.TopTags) != fmt.Sprint(want) {
+		t.Errorf("TopTags = %v, want %v", stats.TopTags, want)
 	}
 }
 
-func TestConfigDSN(t *testing.T) {
+func TestListTags(t *testing.T) {
+	t.Parallel()
+	want := "[{go 3} {cli 2} {git 1} {web 1}]"
+
+	t.Run("sql client", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		tagQuery, _ := TagStatsQuery(ListOptions{})
+		fc.setRows(tagQuery, []string{"tags"},
+			[]driver.Value{"go, cli"},
+			[]driver.Value{`["go","cli","go"]`},
+			[]driver.Value{"go,git"},
+			[]driver.Value{"web"},
+			[]driver.Value{nil},
+		)
+		c := NewSQLClient(db, DefaultConfig())
+
+		tags, err := c.ListTags(context.Background(), ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fmt.Sprint(tags) != want {
+			t.Errorf("tags = %v, want %s", tags, want)
+		}
+	})
+
+	t.Run("mock client", func(t *testing.T) {
+		t.Parallel()
+		m := NewMockClient()
+		m.AddPackage(NewTestPackage("pkg-1", "one", "1.0.0", []string{"go", "cli"}))
+		m.AddPackage(NewTestPackage("pkg-2", "two", "1.0.0", []string{"cli", "go", "go"}))
+		m.AddPackage(NewTestPackage("pkg-3", "three", "1.0.0", []string{"git", "go"}))
+		m.AddPackage(NewTestPackage("pkg-4", "four", "1.0.0", []string{"web"}))
+		m.AddPackage(NewTestPackage("pkg-5", "five", "1.0.0", nil))
+
+		tags, err := m.ListTags(context.Background(), ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fmt.Sprint(tags) != want {
+			t.Errorf("tags = %v, want %s", tags, want)
+		}
+
+		m.TagsErr = errors.New("boom")
+		if _, err := m.ListTags(context.Background(), ListOptions{}); err == nil {
+			t.Error("expected TagsErr")
+		}
+	})
+}
+
+func TestMockClientCurrentBranch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMockClient()
+
+	branch, err := m.CurrentBranch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("default branch = %q, want %q", branch, "main")
+	}
+
+	m.ActiveBranch = "staging"
+	if branch, _ := m.CurrentBranch(ctx); branch != "staging" {
+		t.Errorf("branch after switch = %q, want %q", branch, "staging")
+	}
+}
+
+func TestSQLClientCurrentBranch(t *testing.T) {
 	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
 	cfg := DefaultConfig()
-	dsn := cfg.DSN()
+	c := NewSQLClient(db, cfg)
+	c.open = fc.open
 
-	// Should contain user, host:port, and database.
-	if dsn == "" {
-		t.Fatal("DSN should not be empty")
+	fc.setRows(CurrentBranchQuery(), []string{"active_branch()"}, []driver.Value{"main"})
+	branch, err := c.CurrentBranch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	// Basic format check: user@tcp(host:port)/database
-	if !strings.Contains(dsn, "tcp(127.0.0.1:3306)") {
-		t.Errorf("DSN %q missing host:port", dsn)
+	if branch != "main" {
+		t.Errorf("default branch = %q, want %q", branch, "main")
 	}
-	if !strings.Contains(dsn, "synaptic_canvas") {
-		t.Errorf("DSN %q missing database name", dsn)
+
+	// Simulate the server state after connecting to db/staging.
+	fc.setRows(listQuery(ListOptions{}), listPackagesColumns)
+	if _, err := c.ListPackages(ctx, ListOptions{Branch: "staging"}); err != nil {
+		t.Fatalf("ListPackages failed: %v", err)
+	}
+	fc.setRows(CurrentBranchQuery(), []string{"active_branch()"}, []driver.Value{"staging"})
+	branch, err = c.CurrentBranch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "staging" {
+		t.Errorf("branch after switch = %q, want %q", branch, "staging")
+	}
+	if got := fc.openedDatabases(); fmt.Sprint(got) != "[synaptic_canvas/staging]" {
+		t.Errorf("opened %v, want [synaptic_canvas/staging]", got)
 	}
 }
 
-func TestListOptions(t *testing.T) {
+func TestSQLClientSwitchBranchElidesRedundantSwitch(t *testing.T) {
 	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	c.open = fc.open
 
-	opts := ListOptions{Branch: "staging"}
-	if opts.Branch != "staging" {
-		t.Errorf("Branch = %q, want %q", opts.Branch, "staging")
+	steps := []struct {
+		branch string
+		want   []string
+	}{
+		{branch: "", want: nil},
+		{branch: "staging", want: []string{"synaptic_canvas/staging"}},
+		{branch: "staging", want: []string{"synaptic_canvas/staging"}},
+		{branch: "", want: []string{"synaptic_canvas/staging"}},
+		{branch: "main", want: []string{"synaptic_canvas/staging", "synaptic_canvas/main"}},
+		{branch: "staging", want: []string{"synaptic_canvas/staging", "synaptic_canvas/main", "synaptic_canvas/staging"}},
+	}
+	for i, step := range steps {
+		if err := c.switchBranch(ctx, step.branch); err != nil {
+			t.Fatalf("step %d: switchBranch(%q) failed: %v", i, step.branch, err)
+		}
+		if got := fc.openedDatabases(); fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("step %d: opened %v, want %v", i, got, step.want)
+		}
+	}
+	// No session statement is involved: the branch is part of the DSN.
+	if execs := fc.execs(); len(execs) != 0 {
+		t.Errorf("execs = %v, want none", execs)
+	}
+}
+
+func TestSQLClientSwitchBranchReplacesPool(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	c.open = fc.open
+	fc.setRows(GetPackageFilesQuery(), []string{
+		"package_id", "dest_path", "content", "sha256", "file_type", "content_type",
+		"is_template", "frontmatter", "fm_name", "fm_description", "fm_version", "fm_model",
+	})
+	if _, err := c.GetPackageFiles(ctx, "pkg-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.switchBranch(ctx, "staging"); err != nil {
+		t.Fatalf("switchBranch failed: %v", err)
+	}
+	if c.handle() == db {
+		t.Error("switchBranch kept the default branch's pool")
+	}
+	if err := db.PingContext(ctx); err == nil {
+		t.Error("the old pool should be closed")
+	}
+	if len(c.stmts) != 0 {
+		t.Errorf("statements prepared on the old pool survived: %d", len(c.stmts))
+	}
+}
+
+func TestSQLClientSwitchBranchFailureNotRecorded(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, _ := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	// Dolt refuses connections to a database/branch that does not exist.
+	missingDB, missing := newFakeDB(t)
+	missing.pingErr = errors.New("database not found: synaptic_canvas/staging")
+	c.open = func(Config) (*sql.DB, error) { return missingDB, nil }
+
+	if err := c.switchBranch(ctx, "staging"); err == nil {
+		t.Fatal("expected switch error")
+	}
+	if c.branch != "" || c.handle() != db {
+		t.Errorf("failed switch recorded branch %q", c.branch)
+	}
+}
+
+func TestSQLClientSwitchBranchRejectsInvalidName(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	if err := c.switchBranch(context.Background(), "main`x"); err == nil {
+		t.Fatal("expected invalid branch name error")
+	}
+	if got := fc.recorded(); len(got) != 0 {
+		t.Errorf("invalid branch reached the server: %v", got)
+	}
+}
+
+func TestMockClientClose(t *testing.T) {
+	t.Parallel()
+
+	t.Run("successful close", func(t *testing.T) {
+		t.Parallel()
+		m := NewMockClient()
+		if m.Closed {
+			t.Error("expected Closed=false before close")
+		}
+		if err := m.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !m.Closed {
+			t.Error("expected Closed=true after close")
+		}
+	})
+
+	t.Run("close error", func(t *testing.T) {
+		t.Parallel()
+		m := NewMockClient()
+		m.CloseErr = errors.New("close failed")
+		if err := m.Close(); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestMockClientListPackagesOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	for _, p := range []*models.Package{
+		NewTestPackage("id-3", "charlie", "1.0.0", nil),
+		NewTestPackage("id-1", "alpha", "1.0.0", nil),
+		NewTestPackage("id-9", "bravo", "1.0.0", nil),
+		NewTestPackage("id-2", "bravo", "2.0.0", nil),
+	} {
+		m.AddPackage(p)
+	}
+
+	want := "id-1 id-2 id-9 id-3"
+	for range 5 {
+		pkgs, err := m.ListPackages(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids := make([]string, len(pkgs))
+		for i, p := range pkgs {
+			ids[i] = p.ID
+		}
+		if got := strings.Join(ids, " "); got != want {
+			t.Fatalf("order = %q, want %q (by name, then ID)", got, want)
+		}
+	}
+}
+
+func TestMockClientConcurrentAccess(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("seed", "seed", "1.0.0", nil))
+
+	const writes = 50
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range writes {
+			id := fmt.Sprintf("pkg-%d", i)
+			m.AddPackage(NewTestPackage(id, id, "1.0.0", []string{"go"}))
+			m.AddFiles(id, []models.PackageFile{{PackageID: id, DestPath: "a.md"}})
+		}
+	}()
+
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := 0
+			for range writes {
+				pkgs, err := m.ListPackages(ctx, ListOptions{})
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Packages are only ever added, so a reader never sees fewer.
+				if len(pkgs) < last {
+					errs <- fmt.Errorf("listed %d packages after seeing %d", len(pkgs), last)
+					return
+				}
+				last = len(pkgs)
+				if _, err := m.GetPackage(ctx, "seed"); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := m.GetPackageFiles(ctx, "pkg-0"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n, err := m.CountPackages(ctx, ListOptions{}); err != nil || n != writes+1 {
+		t.Errorf("CountPackages = %d, %v; want %d", n, err, writes+1)
+	}
+	if err := m.Close(); err != nil || !m.IsClosed() {
+		t.Errorf("Close() = %v, IsClosed() = %v", err, m.IsClosed())
+	}
+}
+
+func TestNewTestPackage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("with tags", func(t *testing.T) {
+		t.Parallel()
+		p := NewTestPackage("id-1", "test", "1.0.0", []string{"a", "b"})
+		if p.ID != "id-1" {
+			t.Errorf("ID = %q, want %q", p.ID, "id-1")
+		}
+		if p.InstallScope != "any" {
+			t.Errorf("InstallScope = %q, want %q", p.InstallScope, "any")
+		}
+		tags, err := p.TagsList()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tags) != 2 {
+			t.Fatalf("got %d tags, want 2", len(tags))
+		}
+	})
+
+	t.Run("without tags", func(t *testing.T) {
+		t.Parallel()
+		p := NewTestPackage("id-2", "test", "1.0.0", nil)
+		if p.Tags != "" {
+			t.Errorf("expected empty Tags, got %q", p.Tags)
+		}
+	})
+}
+
+func TestDefaultConfig(t *testing.T) {
+	t.Parallel()
+	cfg := DefaultConfig()
+	if cfg.Host != "127.0.0.1" {
+		t.Errorf("Host = %q, want %q", cfg.Host, "127.0.0.1")
+	}
+	if cfg.Port != 3306 {
+		t.Errorf("Port = %d, want %d", cfg.Port, 3306)
+	}
+	if cfg.Database != "synaptic_canvas" {
+		t.Errorf("Database = %q, want %q", cfg.Database, "synaptic_canvas")
+	}
+}
+
+func TestConfigDSN(t *testing.T) {
+	t.Parallel()
+	cfg := DefaultConfig()
+	dsn := cfg.DSN()
+
+	// Should contain user, host:port, and database.
+	if dsn == "" {
+		t.Fatal("DSN should not be empty")
+	}
+	// Basic format check: user@tcp(host:port)/database
+	if !strings.Contains(dsn, "tcp(127.0.0.1:3306)") {
+		t.Errorf("DSN %q missing host:port", dsn)
+	}
+	if !strings.Contains(dsn, "synaptic_canvas") {
+		t.Errorf("DSN %q missing database name", dsn)
+	}
+}
+
+func TestConfigDSNTransport(t *testing.T) {
+	t.Parallel()
+
+	tcp := DefaultConfig()
+	tcp.Host, tcp.Port, tcp.Password = "dolt.internal", 3307, "secret"
+
+	socket := tcp
+	socket.Socket = "/var/run/dolt/mysql.sock"
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{name: "tcp", cfg: tcp, want: "root:secret@tcp(dolt.internal:3307)/synaptic_canvas?parseTime=true&transaction_read_only=1"},
+		{name: "socket ignores host and port", cfg: socket, want: "root:secret@unix(/var/run/dolt/mysql.sock)/synaptic_canvas?parseTime=true&transaction_read_only=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.cfg.DSN(); got != tt.want {
+				t.Errorf("DSN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigRedactsPassword(t *testing.T) {
+	t.Parallel()
+	const secret = "hunter2-s3cret"
+	cfg := DefaultConfig()
+	cfg.Password = secret
+	cfg.Logger = slog.Default()
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshaling config: %v", err)
+	}
+	if bytes.Contains(data, []byte(secret)) {
+		t.Errorf("JSON leaks the password: %s", data)
+	}
+	if !bytes.Contains(data, []byte(`"password":"***"`)) || !bytes.Contains(data, []byte(`"host":"127.0.0.1"`)) {
+		t.Errorf("JSON = %s, want a masked password and the other settings", data)
+	}
+
+	// Passed to slog directly or inside a struct, through both handlers.
+	var logs bytes.Buffer
+	for _, h := range []slog.Handler{slog.NewJSONHandler(&logs, nil), slog.NewTextHandler(&logs, nil)} {
+		slog.New(h).Info("connecting", "config", cfg, "wrapped", struct{ C Config }{cfg})
+	}
+	if bytes.Contains(logs.Bytes(), []byte(secret)) {
+		t.Errorf("logs leak the password: %s", logs.String())
+	}
+	if s := fmt.Sprintf("%v %+v %#v %#v", cfg, struct{ C Config }{cfg}, cfg, struct{ C Config }{cfg}); strings.Contains(s, secret) {
+		t.Errorf("fmt output leaks the password: %s", s)
+	}
+
+	// An unset password stays empty rather than looking set.
+	cfg.Password = ""
+	if data, _ := json.Marshal(cfg); bytes.Contains(data, []byte("***")) {
+		t.Errorf("empty password should not be masked: %s", data)
+	}
+}
+
+func TestConfigJSONFieldParity(t *testing.T) {
+	t.Parallel()
+	// Logger and Observer are interfaces with no useful JSON form.
+	omitted := map[string]bool{"Logger": true, "Observer": true}
+
+	cfgType, jsonType := reflect.TypeFor[Config](), reflect.TypeFor[configJSON]()
+	for i := range cfgType.NumField() {
+		f := cfgType.Field(i)
+		if omitted[f.Name] {
+			continue
+		}
+		jf, ok := jsonType.FieldByName(f.Name)
+		if !ok {
+			t.Errorf("Config.%s is missing from configJSON, so MarshalJSON drops it", f.Name)
+			continue
+		}
+		if jf.Type != f.Type {
+			t.Errorf("configJSON.%s is %v, want %v as in Config", f.Name, jf.Type, f.Type)
+		}
+	}
+	for i := range jsonType.NumField() {
+		if name := jsonType.Field(i).Name; !omitted[name] {
+			if _, ok := cfgType.FieldByName(name); !ok {
+				t.Errorf("configJSON.%s has no Config field", name)
+			}
+		}
+	}
+}
+
+func TestConfigDSNParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{name: "none", want: "?parseTime=true"},
+		{
+			name:   "sorted after default",
+			params: map[string]string{"readTimeout": "30s", "charset": "utf8mb4", "interpolateParams": "true"},
+			want:   "?parseTime=true&charset=utf8mb4&interpolateParams=true&readTimeout=30s",
+		},
+		{
+			name:   "override default",
+			params: map[string]string{"parseTime": "false", "collation": "utf8mb4_bin"},
+			want:   "?collation=utf8mb4_bin&parseTime=false",
+		},
+		{name: "escaped value", params: map[string]string{"loc": "America/New_York"}, want: "?parseTime=true&loc=America%2FNew_York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := DefaultConfig()
+			cfg.ReadOnly = false
+			cfg.Params = tt.params
+			if got := cfg.DSN(); !strings.HasSuffix(got, "/synaptic_canvas"+tt.want) {
+				t.Errorf("DSN() = %q, want suffix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOptions(t *testing.T) {
+	t.Parallel()
+
+	opts := ListOptions{Branch: "staging"}
+	if opts.Branch != "staging" {
+		t.Errorf("Branch = %q, want %q", opts.Branch, "staging")
+	}
+}
+
+// recordingObserver captures every OnQuery event for assertions.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []observedQuery
+}
+
+type observedQuery struct {
+	name string
+	err  error
+}
+
+func (o *recordingObserver) OnQuery(name string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, observedQuery{name: name, err: err})
+}
+
+func (o *recordingObserver) names() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	names := make([]string, len(o.events))
+	for i, e := range o.events {
+		names[i] = e.name
+	}
+	return names
+}
+
+var listPackagesColumns = []string{"id", "name", "version", "description", "tags", "install_scope", "created_at", "updated_at"}
+
+// listQuery returns just the SQL text of ListPackagesQuery for registering
+// fake results.
+func listQuery(opts ListOptions) string {
+	q, _ := ListPackagesQuery(opts)
+	return q
+}
+
+func TestSQLClientListPackagesNotifiesObserver(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(listQuery(ListOptions{}), listPackagesColumns,
+		[]driver.Value{"pkg-1", "alpha", "1.0.0", nil, "go", "any", nil, nil},
+		[]driver.Value{"pkg-2", "beta", "2.0.0", "desc", "", "local-only", nil, nil},
+	)
+
+	obs := &recordingObserver{}
+	c := NewSQLClient(db, DefaultConfig())
+	c.SetObserver(obs)
+
+	pkgs, err := c.ListPackages(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+
+	names := obs.names()
+	if len(names) != 1 || names[0] != "ListPackages" {
+		t.Errorf("observer events = %v, want exactly [ListPackages]", names)
+	}
+}
+
+func TestSQLClientObserverReceivesErrors(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setErr(GetPackageFilesQuery(), errors.New("boom"))
+
+	obs := &recordingObserver{}
+	c := NewSQLClient(db, DefaultConfig())
+	c.SetObserver(obs)
+
+	if _, err := c.GetPackageFiles(context.Background(), "pkg-1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(obs.events) != 1 || obs.events[0].name != "GetPackageFiles" || obs.events[0].err == nil {
+		t.Errorf("observer events = %+v, want one failed GetPackageFiles", obs.events)
+	}
+}
+
+func TestSQLClientQueryTimeoutFires(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setBlock(GetPackageQuery())
+
+	c := NewSQLClient(db, DefaultConfig())
+	c.SetQueryTimeout(20 * time.Millisecond)
+
+	_, err := c.GetPackage(context.Background(), "pkg-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSQLClientWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applies default when caller has no deadline", func(t *testing.T) {
+		t.Parallel()
+		c := &SQLClient{queryTimeout: time.Minute}
+		ctx, cancel := c.withTimeout(context.Background())
+		defer cancel()
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected derived context to have a deadline")
+		}
+	})
+
+	t.Run("caller deadline takes precedence", func(t *testing.T) {
+		t.Parallel()
+		c := &SQLClient{queryTimeout: time.Millisecond}
+		want := time.Now().Add(time.Hour)
+		parent, parentCancel := context.WithDeadline(context.Background(), want)
+		defer parentCancel()
+
+		ctx, cancel := c.withTimeout(parent)
+		defer cancel()
+		got, ok := ctx.Deadline()
+		if !ok || !got.Equal(want) {
+			t.Errorf("deadline = %v, want caller deadline %v", got, want)
+		}
+	})
+
+	t.Run("zero timeout leaves context unbounded", func(t *testing.T) {
+		t.Parallel()
+		c := &SQLClient{}
+		ctx, cancel := c.withTimeout(context.Background())
+		defer cancel()
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("expected no deadline when timeout is disabled")
+		}
+	})
+}
+
+func TestDefaultConfigReadOnly(t *testing.T) {
+	t.Parallel()
+	if !DefaultConfig().ReadOnly {
+		t.Error("DefaultConfig should enable ReadOnly")
+	}
+}
+
+func TestConfigDSNReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		readOnly bool
+		params   map[string]string
+		want     string
+	}{
+		{name: "enabled", readOnly: true, want: "?parseTime=true&transaction_read_only=1"},
+		{name: "disabled", want: "?parseTime=true"},
+		{
+			name:     "overrides params",
+			readOnly: true,
+			params:   map[string]string{"transaction_read_only": "0", "charset": "utf8mb4"},
+			want:     "?parseTime=true&transaction_read_only=1&charset=utf8mb4",
+		},
+		{
+			name:   "disabled keeps params",
+			params: map[string]string{"transaction_read_only": "0"},
+			want:   "?parseTime=true&transaction_read_only=0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := DefaultConfig()
+			cfg.ReadOnly = tt.readOnly
+			cfg.Params = tt.params
+			if got := cfg.DSN(); !strings.HasSuffix(got, "/synaptic_canvas"+tt.want) {
+				t.Errorf("DSN() = %q, want suffix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectReadOnlyIssuesNoStatements(t *testing.T) {
+	t.Parallel()
+	// Read-only is applied by the driver on each new connection from the
+	// DSN, so connect itself sends nothing that a single pooled
+	// connection would keep to itself.
+	db, fc := newFakeDB(t)
+	if _, err := connect(context.Background(), db, DefaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if execs := fc.execs(); len(execs) != 0 {
+		t.Errorf("execs = %v, want none", execs)
+	}
+}
+
+func TestSQLClientGetPackageFilesNullFrontmatter(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(GetPackageFilesQuery(), []string{
+		"package_id", "dest_path", "content", "sha256", "file_type", "content_type",
+		"is_template", "frontmatter", "fm_name", "fm_description", "fm_version", "fm_model",
+	}, []driver.Value{"pkg-1", "skills/a.md", "# A\n", "abc", "skill", "markdown", false, nil, nil, nil, nil, nil})
+
+	c := NewSQLClient(db, DefaultConfig())
+	files, err := c.GetPackageFiles(context.Background(), "pkg-1")
+	if err != nil {
+		t.Fatalf("all-NULL frontmatter columns should scan cleanly: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	f := files[0]
+	if f.Frontmatter != nil || f.FMName != nil || f.FMDescription != nil || f.FMVersion != nil || f.FMModel != nil {
+		t.Errorf("NULL frontmatter columns should scan to nil, got %+v", f)
+	}
+	if fm, err := f.ParsedFrontmatter(); err != nil || len(fm) != 0 {
+		t.Errorf("ParsedFrontmatter() = %v, %v; want no frontmatter", fm, err)
+	}
+
+	m, err := models.BuildManifest(&models.Package{ID: "pkg-1", Name: "a", Version: "1.0.0"}, files, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildManifest failed on file without frontmatter: %v", err)
+	}
+	if got := m.Artifacts["skills"]; len(got) != 1 || got[0] != "skills/a.md" {
+		t.Errorf("Artifacts[skills] = %v, want [skills/a.md]", got)
+	}
+}
+
+func TestSQLClientReusesPreparedStatements(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(GetPackageFilesQuery(), []string{
+		"package_id", "dest_path", "content", "sha256", "file_type", "content_type",
+		"is_template", "frontmatter", "fm_name", "fm_description", "fm_version", "fm_model",
+	})
+
+	c := NewSQLClient(db, DefaultConfig())
+	ctx := context.Background()
+	for range 3 {
+		if _, err := c.GetPackageFiles(ctx, "pkg-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(c.stmts) != 1 {
+		t.Fatalf("cached %d statements, want 1", len(c.stmts))
+	}
+	first := c.stmts[GetPackageFilesQuery()].Value.(*cachedStmt).stmt
+	again, err := c.prepared(ctx, GetPackageFilesQuery())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again != first {
+		t.Error("prepared returned a new *sql.Stmt for a cached query")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(c.stmts) != 0 {
+		t.Errorf("Close left %d cached statements", len(c.stmts))
+	}
+}
+
+func TestSQLClientPreparedEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	db, _ := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	c.maxStmts = 2
+	ctx := context.Background()
+
+	prepare := func(query string) *sql.Stmt {
+		t.Helper()
+		stmt, err := c.prepared(ctx, query)
+		if err != nil {
+			t.Fatalf("preparing %q: %v", query, err)
+		}
+		return stmt
+	}
+	a := prepare("SELECT 1")
+	prepare("SELECT 2")
+	prepare("SELECT 1") // a is now the most recently used
+	prepare("SELECT 3") // evicts SELECT 2
+
+	if len(c.stmts) != 2 || c.stmtLRU.Len() != 2 {
+		t.Fatalf("cached %d statements (%d in LRU), want 2", len(c.stmts), c.stmtLRU.Len())
+	}
+	if _, ok := c.stmts["SELECT 2"]; ok {
+		t.Error("least recently used statement was not evicted")
+	}
+	if again := prepare("SELECT 1"); again != a {
+		t.Error("recently used statement was evicted")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if c.stmtLRU.Len() != 0 {
+		t.Errorf("Close left %d statements in the LRU", c.stmtLRU.Len())
+	}
+}
+
+func TestSQLClientReconnectsAfterBadConn(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	opts := ListOptions{Branch: "staging"}
+
+	staleDB, stale := newFakeDB(t)
+	// The restarted server answers normally.
+	freshDB, fresh := newFakeDB(t)
+	fresh.setRows(listQuery(opts), listPackagesColumns,
+		[]driver.Value{"pkg-1", "alpha", "1.0.0", nil, "", "any", nil, nil},
+	)
+
+	defaultDB, _ := newFakeDB(t)
+	c := NewSQLClient(defaultDB, DefaultConfig())
+	pools := []*sql.DB{staleDB, freshDB}
+	var opened []string
+	c.open = func(cfg Config) (*sql.DB, error) {
+		opened = append(opened, cfg.Database)
+		db := pools[0]
+		pools = pools[1:]
+		return db, nil
+	}
+	if err := c.switchBranch(ctx, "staging"); err != nil {
+		t.Fatalf("switchBranch failed: %v", err)
+	}
+
+	// The original server goes away: every query and ping fails.
+	stale.setErr(listQuery(opts), driver.ErrBadConn)
+	stale.pingErr = driver.ErrBadConn
+
+	pkgs, err := c.ListPackages(ctx, opts)
+	if err != nil {
+		t.Fatalf("expected query to succeed after reconnect, got: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].ID != "pkg-1" {
+		t.Errorf("got %+v, want [pkg-1]", pkgs)
+	}
+
+	// The fresh pool must be opened on the branch, not re-checked out.
+	if fmt.Sprint(opened) != "[synaptic_canvas/staging synaptic_canvas/staging]" {
+		t.Errorf("opened %v, want staging twice", opened)
+	}
+	if execs := fresh.execs(); len(execs) != 0 {
+		t.Errorf("fresh execs = %v, want none", execs)
+	}
+}
+
+func TestSQLClientEnsureAliveHealthy(t *testing.T) {
+	t.Parallel()
+	db, _ := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	c.open = func(Config) (*sql.DB, error) {
+		t.Error("healthy connection should not be reopened")
+		return nil, errors.New("unexpected reopen")
+	}
+	if err := c.ensureAlive(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestMockClientSearchByTags(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("p-go", "go-tools", "1.0.0", []string{"go", "cli"}))
+	m.AddPackage(NewTestPackage("p-py", "py-tools", "1.0.0", []string{"python", "cli"}))
+	arrayTags := NewTestPackage("p-json", "json-tags", "1.0.0", nil)
+	arrayTags.Tags = `["go", "lint"]`
+	m.AddPackage(arrayTags)
+
+	tests := []struct {
+		name  string
+		tags  []string
+		match TagMatch
+		want  string
+	}{
+		{name: "single tag", tags: []string{"go"}, want: "p-go p-json"},
+		{name: "all of two tags", tags: []string{"go", "cli"}, want: "p-go"},
+		{name: "any of two tags", tags: []string{"python", "lint"}, match: TagMatchAny, want: "p-json p-py"},
+		{name: "no match", tags: []string{"rust"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pkgs, err := m.SearchByTags(ctx, tt.tags, ListOptions{TagMatch: tt.match})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]string, len(pkgs))
+			for i, p := range pkgs {
+				ids[i] = p.ID
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("SearchByTags(%v) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.SearchByTags(ctx, []string{" "}, ListOptions{}); err == nil {
+		t.Error("expected error without tags")
+	}
+}
+
+func TestSQLClientSearchByTags(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	tags := []string{"go", "cli"}
+	query, _ := SearchByTagsQuery(tags, ListOptions{})
+	fc.setRows(query, listPackagesColumns,
+		[]driver.Value{"p-go", "go-tools", "1.0.0", nil, "go,cli", "any", nil, nil},
+	)
+
+	pkgs, err := c.SearchByTags(ctx, tags, ListOptions{Tags: []string{"ignored"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].ID != "p-go" {
+		t.Errorf("got %+v, want [p-go]", pkgs)
+	}
+	calls := fc.recorded()
+	if last := calls[len(calls)-1]; fmt.Sprint(last.args) != "[go cli]" {
+		t.Errorf("args = %v, want [go cli]", last.args)
+	}
+
+	if _, err := c.SearchByTags(ctx, nil, ListOptions{}); err == nil {
+		t.Error("expected error without tags")
+	}
+}
+
+func TestMockClientSearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	lint := NewTestPackage("p-lint", "linter", "1.0.0", []string{"go", "quality"})
+	lint.Description = strPtr("Catches formatting drift in Go sources")
+	m.AddPackage(lint)
+	m.AddPackage(NewTestPackage("p-fmt", "fmt-helper", "1.0.0", []string{"format"}))
+	m.AddPackage(NewTestPackage("p-exact", "format", "1.0.0", nil))
+	m.AddPackage(NewTestPackage("p-py", "py-tools", "1.0.0", []string{"python"}))
+
+	tests := []struct {
+		name string
+		term string
+		opts SearchOptions
+		want string
+	}{
+		{name: "description only", term: "drift", want: "p-lint"},
+		{name: "tag only", term: "quality", want: "p-lint"},
+		{name: "tag is an exact match", term: "qual", want: ""},
+		{name: "multi-word term skips tags", term: "formatting drift", want: "p-lint"},
+		{name: "exact name first", term: "FORMAT", want: "p-exact p-fmt p-lint"},
+		{name: "restricted fields", term: "format", opts: SearchOptions{Fields: []string{SearchFieldTags}}, want: "p-fmt"},
+		{name: "limit", term: "format", opts: SearchOptions{Limit: 2}, want: "p-exact p-fmt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pkgs, err := m.Search(ctx, tt.term, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := make([]string, len(pkgs))
+			for i, p := range pkgs {
+				ids[i] = p.ID
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("Search(%q) = %q, want %q", tt.term, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Search(ctx, " ", SearchOptions{}); err == nil {
+		t.Error("expected error for an empty term")
+	}
+	if _, err := m.Search(ctx, "go", SearchOptions{Fields: []string{"author"}}); err == nil {
+		t.Error("expected error for an unknown field")
+	}
+	if _, err := m.Search(ctx, "code review", SearchOptions{Fields: []string{SearchFieldTags}}); err == nil {
+		t.Error("expected error for a tag-only search with a space")
+	}
+}
+
+func TestSQLClientSearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	query, _ := SearchQuery("drift", SearchOptions{})
+	fc.setRows(query, listPackagesColumns,
+		[]driver.Value{"p-lint", "linter", "1.0.0", "Catches formatting drift", "go", "any", nil, nil},
+	)
+
+	pkgs, err := c.Search(ctx, " drift ", SearchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].ID != "p-lint" {
+		t.Errorf("got %+v, want [p-lint]", pkgs)
+	}
+	calls := fc.recorded()
+	if last := calls[len(calls)-1]; fmt.Sprint(last.args) != "[%drift% %drift% drift drift]" {
+		t.Errorf("args = %v, want the pattern twice then the term for the tag and ranking", last.args)
+	}
+
+	if _, err := c.Search(ctx, "", SearchOptions{}); err == nil {
+		t.Error("expected error for an empty term")
+	}
+	before := len(fc.recorded())
+	if _, err := c.Search(ctx, "go, cli", SearchOptions{Fields: []string{SearchFieldTags}}); err == nil {
+		t.Error("expected error for a tag-only search with a comma")
+	}
+	if n := len(fc.recorded()); n != before {
+		t.Errorf("an impossible tag reached the server")
+	}
+}
+
+func TestSQLClientLogger(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(GetPackageQuery(), packageColumnNames, packageRow("pkg-1"))
+
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})).
+		With("component", "dolt")
+	c := NewSQLClient(db, cfg)
+
+	if _, err := c.GetPackage(context.Background(), "pkg-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var found bool
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, `msg="getting package"`) {
+			found = true
+			if !strings.Contains(line, "component=dolt") || !strings.Contains(line, "id=pkg-1") {
+				t.Errorf("log line lacks injected attributes: %s", line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no query log line written to the injected logger:\n%s", buf.String())
+	}
+}
+
+func TestSQLClientGetStatus(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cols := []string{"table_name", "staged", "status"}
+
+	t.Run("clean", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(StatusQuery(), cols)
+		c := NewSQLClient(db, DefaultConfig())
+
+		entries, err := c.GetStatus(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if entries == nil || len(entries) != 0 {
+			t.Errorf("entries = %#v, want an empty slice", entries)
+		}
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(StatusQuery(), cols,
+			[]driver.Value{"package_files", int64(0), "modified"},
+			[]driver.Value{"packages", int64(1), "modified"},
+		)
+		c := NewSQLClient(db, DefaultConfig())
+
+		entries, err := c.GetStatus(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []models.StatusEntry{
+			{TableName: "package_files", Staged: false, Status: "modified"},
+			{TableName: "packages", Staged: true, Status: "modified"},
+		}
+		if fmt.Sprint(entries) != fmt.Sprint(want) {
+			t.Errorf("entries = %+v, want %+v", entries, want)
+		}
+	})
+}
+
+func TestMockClientGetStatus(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	entries, err := m.GetStatus(ctx)
+	if err != nil || entries == nil || len(entries) != 0 {
+		t.Fatalf("clean status = %#v, %v; want an empty slice", entries, err)
+	}
+
+	m.Status = []models.StatusEntry{{TableName: "packages", Status: "modified"}}
+	entries, err = m.GetStatus(ctx)
+	if err != nil || len(entries) != 1 || entries[0].TableName != "packages" {
+		t.Fatalf("dirty status = %+v, %v", entries, err)
+	}
+	entries[0].TableName = "changed"
+	if m.Status[0].TableName != "packages" {
+		t.Error("GetStatus should return a copy")
+	}
+
+	m.StatusErr = errors.New("boom")
+	if _, err := m.GetStatus(ctx); err == nil {
+		t.Error("expected StatusErr")
+	}
+}
+
+func TestSQLClientGetConflicts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cols := []string{"table", "num_conflicts"}
+
+	t.Run("clean", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(ConflictsQuery(), cols)
+		c := NewSQLClient(db, DefaultConfig())
+
+		conflicts, err := c.GetConflicts(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if conflicts == nil || len(conflicts) != 0 {
+			t.Errorf("conflicts = %#v, want an empty slice", conflicts)
+		}
+		if has, err := c.HasConflicts(ctx); err != nil || has {
+			t.Errorf("HasConflicts = %v, %v; want false", has, err)
+		}
+	})
+
+	t.Run("conflicted", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setRows(ConflictsQuery(), cols,
+			[]driver.Value{"package_files", int64(2)},
+			[]driver.Value{"packages", int64(1)},
+		)
+		c := NewSQLClient(db, DefaultConfig())
+
+		conflicts, err := c.GetConflicts(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []models.Conflict{
+			{Table: "package_files", NumConflicts: 2},
+			{Table: "packages", NumConflicts: 1},
+		}
+		if fmt.Sprint(conflicts) != fmt.Sprint(want) {
+			t.Errorf("conflicts = %+v, want %+v", conflicts, want)
+		}
+		if has, err := c.HasConflicts(ctx); err != nil || !has {
+			t.Errorf("HasConflicts = %v, %v; want true", has, err)
+		}
+	})
+}
+
+func TestMockClientGetConflicts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	if has, err := m.HasConflicts(ctx); err != nil || has {
+		t.Fatalf("clean HasConflicts = %v, %v; want false", has, err)
+	}
+
+	m.Conflicts = []models.Conflict{{Table: "packages", NumConflicts: 3}}
+	conflicts, err := m.GetConflicts(ctx)
+	if err != nil || len(conflicts) != 1 || conflicts[0].NumConflicts != 3 {
+		t.Fatalf("conflicts = %+v, %v", conflicts, err)
+	}
+	if has, err := m.HasConflicts(ctx); err != nil || !has {
+		t.Errorf("HasConflicts = %v, %v; want true", has, err)
+	}
+
+	m.ConflictsErr = errors.New("boom")
+	if _, err := m.HasConflicts(ctx); err == nil {
+		t.Error("expected ConflictsErr")
+	}
+}
+
+func TestConfigDSNSessionVars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		vars   map[string]string
+		params map[string]string
+		want   string
+	}{
+		{
+			name: "numbers unquoted in key order",
+			vars: map[string]string{"foreign_key_checks": "0", "dolt_transaction_commit": "1"},
+			want: "?parseTime=true&dolt_transaction_commit=1&foreign_key_checks=0",
+		},
+		{
+			name: "strings quoted",
+			vars: map[string]string{"sql_mode": "ANSI"},
+			want: "?parseTime=true&sql_mode=%27ANSI%27",
+		},
+		{
+			name: "quotes and backslashes escaped",
+			vars: map[string]string{"x": `a'b\c`},
+			want: "?parseTime=true&x=%27a%27%27b%5C%5Cc%27",
+		},
+		{
+			name: "injection stays inside the literal",
+			vars: map[string]string{"sql_mode": "1; DROP TABLE packages"},
+			want: "?parseTime=true&sql_mode=%271%3B+DROP+TABLE+packages%27",
+		},
+		{
+			name:   "merged with params",
+			vars:   map[string]string{"foreign_key_checks": "0"},
+			params: map[string]string{"charset": "utf8mb4"},
+			want:   "?parseTime=true&charset=utf8mb4&foreign_key_checks=0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := DefaultConfig()
+			cfg.ReadOnly = false
+			cfg.SessionVars = tt.vars
+			cfg.Params = tt.params
+			if got := cfg.DSN(); !strings.HasSuffix(got, "/synaptic_canvas"+tt.want) {
+				t.Errorf("DSN() = %q, want suffix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsUnsafeConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		vars   map[string]string
+		params map[string]string
+		want   string
+	}{
+		{
+			name: "not an identifier",
+			vars: map[string]string{"autocommit": "1", "x = 1; DROP TABLE packages; --": "0"},
+			want: "invalid session variable name",
+		},
+		{
+			name: "driver option",
+			vars: map[string]string{"multiStatements": "1"},
+			want: "invalid session variable name",
+		},
+		{
+			name:   "multiStatements param",
+			params: map[string]string{"multiStatements": "true"},
+			want:   "driver parameter multiStatements is not allowed",
+		},
+		{
+			name:   "allowAllFiles param",
+			params: map[string]string{"allowAllFiles": "true"},
+			want:   "driver parameter allowAllFiles is not allowed",
+		},
+		{
+			name:   "also in params",
+			vars:   map[string]string{"foreign_key_checks": "0"},
+			params: map[string]string{"foreign_key_checks": "1"},
+			want:   "also set in Params",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := DefaultConfig()
+			cfg.SessionVars = tt.vars
+			cfg.Params = tt.params
+			if _, err := Open(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLClientGetPackageQuestionsChoices(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(GetPackageQuestionsQuery(),
+		[]string{"package_id", "question_id", "prompt", "type", "default_val", "choices", "sort_order"},
+		[]driver.Value{"pkg-1", "mode", "Mode?", "choice", "", `["fast","slow"]`, int64(1)},
+		[]driver.Value{"pkg-1", "name", "Name?", "text", "", nil, int64(2)},
+	)
+	c := NewSQLClient(db, DefaultConfig())
+
+	qs, err := c.GetPackageQuestions(context.Background(), "pkg-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(qs) != 2 {
+		t.Fatalf("got %d questions, want 2", len(qs))
+	}
+	if choices, err := qs[0].ChoicesList(); err != nil || fmt.Sprint(choices) != "[fast slow]" {
+		t.Errorf("choices = %v, %v; want [fast slow]", choices, err)
+	}
+	if qs[1].Choices != "" {
+		t.Errorf("NULL choices = %q, want empty", qs[1].Choices)
+	}
+}
+
+func TestGetPackageFileMeta(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sql client", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		query := GetPackageFileMetaQuery(ListOptions{})
+		if strings.Contains(query, "content,") {
+			t.Errorf("metadata query selects content: %s", query)
+		}
+		fc.setRows(query, []string{
+			"package_id", "dest_path", "sha256", "file_type", "content_type",
+			"is_template", "frontmatter", "fm_name", "fm_description", "fm_version", "fm_model",
+		}, []driver.Value{"pkg-1", "skills/a.md", "abc", "skill", "markdown", true, `{"name":"a"}`, "a", nil, nil, nil})
+		c := NewSQLClient(db, DefaultConfig())
+
+		files, err := c.GetPackageFileMeta(context.Background(), "pkg-1", ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("got %d files, want 1", len(files))
+		}
+		f := files[0]
+		if f.DestPath != "skills/a.md" || f.SHA256 != "abc" || f.FileType != models.FileTypeSkill ||
+			!f.IsTemplate || f.FMName == nil || *f.FMName != "a" || string(f.Frontmatter) != `{"name":"a"}` {
+			t.Errorf("metadata = %+v", f)
+		}
+		if f.Content != "" {
+			t.Errorf("Content = %q, want empty", f.Content)
+		}
+	})
+
+	t.Run("mock client", func(t *testing.T) {
+		t.Parallel()
+		m := NewMockClient()
+		m.AddFiles("pkg-1", []models.PackageFile{
+			{PackageID: "pkg-1", DestPath: "agent.md", Content: "# agent", SHA256: "abc123", FileType: models.FileTypeAgent},
+		})
+
+		files, err := m.GetPackageFileMeta(context.Background(), "pkg-1", ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 1 || files[0].DestPath != "agent.md" || files[0].SHA256 != "abc123" || files[0].Content != "" {
+			t.Errorf("files = %+v, want agent.md metadata without content", files)
+		}
+		full, _ := m.GetPackageFiles(context.Background(), "pkg-1")
+		if full[0].Content != "# agent" {
+			t.Errorf("GetPackageFileMeta modified the stored file: %+v", full[0])
+		}
+	})
+}
+
+func TestMockClientHonorsContext(t *testing.T) {
+	t.Parallel()
+
+	calls := map[string]func(context.Context, *MockClient) error{
+		"ListPackages": func(ctx context.Context, m *MockClient) error {
+			_, err := m.ListPackages(ctx, ListOptions{})
+			return err
+		},
+		"ListPackagesIter": func(ctx context.Context, m *MockClient) error {
+			_, err := m.ListPackagesIter(ctx, ListOptions{})
+			return err
+		},
+		"CountPackages": func(ctx context.Context, m *MockClient) error {
+			_, err := m.CountPackages(ctx, ListOptions{})
+			return err
+		},
+		"GetPackage": func(ctx context.Context, m *MockClient) error {
+			_, err := m.GetPackage(ctx, "pkg-1")
+			return err
+		},
+		"GetPackageFiles": func(ctx context.Context, m *MockClient) error {
+			_, err := m.GetPackageFiles(ctx, "pkg-1")
+			return err
+		},
+		"GetManifest": func(ctx context.Context, m *MockClient) error {
+			_, err := m.GetManifest(ctx, "pkg-1", ListOptions{})
+			return err
+		},
+		"GetBundle": func(ctx context.Context, m *MockClient) error {
+			_, err := m.GetBundle(ctx, "pkg-1", ListOptions{})
+			return err
+		},
+		"ResolveVariant": func(ctx context.Context, m *MockClient) error {
+			_, err := m.ResolveVariant(ctx, "logical", "claude")
+			return err
+		},
+		"CurrentBranch": func(ctx context.Context, m *MockClient) error {
+			_, err := m.CurrentBranch(ctx)
+			return err
+		},
+	}
+	newMock := func() *MockClient {
+		m := NewMockClient()
+		m.AddPackage(NewTestPackage("pkg-1", "one", "1.0.0", nil))
+		m.AddVariant("logical", "claude", "pkg-1")
+		return m
+	}
+
+	for name, call := range calls {
+		t.Run(name+"/canceled before", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := call(ctx, newMock()); !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+		})
+
+		t.Run(name+"/canceled during", func(t *testing.T) {
+			t.Parallel()
+			m := newMock()
+			m.Delays = map[string]time.Duration{name: time.Minute}
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+			start := time.Now()
+			if err := call(ctx, m); !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("call took %s, cancellation was not honored", elapsed)
+			}
+		})
+
+		t.Run(name+"/delay elapses", func(t *testing.T) {
+			t.Parallel()
+			m := newMock()
+			m.Delays = map[string]time.Duration{name: time.Millisecond}
+			if err := call(context.Background(), m); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLClientLogQueries(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint("enabled=", enabled), func(t *testing.T) {
+			t.Parallel()
+			db, fc := newFakeDB(t)
+			fc.setRows(GetPackageQuery(), packageColumnNames, packageRow("pkg-1"))
+
+			var buf bytes.Buffer
+			cfg := DefaultConfig()
+			cfg.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			cfg.LogQueries = enabled
+			c := NewSQLClient(db, cfg)
+
+			if _, err := c.GetPackage(context.Background(), "pkg-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			logged := strings.Contains(buf.String(), `msg="sql query"`)
+			if logged != enabled {
+				t.Fatalf("query logged = %v, want %v:\n%s", logged, enabled, buf.String())
+			}
+			if enabled && (!strings.Contains(buf.String(), "FROM packages WHERE id = ?") || !strings.Contains(buf.String(), "pkg-1")) {
+				t.Errorf("log lacks the query text or its argument:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestLoggedArg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		arg  any
+		want string
+	}{
+		{nil, "NULL"},
+		{int64(7), "7"},
+		{"commit-msg-generator", `"commit-msg-generator"`},
+		{"skills/demo/SKILL.md", `"skills/demo/SKILL.md"`},
+		{"ghp_A1b2C3d4E5f6G7h8I9j0", `"ghp_…" (redacted, 24 chars)`},
+		{strings.Repeat("word ", 20), `(truncated, 100 chars)`},
+	}
+	for _, tt := range tests {
+		if got := loggedArg(tt.arg); !strings.Contains(got, tt.want) {
+			t.Errorf("loggedArg(%v) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+	if got := loggedArg("ghp_A1b2C3d4E5f6G7h8I9j0"); strings.Contains(got, "A1b2") {
+		t.Errorf("secret logged verbatim: %s", got)
+	}
+}
+
+func TestSQLClientChangedPackagesSince(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	query, pairs := ChangedPackagesSinceQuery()
+	fc.setRows(query, []string{"from_id", "to_id"},
+		[]driver.Value{nil, "pkg-added"},
+		[]driver.Value{"pkg-removed", nil},
+		[]driver.Value{"pkg-modified", "pkg-modified"},
+		// A file change to an already modified package collapses into it.
+		[]driver.Value{"pkg-modified", "pkg-modified"},
+		[]driver.Value{nil, "pkg-files-only"},
+	)
+	c := NewSQLClient(db, DefaultConfig())
+
+	got, err := c.ChangedPackagesSince(context.Background(), "abc123", "staging")
+	if err != nil {
+		t.Fatalf("ChangedPackagesSince failed: %v", err)
+	}
+	if want := "[pkg-added pkg-files-only pkg-modified pkg-removed]"; fmt.Sprint(got) != want {
+		t.Errorf("changed = %v, want %s", got, want)
+	}
+
+	calls := fc.recorded()
+	if len(calls) != 1 || len(calls[0].args) != 2*pairs {
+		t.Fatalf("recorded %v, want one query with %d args", calls, 2*pairs)
+	}
+	if calls[0].args[0] != "abc123" || calls[0].args[1] != "staging" {
+		t.Errorf("args = %v, want the commit and branch bound in pairs", calls[0].args)
+	}
+
+	if _, err := c.ChangedPackagesSince(context.Background(), "", ""); err == nil {
+		t.Error("expected an error for an empty commit")
+	}
+	if _, err := c.ChangedPackagesSince(context.Background(), "abc123", "bad`branch"); err == nil {
+		t.Error("expected an error for an invalid branch")
+	}
+}
+
+func TestMockClientChangedPackagesSince(t *testing.T) {
+	t.Parallel()
+	m := NewMockClient()
+	m.Changed = []string{"b", "a", "b"}
+
+	got, err := m.ChangedPackagesSince(context.Background(), "abc123", "")
+	if err != nil || fmt.Sprint(got) != "[a b]" {
+		t.Errorf("got %v, %v; want [a b]", got, err)
+	}
+	m.ChangedErr = errors.New("boom")
+	if _, err := m.ChangedPackagesSince(context.Background(), "abc123", ""); err == nil {
+		t.Error("expected ChangedErr")
 	}
 }
@@ -0,0 +1,46 @@
+package dolt
+
+import "fmt"
+
+// WithDatabase returns a client for database name on the same server, with
+// the receiver's settings, observer, and query timeout. The receiver keeps
+// its database and branch. The new client has its own connection pool,
+// since a USE on a shared pool would move whichever connection ran it,
+// and it is opened with the same session settings. Closing either
+// client does not close the other.
+func (c *SQLClient) WithDatabase(name string) (Client, error) {
+	if err := ValidateDatabaseName(name); err != nil {
+		return nil, err
+	}
+	cfg := c.cfg
+	cfg.Database = name
+	db, err := c.open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("opening database %q: %w", name, err)
+	}
+	view := NewSQLClient(db, cfg)
+	view.open = c.open
+	view.observer = c.observer
+	view.queryTimeout = c.queryTimeout
+	c.log.Debug("opened dolt database view", "from", c.database, "to", name)
+	return view, nil
+}
+
+// WithDatabase returns the mock registered for name in Databases, creating
+// and registering an empty one on first use.
+func (m *MockClient) WithDatabase(name string) (Client, error) {
+	if err := ValidateDatabaseName(name); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Databases == nil {
+		m.Databases = make(map[string]*MockClient)
+	}
+	view, ok := m.Databases[name]
+	if !ok {
+		view = NewMockClient()
+		m.Databases[name] = view
+	}
+	return view, nil
+}
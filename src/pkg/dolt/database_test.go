@@ -0,0 +1,94 @@
+package dolt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestSQLClientWithDatabase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	origDB, orig := newFakeDB(t)
+	stagingDB, staging := newFakeDB(t)
+	opts := ListOptions{}
+	staging.setRows(listQuery(opts), listPackagesColumns,
+		[]driver.Value{"pkg-s", "staged", "1.0.0", nil, "", "any", nil, nil},
+	)
+
+	c := NewSQLClient(origDB, DefaultConfig())
+	var opened []string
+	c.open = func(cfg Config) (*sql.DB, error) {
+		opened = append(opened, cfg.Database)
+		return stagingDB, nil
+	}
+
+	view, err := c.WithDatabase("synaptic_canvas_staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opened) != 1 || opened[0] != "synaptic_canvas_staging" {
+		t.Fatalf("opened %v, want [synaptic_canvas_staging]", opened)
+	}
+	if got := view.(*SQLClient).cfg.Database; got != "synaptic_canvas_staging" {
+		t.Errorf("view database = %q, want synaptic_canvas_staging", got)
+	}
+	if c.cfg.Database != "synaptic_canvas" || c.database != "synaptic_canvas" {
+		t.Errorf("original database changed to %q", c.cfg.Database)
+	}
+
+	pkgs, err := view.ListPackages(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].ID != "pkg-s" {
+		t.Errorf("got %+v, want [pkg-s]", pkgs)
+	}
+	if n := len(orig.recorded()); n != 0 {
+		t.Errorf("original connection saw %d calls, want 0", n)
+	}
+	if !view.(*SQLClient).cfg.ReadOnly {
+		t.Error("view should keep the read-only setting")
+	}
+	if execs := staging.execs(); len(execs) != 0 {
+		t.Errorf("view execs = %v, want none", execs)
+	}
+}
+
+func TestSQLClientWithDatabaseInvalid(t *testing.T) {
+	t.Parallel()
+	db, _ := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	c.open = func(Config) (*sql.DB, error) {
+		t.Error("an invalid name should not open a connection")
+		return db, nil
+	}
+	if _, err := c.WithDatabase("prod`; DROP"); err == nil {
+		t.Error("expected an error for an invalid database name")
+	}
+}
+
+func TestMockClientWithDatabase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", nil))
+
+	view, err := m.WithDatabase("staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	view.(*MockClient).AddPackage(NewTestPackage("pkg-s", "staged", "1.0.0", nil))
+
+	again, err := m.WithDatabase("staging")
+	if err != nil || again != view {
+		t.Errorf("second WithDatabase = %v, %v, want the same client", again, err)
+	}
+	if pkgs, _ := m.ListPackages(ctx, ListOptions{}); len(pkgs) != 1 || pkgs[0].ID != "pkg-1" {
+		t.Errorf("original packages = %+v, want [pkg-1]", pkgs)
+	}
+	if _, err := m.WithDatabase(""); err == nil {
+		t.Error("expected an error for an empty database name")
+	}
+}
@@ -0,0 +1,226 @@
+// Package dolttest runs a real dolt sql-server for integration tests.
+//
+// Start creates a temporary Dolt repository, applies the schema from
+// sql/001-create-tables.sql, starts "dolt sql-server" on a free local port,
+// and returns a connected *dolt.SQLClient. Tests are skipped when no dolt
+// binary is on PATH, so they are safe to run everywhere.
+package dolttest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// schemaFile is the schema applied to every test repository, relative to
+// the repository root.
+const schemaFile = "sql/001-create-tables.sql"
+
+// startTimeout bounds how long Start waits for the server to accept
+// connections.
+const startTimeout = 30 * time.Second
+
+// Server is a dolt sql-server running against a temporary repository. It is
+// stopped when the test that started it finishes.
+type Server struct {
+	// Client is a read-write client connected to the server.
+	Client *dolt.SQLClient
+	// DB is a separate handle used by the seed helpers.
+	DB *sql.DB
+	// Config is the configuration Client was opened with.
+	Config dolt.Config
+}
+
+// Start starts a dolt sql-server seeded with the schema and returns it. It
+// skips the test if the dolt binary is not available and fails it if the
+// server cannot be started.
+func Start(t testing.TB) *Server {
+	t.Helper()
+	if _, err := exec.LookPath("dolt"); err != nil {
+		t.Skip("dolt binary not found on PATH; skipping integration test")
+	}
+	schema := findSchema(t)
+
+	cfg := dolt.DefaultConfig()
+	cfg.ReadOnly = false
+
+	root := t.TempDir()
+	repo := filepath.Join(root, cfg.Database)
+	if err := os.Mkdir(repo, 0o755); err != nil {
+		t.Fatalf("creating dolt repository: %v", err)
+	}
+	// DOLT_ROOT_PATH keeps the global config out of the user's home.
+	env := append(os.Environ(), "DOLT_ROOT_PATH="+root)
+	runDolt(t, env, repo, nil, "config", "--global", "--add", "user.name", "dolttest")
+	runDolt(t, env, repo, nil, "config", "--global", "--add", "user.email", "dolttest@example.com")
+	runDolt(t, env, repo, nil, "init")
+
+	f, err := os.Open(schema)
+	if err != nil {
+		t.Fatalf("opening schema: %v", err)
+	}
+	defer f.Close()
+	runDolt(t, env, repo, f, "sql")
+
+	cfg.Port = freePort(t)
+	server := exec.Command("dolt", "sql-server", "--host", cfg.Host, "--port", fmt.Sprint(cfg.Port))
+	server.Dir = repo
+	server.Env = env
+	var logs strings.Builder
+	server.Stdout = &logs
+	server.Stderr = &logs
+	if err := server.Start(); err != nil {
+		t.Fatalf("starting dolt sql-server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = server.Process.Kill()
+		_ = server.Wait()
+	})
+
+	client, err := waitForServer(cfg)
+	if err != nil {
+		t.Fatalf("dolt sql-server did not start: %v\n%s", err, logs.String())
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	db, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		t.Fatalf("opening seed connection: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return &Server{Client: client, DB: db, Config: cfg}
+}
+
+// InsertPackage inserts p into the packages table. An empty AgentVariant
+// is stored as "claude", the schema default.
+func (s *Server) InsertPackage(t testing.TB, p *models.Package) {
+	t.Helper()
+	variant := p.AgentVariant
+	if variant == "" {
+		variant = "claude"
+	}
+	s.exec(t, `INSERT INTO packages (id, name, version, description, agent_variant, author, license, tags, install_scope, variables, options, sha256, min_claude_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Name, p.Version, p.Description, variant, p.Author, p.License, p.Tags,
+		string(p.InstallScope), jsonArg(p.Variables), jsonArg(p.Options), p.SHA256, p.MinClaudeVer)
+}
+
+// InsertFiles inserts files into the package_files table.
+func (s *Server) InsertFiles(t testing.TB, files ...models.PackageFile) {
+	t.Helper()
+	for _, f := range files {
+		s.exec(t, `INSERT INTO package_files (package_id, dest_path, content, sha256, file_type, content_type, is_template, frontmatter, fm_name, fm_description, fm_version, fm_model)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			f.PackageID, f.DestPath, f.Content, f.SHA256, string(f.FileType), string(f.ContentType),
+			f.IsTemplate, jsonArg(f.Frontmatter), f.FMName, f.FMDescription, f.FMVersion, f.FMModel)
+	}
+}
+
+// NewTestFile is a helper that creates a markdown PackageFile with its
+// SHA256 computed from content, mirroring dolt.NewTestPackage.
+func NewTestFile(packageID, destPath string, fileType models.FileType, content string) models.PackageFile {
+	return models.PackageFile{
+		PackageID:   packageID,
+		DestPath:    destPath,
+		Content:     content,
+		SHA256:      models.FileSHA256([]byte(content)),
+		FileType:    fileType,
+		ContentType: models.ContentTypeMarkdown,
+	}
+}
+
+// exec runs a seed statement and fails the test on error.
+func (s *Server) exec(t testing.TB, query string, args ...any) {
+	t.Helper()
+	if _, err := s.DB.Exec(query, args...); err != nil {
+		t.Fatalf("seeding dolt: %v", err)
+	}
+}
+
+// jsonArg returns raw as a string argument, or nil for an empty value so
+// the column is stored as NULL.
+func jsonArg(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// runDolt runs a dolt subcommand in dir and fails the test on error.
+func runDolt(t testing.TB, env []string, dir string, stdin *os.File, args ...string) {
+	t.Helper()
+	cmd := exec.Command("dolt", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("dolt %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+}
+
+// waitForServer opens a client for cfg, retrying until the server accepts
+// connections or startTimeout passes.
+func waitForServer(cfg dolt.Config) (*dolt.SQLClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	for {
+		client, err := dolt.Open(cfg)
+		if err == nil {
+			return client, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// freePort returns a TCP port on the loopback interface that was free when
+// checked.
+func freePort(t testing.TB) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// findSchema walks up from the working directory to the repository root
+// holding schemaFile.
+func findSchema(t testing.TB) string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("locating schema: %v", err)
+	}
+	for {
+		path := filepath.Join(dir, schemaFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		} else if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("locating schema: %v", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatalf("locating schema: %s not found above the working directory", schemaFile)
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,43 @@
+package dolttest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestGetManifestRoundTrip(t *testing.T) {
+	s := Start(t)
+
+	pkg := dolt.NewTestPackage("pkg-rt", "round-trip", "1.2.0", []string{"git", "commit"})
+	s.InsertPackage(t, pkg)
+	s.InsertFiles(t,
+		NewTestFile("pkg-rt", "skills/rt/SKILL.md", models.FileTypeSkill, "# skill\n"),
+		NewTestFile("pkg-rt", "agents/rt.md", models.FileTypeAgent, "# agent\n"),
+		NewTestFile("pkg-rt", "commands/rt.md", models.FileTypeCommand, "# command\n"),
+	)
+
+	m, err := s.Client.GetManifest(context.Background(), "pkg-rt", dolt.ListOptions{})
+	if err != nil {
+		t.Fatalf("GetManifest: %v", err)
+	}
+	if m.ID != "pkg-rt" || m.Name != "round-trip" || m.Version != "1.2.0" {
+		t.Errorf("manifest = %+v, want pkg-rt round-trip 1.2.0", m)
+	}
+	if fmt.Sprint(m.Tags) != "[git commit]" {
+		t.Errorf("tags = %v, want [git commit]", m.Tags)
+	}
+	want := map[string]string{
+		"skills":   "[skills/rt/SKILL.md]",
+		"agents":   "[agents/rt.md]",
+		"commands": "[commands/rt.md]",
+	}
+	for key, paths := range want {
+		if got := fmt.Sprint(m.Artifacts[key]); got != paths {
+			t.Errorf("artifacts[%s] = %s, want %s", key, got, paths)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package dolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEmbeddedReadyTimeout bounds how long EmbeddedServer.Start waits for
+// the server to accept connections when ReadyTimeout is zero.
+const DefaultEmbeddedReadyTimeout = 30 * time.Second
+
+// maxStderr caps how much of the server's stderr execProcess keeps; only
+// the tail is needed to explain a failed start.
+const maxStderr = 4 << 10
+
+// embeddedStopTimeout is how long Stop waits after an interrupt before
+// killing the server.
+const embeddedStopTimeout = 5 * time.Second
+
+// ServerLauncher starts a long-running program in dir and returns a handle
+// to stop it. It is an interface so tests can avoid needing a dolt binary.
+type ServerLauncher interface {
+	Launch(ctx context.Context, dir, name string, args ...string) (ServerProcess, error)
+}
+
+// ServerProcess is a program started by a ServerLauncher.
+type ServerProcess interface {
+	// Stop terminates the program and waits for it to exit.
+	Stop() error
+	// Done is closed when the program exits on its own or is stopped.
+	Done() <-chan struct{}
+	// Err returns why the program exited once Done is closed, including
+	// the end of what it wrote to stderr.
+	Err() error
+}
+
+// ExecLauncher launches programs with os/exec. The program's stderr is
+// kept so that Err can explain an early exit.
+type ExecLauncher struct{}
+
+// Launch implements ServerLauncher. ctx only bounds starting the program;
+// it keeps running until Stop is called.
+func (ExecLauncher) Launch(ctx context.Context, dir, name string, args ...string) (ServerProcess, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p := &execProcess{done: make(chan struct{})}
+	p.cmd = exec.Command(name, args...)
+	p.cmd.Dir = dir
+	p.cmd.Stderr = &p.stderr
+	if err := p.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		p.err = p.cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// execProcess is a ServerProcess backed by an exec.Cmd.
+type execProcess struct {
+	cmd    *exec.Cmd
+	stderr tailBuffer
+	done   chan struct{}
+	err    error
+}
+
+// Done implements ServerProcess.
+func (p *execProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err implements ServerProcess. It returns nil until the process exits.
+func (p *execProcess) Err() error {
+	select {
+	case <-p.done:
+	default:
+		return nil
+	}
+	if p.err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(string(p.stderr)); msg != "" {
+		return fmt.Errorf("%w: %s", p.err, msg)
+	}
+	return p.err
+}
+
+// tailBuffer is an io.Writer that keeps the last maxStderr bytes written.
+// exec.Cmd.Wait returns only after the last write, so it is read without
+// locking once the process is done.
+type tailBuffer []byte
+
+// Write implements io.Writer.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	if over := len(*b) - maxStderr; over > 0 {
+		*b = append((*b)[:0], (*b)[over:]...)
+	}
+	return len(p), nil
+}
+
+// Stop interrupts the process, killing it if it has not exited after
+// embeddedStopTimeout. An exit caused by the signal is not an error.
+func (p *execProcess) Stop() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+	}
+	_ = p.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.done:
+	case <-time.After(embeddedStopTimeout):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+	var exitErr *exec.ExitError
+	if errors.As(p.err, &exitErr) {
+		return nil
+	}
+	return p.err
+}
+
+// EmbeddedServer runs "dolt sql-server" against the local Dolt repository
+// in Dir, for users who do not run a server of their own. Start launches it
+// on an ephemeral loopback port and returns the Config to connect with;
+// Stop shuts it down. An EmbeddedServer can be started once at a time.
+type EmbeddedServer struct {
+	// Dir is the Dolt repository directory. Its base name, with dashes
+	// replaced by underscores, is the database name the server exposes.
+	Dir string
+
+	// Launcher starts the server process. Defaults to ExecLauncher.
+	Launcher ServerLauncher
+
+	// ReadyTimeout bounds the wait for the server to accept connections.
+	// Zero means DefaultEmbeddedReadyTimeout.
+	ReadyTimeout time.Duration
+
+	// lookPath and ready are replaced in tests.
+	lookPath func(string) (string, error)
+	ready    func(ctx context.Context, addr string) error
+
+	mu   sync.Mutex
+	proc ServerProcess
+}
+
+// Start launches the server and waits until it accepts connections. It
+// fails if no dolt binary is on PATH, and stops waiting if the server
+// exits, returning its Err. The returned Config is DefaultConfig pointed at
+// the server, with Database named after Dir's absolute path, so that "."
+// names the current directory.
+func (s *EmbeddedServer) Start(ctx context.Context) (Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc != nil {
+		return Config{}, errors.New("starting embedded dolt server: already started")
+	}
+	if s.Dir == "" {
+		return Config{}, errors.New("starting embedded dolt server: no dolt directory")
+	}
+	lookPath := s.lookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	if _, err := lookPath("dolt"); err != nil {
+		return Config{}, fmt.Errorf("starting embedded dolt server: %w", err)
+	}
+	dir, err := filepath.Abs(s.Dir)
+	if err != nil {
+		return Config{}, fmt.Errorf("starting embedded dolt server: %w", err)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return Config{}, fmt.Errorf("starting embedded dolt server: %w", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Port = port
+	cfg.Database = strings.ReplaceAll(filepath.Base(dir), "-", "_")
+
+	launcher := s.Launcher
+	if launcher == nil {
+		launcher = ExecLauncher{}
+	}
+	proc, err := launcher.Launch(ctx, dir, "dolt", "sql-server",
+		"--host", cfg.Host, "--port", strconv.Itoa(port))
+	if err != nil {
+		return Config{}, fmt.Errorf("starting embedded dolt server: %w", err)
+	}
+
+	timeout := s.ReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultEmbeddedReadyTimeout
+	}
+	readyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-proc.Done():
+			cancel()
+		case <-readyCtx.Done():
+		}
+	}()
+	ready := s.ready
+	if ready == nil {
+		ready = waitForListener
+	}
+	if err := ready(readyCtx, net.JoinHostPort(cfg.Host, strconv.Itoa(port))); err != nil {
+		select {
+		case <-proc.Done():
+			if exitErr := proc.Err(); exitErr != nil {
+				return Config{}, fmt.Errorf("embedded dolt server exited during startup: %w", exitErr)
+			}
+			return Config{}, errors.New("embedded dolt server exited during startup")
+		default:
+		}
+		_ = proc.Stop()
+		return Config{}, fmt.Errorf("waiting for embedded dolt server: %w", err)
+	}
+	s.proc = proc
+	return cfg, nil
+}
+
+// Stop shuts the server down. It is a no-op if the server is not running.
+func (s *EmbeddedServer) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc == nil {
+		return nil
+	}
+	err := s.proc.Stop()
+	s.proc = nil
+	if err != nil {
+		return fmt.Errorf("stopping embedded dolt server: %w", err)
+	}
+	return nil
+}
+
+// OpenEmbedded starts srv and opens a client connected to it with the
+// settings of base other than the address and database. Closing the client
+// stops the server.
+func OpenEmbedded(ctx context.Context, srv *EmbeddedServer, base Config) (*SQLClient, error) {
+	return openEmbedded(ctx, srv, base, OpenContext)
+}
+
+// openEmbedded is OpenEmbedded with the client constructor replaceable.
+func openEmbedded(ctx context.Context, srv *EmbeddedServer, base Config, open func(context.Context, Config) (*SQLClient, error)) (*SQLClient, error) {
+	addr, err := srv.Start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := base
+	cfg.Host, cfg.Port, cfg.Socket, cfg.Database = addr.Host, addr.Port, "", addr.Database
+	client, err := open(ctx, cfg)
+	if err != nil {
+		_ = srv.Stop()
+		return nil, err
+	}
+	client.onClose = srv.Stop
+	return client, nil
+}
+
+// freePort returns a loopback TCP port that was free when checked.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("finding a free port: %w", err)
+	}
+	defer func() { _ = l.Close() }()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitForListener polls addr until it accepts a TCP connection or ctx is
+// done.
+func waitForListener(ctx context.Context, addr string) error {
+	var d net.Dialer
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", addr, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package dolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeLauncher records launches and stops into a shared event log.
+type fakeLauncher struct {
+	mu     sync.Mutex
+	events *[]string
+	args   []string
+	err    error
+
+	// exited, if set, is the process's Done channel and exitErr its Err.
+	exited  chan struct{}
+	exitErr error
+}
+
+func (l *fakeLauncher) Launch(_ context.Context, dir, name string, args ...string) (ServerProcess, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.args = append([]string{dir, name}, args...)
+	*l.events = append(*l.events, "start")
+	return fakeProcess{l}, nil
+}
+
+type fakeProcess struct{ l *fakeLauncher }
+
+func (p fakeProcess) Stop() error {
+	p.l.mu.Lock()
+	defer p.l.mu.Unlock()
+	*p.l.events = append(*p.l.events, "stop")
+	return nil
+}
+
+func (p fakeProcess) Done() <-chan struct{} { return p.l.exited }
+
+func (p fakeProcess) Err() error { return p.l.exitErr }
+
+func newFakeEmbedded(events *[]string) (*EmbeddedServer, *fakeLauncher) {
+	l := &fakeLauncher{events: events}
+	return &EmbeddedServer{
+		Dir:      "/data/synaptic-canvas",
+		Launcher: l,
+		lookPath: func(string) (string, error) { return "/usr/bin/dolt", nil },
+		ready: func(context.Context, string) error {
+			*events = append(*events, "ready")
+			return nil
+		},
+	}, l
+}
+
+func TestOpenEmbeddedOrdering(t *testing.T) {
+	t.Parallel()
+	var events []string
+	srv, l := newFakeEmbedded(&events)
+	db, _ := newFakeDB(t)
+
+	var got Config
+	c, err := openEmbedded(context.Background(), srv, DefaultConfig(), func(_ context.Context, cfg Config) (*SQLClient, error) {
+		events = append(events, "connect")
+		got = cfg
+		return NewSQLClient(db, cfg), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Database != "synaptic_canvas" || got.Host != "127.0.0.1" || got.Port == 0 {
+		t.Errorf("client config = %+v, want the embedded server address", got)
+	}
+	wantArgs := fmt.Sprintf("[/data/synaptic-canvas dolt sql-server --host 127.0.0.1 --port %d]", got.Port)
+	if fmt.Sprint(l.args) != wantArgs {
+		t.Errorf("launch args = %v, want %s", l.args, wantArgs)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if fmt.Sprint(events) != "[start ready connect stop]" {
+		t.Errorf("events = %v, want start, ready, connect, stop", events)
+	}
+	if err := srv.Stop(); err != nil || len(events) != 4 {
+		t.Errorf("second Stop = %v, events %v; want a no-op", err, events)
+	}
+}
+
+func TestOpenEmbeddedConnectFailureStopsServer(t *testing.T) {
+	t.Parallel()
+	var events []string
+	srv, _ := newFakeEmbedded(&events)
+
+	_, err := openEmbedded(context.Background(), srv, DefaultConfig(), func(context.Context, Config) (*SQLClient, error) {
+		return nil, errors.New("refused")
+	})
+	if err == nil || err.Error() != "refused" {
+		t.Fatalf("err = %v, want refused", err)
+	}
+	if fmt.Sprint(events) != "[start ready stop]" {
+		t.Errorf("events = %v, want the server stopped", events)
+	}
+}
+
+func TestEmbeddedServerStartErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no dolt binary", func(t *testing.T) {
+		t.Parallel()
+		var events []string
+		srv, _ := newFakeEmbedded(&events)
+		srv.lookPath = func(string) (string, error) { return "", &exec.Error{Name: "dolt", Err: exec.ErrNotFound} }
+		_, err := srv.Start(context.Background())
+		if !errors.Is(err, exec.ErrNotFound) || len(events) != 0 {
+			t.Errorf("err = %v, events %v; want ErrNotFound before launching", err, events)
+		}
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		t.Parallel()
+		var events []string
+		srv, _ := newFakeEmbedded(&events)
+		srv.ready = func(context.Context, string) error { return context.DeadlineExceeded }
+		_, err := srv.Start(context.Background())
+		if !errors.Is(err, context.DeadlineExceeded) || fmt.Sprint(events) != "[start stop]" {
+			t.Errorf("err = %v, events %v; want the server stopped after a failed start", err, events)
+		}
+	})
+
+	t.Run("server exits", func(t *testing.T) {
+		t.Parallel()
+		var events []string
+		srv, l := newFakeEmbedded(&events)
+		l.exited = make(chan struct{})
+		l.exitErr = errors.New("exit status 1: port already in use")
+		close(l.exited)
+		srv.ready = func(ctx context.Context, _ string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		_, err := srv.Start(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "exited during startup") || !strings.Contains(err.Error(), "port already in use") {
+			t.Errorf("err = %v, want the exit reason", err)
+		}
+	})
+
+	t.Run("no directory", func(t *testing.T) {
+		t.Parallel()
+		var events []string
+		srv, _ := newFakeEmbedded(&events)
+		srv.Dir = ""
+		if _, err := srv.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "no dolt directory") {
+			t.Errorf("err = %v, want no dolt directory", err)
+		}
+	})
+}
+
+func TestEmbeddedServerRelativeDir(t *testing.T) {
+	t.Parallel()
+	var events []string
+	srv, l := newFakeEmbedded(&events)
+	srv.Dir = "."
+	cfg, err := srv.Start(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = srv.Stop() }()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(filepath.Base(wd), "-", "_"); cfg.Database != want {
+		t.Errorf("Database = %q, want %q", cfg.Database, want)
+	}
+	if l.args[0] != wd {
+		t.Errorf("launch dir = %q, want %q", l.args[0], wd)
+	}
+}
+
+func TestExecLauncherKeepsStderr(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh on PATH")
+	}
+	proc, err := ExecLauncher{}.Launch(context.Background(), t.TempDir(), "sh", "-c", "echo 'cannot bind port' >&2; exit 3")
+	if err != nil {
+		t.Fatalf("Launch failed: %v", err)
+	}
+	<-proc.Done()
+	err = proc.Err()
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") || !strings.Contains(err.Error(), "cannot bind port") {
+		t.Errorf("Err() = %v, want the exit status and stderr", err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	t.Parallel()
+	var b tailBuffer
+	_, _ = b.Write([]byte(strings.Repeat("a", maxStderr)))
+	_, _ = b.Write([]byte("tail"))
+	if len(b) != maxStderr || !strings.HasSuffix(string(b), "tail") {
+		t.Errorf("buffer has %d bytes ending %q, want the last %d bytes", len(b), b[len(b)-4:], maxStderr)
+	}
+}
@@ -0,0 +1,35 @@
+package dolt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by single-row lookups (GetPackage, ResolveVariant,
+// ResolveVariantChain) when no matching row exists. Test for it with
+// errors.Is; the returned error is wrapped with the key that was looked up.
+var ErrNotFound = errors.New("not found")
+
+// ErrWriteQuery is returned by QueryRaw for a statement that is not a read.
+var ErrWriteQuery = errors.New("only read statements are allowed")
+
+// QueryError wraps a failure from the database driver with the client
+// operation and SQL that produced it. Use errors.As to inspect it; Unwrap
+// exposes the underlying driver or context error.
+type QueryError struct {
+	// Op is the client operation name, as reported to the Observer.
+	Op string
+	// Query is the SQL text that failed.
+	Query string
+	Err   error
+}
+
+// Error implements error. The SQL text is omitted to keep messages short.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s query failed: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,81 @@
+package dolt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSQLClientGetPackageNotFound(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(GetPackageQuery(), []string{"id"})
+	c := NewSQLClient(db, DefaultConfig())
+
+	p, err := c.GetPackage(context.Background(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if p != nil {
+		t.Errorf("expected nil package, got %+v", p)
+	}
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		t.Errorf("not-found should not be a QueryError: %v", err)
+	}
+}
+
+func TestSQLClientResolveVariantNotFound(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(ResolveVariantQuery(), []string{"variant_package_id"})
+	c := NewSQLClient(db, DefaultConfig())
+
+	if _, err := c.ResolveVariant(context.Background(), "logical-1", "codex"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLClientQueryError(t *testing.T) {
+	t.Parallel()
+	driverErr := errors.New("table packages doesn't exist")
+
+	t.Run("single-row lookup", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setErr(GetPackageQuery(), driverErr)
+		c := NewSQLClient(db, DefaultConfig())
+
+		_, err := c.GetPackage(context.Background(), "pkg-1")
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Fatalf("err = %v, want *QueryError", err)
+		}
+		if qe.Op != "GetPackage" || qe.Query != GetPackageQuery() {
+			t.Errorf("QueryError = {Op: %q, Query: %q}", qe.Op, qe.Query)
+		}
+		if !errors.Is(err, driverErr) {
+			t.Error("QueryError should unwrap to the driver error")
+		}
+		if errors.Is(err, ErrNotFound) {
+			t.Error("driver failure must not look like not-found")
+		}
+	})
+
+	t.Run("multi-row query", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		fc.setErr(GetPackageFilesQuery(), driverErr)
+		c := NewSQLClient(db, DefaultConfig())
+
+		_, err := c.GetPackageFiles(context.Background(), "pkg-1")
+		var qe *QueryError
+		if !errors.As(err, &qe) || qe.Op != "GetPackageFiles" {
+			t.Fatalf("err = %v, want *QueryError for GetPackageFiles", err)
+		}
+		if !strings.Contains(err.Error(), "GetPackageFiles") {
+			t.Errorf("error %q should name the operation", err)
+		}
+	})
+}
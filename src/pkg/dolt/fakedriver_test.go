@@ -0,0 +1,255 @@
+package dolt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeConnector is a database/sql driver.Connector that serves canned results
+// keyed by exact query text, optionally with its arguments, and records
+// every statement it receives. It lets
+// tests exercise SQLClient's real query and scan paths without a Dolt server.
+type fakeConnector struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	calls   []fakeCall
+	pingErr error
+	// opened records the database of each Config passed to open.
+	opened []string
+}
+
+// fakeResult is the canned response for a single query text.
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+	// block makes the statement wait until its context is done.
+	block bool
+	// rowsErr is returned from Rows.Next after all rows are consumed.
+	rowsErr error
+}
+
+// fakeCall records one statement sent to the fake driver.
+type fakeCall struct {
+	query string
+	args  []driver.Value
+	exec  bool
+}
+
+// newFakeDB returns a *sql.DB backed by a fresh fakeConnector.
+func newFakeDB(t *testing.T) (*sql.DB, *fakeConnector) {
+	t.Helper()
+	fc := &fakeConnector{results: make(map[string]fakeResult)}
+	db := sql.OpenDB(fc)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, fc
+}
+
+// open is an SQLClient.open replacement that serves every Config from fc
+// through a new *sql.DB, recording the database it was asked for.
+func (fc *fakeConnector) open(cfg Config) (*sql.DB, error) {
+	fc.mu.Lock()
+	fc.opened = append(fc.opened, cfg.Database)
+	fc.mu.Unlock()
+	return sql.OpenDB(fc), nil
+}
+
+// openedDatabases returns the databases passed to open, in order.
+func (fc *fakeConnector) openedDatabases() []string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return append([]string(nil), fc.opened...)
+}
+
+// setRows registers the columns and rows returned for query.
+func (fc *fakeConnector) setRows(query string, columns []string, rows ...[]driver.Value) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.results[query] = fakeResult{columns: columns, rows: rows}
+}
+
+// setRowsFor registers the columns and rows returned for query when it is
+// run with args. They take precedence over rows set for query alone.
+func (fc *fakeConnector) setRowsFor(query string, args []any, columns []string, rows ...[]driver.Value) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.results[argsKey(query, args)] = fakeResult{columns: columns, rows: rows}
+}
+
+// argsKey is the results key for query run with args.
+func argsKey(query string, args any) string {
+	return query + "\x00" + fmt.Sprint(args)
+}
+
+// setRowsErr registers rows for query that end with err instead of io.EOF.
+func (fc *fakeConnector) setRowsErr(query string, columns []string, err error, rows ...[]driver.Value) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.results[query] = fakeResult{columns: columns, rows: rows, rowsErr: err}
+}
+
+// setErr registers an error returned for query.
+func (fc *fakeConnector) setErr(query string, err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.results[query] = fakeResult{err: err}
+}
+
+// setBlock makes query block until the caller's context is done.
+func (fc *fakeConnector) setBlock(query string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.results[query] = fakeResult{block: true}
+}
+
+// recorded returns a copy of every statement received so far.
+func (fc *fakeConnector) recorded() []fakeCall {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return append([]fakeCall(nil), fc.calls...)
+}
+
+// execs returns the text of every Exec statement received so far.
+func (fc *fakeConnector) execs() []string {
+	var out []string
+	for _, c := range fc.recorded() {
+		if c.exec {
+			out = append(out, c.query)
+		}
+	}
+	return out
+}
+
+func (fc *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{fc: fc}, nil
+}
+
+func (fc *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+// lookup records a call and returns the canned result for it.
+func (fc *fakeConnector) lookup(ctx context.Context, query string, args []driver.NamedValue, exec bool) (fakeResult, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	fc.mu.Lock()
+	fc.calls = append(fc.calls, fakeCall{query: query, args: values, exec: exec})
+	res, ok := fc.results[argsKey(query, values)]
+	if !ok {
+		res, ok = fc.results[query]
+	}
+	fc.mu.Unlock()
+
+	if res.block {
+		<-ctx.Done()
+		return fakeResult{}, ctx.Err()
+	}
+	if res.err != nil {
+		return fakeResult{}, res.err
+	}
+	if !ok && !exec {
+		return fakeResult{}, errors.New("fakedriver: no result registered for query: " + query)
+	}
+	return res, nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakedriver: open via the connector")
+}
+
+type fakeConn struct {
+	fc *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fakedriver: transactions not supported")
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	c.fc.mu.Lock()
+	defer c.fc.mu.Unlock()
+	return c.fc.pingErr
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.fc.lookup(ctx, query, args, false)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows, err: res.rowsErr}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.fc.lookup(ctx, query, args, true); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamed(args))
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamed(args))
+}
+
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func toNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+	err     error
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
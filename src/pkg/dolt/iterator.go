@@ -0,0 +1,143 @@
+package dolt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// PackageIterator yields packages one at a time so large catalogs can be
+// processed without buffering every row. Typical use:
+//
+//	it, err := client.ListPackagesIter(ctx, opts)
+//	if err != nil { ... }
+//	defer it.Close()
+//	for it.Next() {
+//		p := it.Package()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// The caller must call Close, which is safe to call more than once.
+type PackageIterator struct {
+	// rows backs SQL iterators; rows are scanned lazily in Next.
+	rows *sql.Rows
+	// pending backs in-memory iterators such as the mock's.
+	pending []models.Package
+	// cancel releases the context the query runs under.
+	cancel context.CancelFunc
+
+	cur    models.Package
+	err    error
+	closed bool
+}
+
+// newRowsPackageIterator wraps rows from a list-packages query.
+func newRowsPackageIterator(rows *sql.Rows, cancel context.CancelFunc) *PackageIterator {
+	return &PackageIterator{rows: rows, cancel: cancel}
+}
+
+// newSlicePackageIterator iterates over an in-memory slice of packages.
+func newSlicePackageIterator(pkgs []models.Package) *PackageIterator {
+	return &PackageIterator{pending: pkgs}
+}
+
+// Next advances to the next package, returning false when the iteration is
+// exhausted or an error occurs. Check Err after Next returns false.
+func (it *PackageIterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+	if it.rows == nil {
+		if len(it.pending) == 0 {
+			return false
+		}
+		it.cur = it.pending[0]
+		it.pending = it.pending[1:]
+		return true
+	}
+	if !it.rows.Next() {
+		return false
+	}
+	var p models.Package
+	if err := it.rows.Scan(&p.ID, &p.Name, &p.Version, &p.Description, &p.Tags, &p.InstallScope,
+		nullTime{&p.CreatedAt}, nullTime{&p.UpdatedAt}); err != nil {
+		it.err = fmt.Errorf("scanning package row: %w", err)
+		return false
+	}
+	it.cur = p
+	return true
+}
+
+// Package returns the package at the current position.
+func (it *PackageIterator) Package() models.Package {
+	return it.cur
+}
+
+// Err returns the first error encountered during iteration, if any.
+func (it *PackageIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if it.rows != nil {
+		if err := it.rows.Err(); err != nil {
+			return fmt.Errorf("iterating packages: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close releases the underlying rows and query context.
+func (it *PackageIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	var err error
+	if it.rows != nil {
+		err = it.rows.Close()
+	}
+	if it.cancel != nil {
+		it.cancel()
+	}
+	return err
+}
+
+// DefaultPageSize is the page size ForEachPackage uses when given none.
+const DefaultPageSize = 100
+
+// forEachPackage implements ForEachPackage for both clients on top of their
+// ListPackages, requesting pages with increasing offsets until one comes
+// back short.
+func forEachPackage(
+	ctx context.Context,
+	list func(context.Context, ListOptions) ([]models.Package, error),
+	opts ListOptions,
+	pageSize int,
+	fn func(models.Package) error,
+) error {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	opts.Limit, opts.Offset = pageSize, 0
+	for {
+		page, err := list(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, p := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		opts.Offset += pageSize
+	}
+}
@@ -0,0 +1,239 @@
+package dolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestMockClientListPackagesIter(t *testing.T) {
+	t.Parallel()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", nil))
+	m.AddPackage(NewTestPackage("pkg-2", "beta", "1.0.0", nil))
+	m.AddPackage(NewTestPackage("pkg-3", "gamma", "1.0.0", nil))
+
+	it, err := m.ListPackagesIter(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = it.Close() }()
+
+	seen := make(map[string]int)
+	for it.Next() {
+		seen[it.Package().ID]++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected iteration error: %v", err)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("saw %d distinct packages, want 3", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("package %s yielded %d times, want 1", id, n)
+		}
+	}
+}
+
+func TestSQLClientListPackagesIter(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRows(listQuery(ListOptions{}), listPackagesColumns,
+		[]driver.Value{"pkg-1", "alpha", "1.0.0", nil, "", "any", nil, nil},
+		[]driver.Value{"pkg-2", "beta", "1.0.0", nil, "", "any", nil, nil},
+	)
+
+	c := NewSQLClient(db, DefaultConfig())
+	it, err := c.ListPackagesIter(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Package().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected iteration error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "pkg-1" || ids[1] != "pkg-2" {
+		t.Errorf("ids = %v, want [pkg-1 pkg-2]", ids)
+	}
+	if err := it.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := it.Close(); err != nil {
+		t.Fatalf("second Close failed: %v", err)
+	}
+	if it.Next() {
+		t.Error("Next should return false after Close")
+	}
+}
+
+func TestSQLClientListPackagesIterRowsErr(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	fc.setRowsErr(listQuery(ListOptions{}), listPackagesColumns, errors.New("connection reset"),
+		[]driver.Value{"pkg-1", "alpha", "1.0.0", nil, "", "any", nil, nil},
+	)
+
+	c := NewSQLClient(db, DefaultConfig())
+	it, err := c.ListPackagesIter(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = it.Close() }()
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("yielded %d packages before the error, want 1", count)
+	}
+	if it.Err() == nil {
+		t.Fatal("expected Err to report the rows error")
+	}
+
+	// The buffered method surfaces the same error.
+	if _, err := c.ListPackages(context.Background(), ListOptions{}); err == nil {
+		t.Error("expected ListPackages to return the rows error")
+	}
+}
+
+func TestSQLClientCloseContextWaitsForIterators(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	query, _ := ListPackagesQuery(ListOptions{})
+	fc.setRows(query, listPackagesColumns, []driver.Value{"pkg-1", "one", "1.0.0", nil, "", "any", nil, nil})
+	c := NewSQLClient(db, DefaultConfig())
+
+	it, err := c.ListPackagesIter(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for it.Next() {
+		}
+		_ = it.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := c.CloseContext(ctx); err != nil {
+		t.Fatalf("CloseContext failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("CloseContext returned after %s, before the iterator closed", elapsed)
+	}
+	if err := db.PingContext(context.Background()); err == nil {
+		t.Error("database should be closed")
+	}
+}
+
+func TestSQLClientCloseContextDeadline(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	query, _ := ListPackagesQuery(ListOptions{})
+	fc.setRows(query, listPackagesColumns)
+	c := NewSQLClient(db, DefaultConfig())
+
+	it, err := c.ListPackagesIter(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = it.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if err := db.PingContext(context.Background()); err == nil {
+		t.Error("database should be closed after the deadline")
+	}
+}
+
+func TestSQLClientForEachPackagePages(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	row := func(id string) []driver.Value {
+		return []driver.Value{id, id, "1.0.0", nil, "", "any", nil, nil}
+	}
+	// Two full pages of two, then a short page of one.
+	q := listQuery(ListOptions{Limit: 2})
+	fc.setRowsFor(q, []any{2, 0}, listPackagesColumns, row("a"), row("b"))
+	fc.setRowsFor(q, []any{2, 2}, listPackagesColumns, row("c"), row("d"))
+	fc.setRowsFor(q, []any{2, 4}, listPackagesColumns, row("e"))
+	c := NewSQLClient(db, DefaultConfig())
+
+	var seen []string
+	err := c.ForEachPackage(context.Background(), ListOptions{}, 2, func(p models.Package) error {
+		seen = append(seen, p.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachPackage failed: %v", err)
+	}
+	if fmt.Sprint(seen) != "[a b c d e]" {
+		t.Errorf("visited %v, want each package once in order", seen)
+	}
+	c.mu.Lock()
+	cached := len(c.stmts)
+	c.mu.Unlock()
+	if cached != 1 {
+		t.Errorf("%d statements cached, want the pages to share one", cached)
+	}
+}
+
+func TestForEachPackage(t *testing.T) {
+	t.Parallel()
+	m := NewMockClient()
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		m.AddPackage(NewTestPackage(id, id, "1.0.0", nil))
+	}
+
+	for _, pageSize := range []int{1, 2, 4, 6, 10, 0} {
+		var seen []string
+		err := m.ForEachPackage(context.Background(), ListOptions{}, pageSize, func(p models.Package) error {
+			seen = append(seen, p.ID)
+			return nil
+		})
+		if err != nil || fmt.Sprint(seen) != "[a b c d e f]" {
+			t.Errorf("page size %d: visited %v, %v; want every package once", pageSize, seen, err)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := m.ForEachPackage(context.Background(), ListOptions{}, 2, func(models.Package) error {
+		calls++
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || calls != 3 {
+		t.Errorf("err = %v after %d calls, want fn's error after 3", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = m.ForEachPackage(ctx, ListOptions{}, 2, func(models.Package) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want cancellation after 1", err, calls)
+	}
+}
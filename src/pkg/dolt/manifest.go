@@ -0,0 +1,151 @@
+package dolt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/cache"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// fetchBundle fetches the related rows of pkg through c. It is shared by
+// SQLClient and MockClient.
+func fetchBundle(ctx context.Context, c Client, pkg *models.Package) (*models.PackageBundle, error) {
+	files, err := c.GetPackageFiles(ctx, pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := c.GetPackageDeps(ctx, pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := c.GetPackageHooks(ctx, pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := c.GetPackageQuestions(ctx, pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PackageBundle{Package: pkg, Files: files, Deps: deps, Hooks: hooks, Questions: questions}, nil
+}
+
+// buildManifest fetches the related rows of pkg through c and assembles its
+// manifest. It is shared by SQLClient and MockClient.
+func buildManifest(ctx context.Context, c Client, pkg *models.Package) (*models.Manifest, error) {
+	b, err := fetchBundle(ctx, c, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return models.FromBundle(b)
+}
+
+// newManifestCache returns the manifest cache configured by cfg, or nil if
+// caching is disabled or no cache directory can be determined.
+func newManifestCache(cfg Config, logger *slog.Logger) *cache.ManifestCache {
+	if !cfg.CacheEnabled {
+		return nil
+	}
+	dir := cfg.CacheDir
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			logger.Warn("manifest cache disabled", "error", err)
+			return nil
+		}
+	}
+	return cache.NewManifestCache(dir, cfg.CacheTTL)
+}
+
+// GetManifest returns the manifest of package id on opts.Branch. When the
+// manifest cache is enabled, an entry matching the package's current SHA is
+// returned without fetching the package's files and related rows; on a miss
+// the manifest is built from the database and cached.
+func (c *SQLClient) GetManifest(ctx context.Context, id string, opts ListOptions) (*models.Manifest, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+	pkg, err := c.GetPackage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sha string
+	if pkg.SHA256 != nil {
+		sha = *pkg.SHA256
+	}
+	if c.cache != nil {
+		if m, ok := c.cache.Get(opts.Branch, id, sha); ok {
+			c.log.Debug("manifest cache hit", "id", id, "branch", opts.Branch)
+			return m, nil
+		}
+	}
+
+	m, err := buildManifest(ctx, c, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest for %q: %w", id, err)
+	}
+	if c.cache != nil {
+		if err := c.cache.Put(opts.Branch, id, sha, m); err != nil {
+			c.log.Warn("caching manifest failed", "id", id, "error", err)
+		}
+	}
+	return m, nil
+}
+
+// GetManifest builds the manifest of package id from the mock store.
+// The mock never caches.
+func (m *MockClient) GetManifest(ctx context.Context, id string, _ ListOptions) (*models.Manifest, error) {
+	if err := m.enter(ctx, "GetManifest"); err != nil {
+		return nil, err
+	}
+	pkg, err := m.GetPackage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := buildManifest(ctx, m, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest for %q: %w", id, err)
+	}
+	return manifest, nil
+}
+
+// GetBundle returns package id and the rows of its related tables on
+// opts.Branch. The manifest cache is not consulted.
+func (c *SQLClient) GetBundle(ctx context.Context, id string, opts ListOptions) (*models.PackageBundle, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.switchBranch(ctx, opts.Branch); err != nil {
+		return nil, err
+	}
+	pkg, err := c.GetPackage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fetchBundle(ctx, c, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("getting bundle for %q: %w", id, err)
+	}
+	return b, nil
+}
+
+// GetBundle returns package id and its related rows from the mock store.
+func (m *MockClient) GetBundle(ctx context.Context, id string, _ ListOptions) (*models.PackageBundle, error) {
+	if err := m.enter(ctx, "GetBundle"); err != nil {
+		return nil, err
+	}
+	pkg, err := m.GetPackage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fetchBundle(ctx, m, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("getting bundle for %q: %w", id, err)
+	}
+	return b, nil
+}
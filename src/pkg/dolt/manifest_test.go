@@ -0,0 +1,176 @@
+package dolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// setManifestRows registers a package with the given sha and empty related
+// tables on fc.
+func setManifestRows(fc *fakeConnector, id, sha string) {
+	row := packageRow(id)
+	row[11] = sha
+	fc.setRows(GetPackageQuery(), packageColumnNames, row)
+	fc.setRows(GetPackageFilesQuery(), []string{"package_id"})
+	fc.setRows(GetPackageDepsQuery(), []string{"package_id"})
+	fc.setRows(GetPackageHooksQuery(), []string{"package_id"})
+	fc.setRows(GetPackageQuestionsQuery(), []string{"package_id"})
+}
+
+// countQueries returns how many times query was sent to fc.
+func countQueries(fc *fakeConnector, query string) int {
+	n := 0
+	for _, c := range fc.recorded() {
+		if c.query == query {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSQLClientGetManifestCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	cfg := DefaultConfig()
+	cfg.CacheEnabled = true
+	cfg.CacheDir = t.TempDir()
+	c := NewSQLClient(db, cfg)
+
+	setManifestRows(fc, "pkg-1", "sha-1")
+	m, err := c.GetManifest(ctx, "pkg-1", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.ID != "pkg-1" {
+		t.Errorf("ID = %q, want %q", m.ID, "pkg-1")
+	}
+	if n := countQueries(fc, GetPackageFilesQuery()); n != 1 {
+		t.Fatalf("miss should query files once, got %d", n)
+	}
+
+	// Hit: the package row is re-read but files are not.
+	if _, err := c.GetManifest(ctx, "pkg-1", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countQueries(fc, GetPackageFilesQuery()); n != 1 {
+		t.Errorf("hit should not query files, got %d queries", n)
+	}
+
+	// A new package SHA invalidates the cached entry.
+	setManifestRows(fc, "pkg-1", "sha-2")
+	if _, err := c.GetManifest(ctx, "pkg-1", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countQueries(fc, GetPackageFilesQuery()); n != 2 {
+		t.Errorf("sha change should rebuild the manifest, got %d file queries", n)
+	}
+}
+
+func TestSQLClientGetManifestCacheDisabled(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+	setManifestRows(fc, "pkg-1", "sha-1")
+
+	for range 2 {
+		if _, err := c.GetManifest(ctx, "pkg-1", ListOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := countQueries(fc, GetPackageFilesQuery()); n != 2 {
+		t.Errorf("without a cache every call should query files, got %d", n)
+	}
+}
+
+func TestMockClientGetManifest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", []string{"go"}))
+
+	manifest, err := m.GetManifest(ctx, "pkg-1", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest.Name != "alpha" || len(manifest.Tags) != 1 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	if _, err := m.GetManifest(ctx, "missing", ListOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+// checkBundle fails t unless all five parts of b are populated.
+func checkBundle(t *testing.T, b *models.PackageBundle) {
+	t.Helper()
+	if b.Package == nil || b.Package.ID != "pkg-1" {
+		t.Fatalf("Package = %+v, want pkg-1", b.Package)
+	}
+	if len(b.Files) != 1 || b.Files[0].Content != "# A\n" {
+		t.Errorf("Files = %+v, want one file with content", b.Files)
+	}
+	if len(b.Deps) != 1 || len(b.Hooks) != 1 || len(b.Questions) != 1 {
+		t.Errorf("deps/hooks/questions = %d/%d/%d, want 1/1/1", len(b.Deps), len(b.Hooks), len(b.Questions))
+	}
+}
+
+func TestSQLClientGetBundle(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	fc.setRows(GetPackageQuery(), packageColumnNames, packageRow("pkg-1"))
+	fc.setRows(GetPackageFilesQuery(), []string{
+		"package_id", "dest_path", "content", "sha256", "file_type", "content_type",
+		"is_template", "frontmatter", "fm_name", "fm_description", "fm_version", "fm_model",
+	}, []driver.Value{"pkg-1", "skills/a.md", "# A\n", "abc", "skill", "markdown", false, nil, nil, nil, nil, nil})
+	fc.setRows(GetPackageDepsQuery(),
+		[]string{"package_id", "dep_type", "dep_name", "dep_spec", "install_cmd", "cmd_sha256"},
+		[]driver.Value{"pkg-1", "tool", "jq", nil, nil, nil})
+	fc.setRows(GetPackageHooksQuery(),
+		[]string{"package_id", "event", "matcher", "script_path", "priority", "blocking"},
+		[]driver.Value{"pkg-1", "PreToolUse", "Bash", "hooks/pre.sh", int64(0), false})
+	fc.setRows(GetPackageQuestionsQuery(),
+		[]string{"package_id", "question_id", "prompt", "type", "default_val", "choices", "sort_order"},
+		[]driver.Value{"pkg-1", "name", "Name?", "text", "", nil, int64(1)})
+
+	b, err := c.GetBundle(ctx, "pkg-1", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkBundle(t, b)
+
+	fc.setRows(GetPackageQuery(), packageColumnNames)
+	if _, err := c.GetBundle(ctx, "missing", ListOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMockClientGetBundle(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-1", "alpha", "1.0.0", nil))
+	m.AddFiles("pkg-1", []models.PackageFile{{PackageID: "pkg-1", DestPath: "skills/a.md", Content: "# A\n"}})
+	m.AddDeps("pkg-1", []models.PackageDep{{PackageID: "pkg-1", DepType: models.DepTypeTool, DepName: "jq"}})
+	m.AddHooks("pkg-1", []models.PackageHook{{PackageID: "pkg-1", Event: models.HookPreToolUse, ScriptPath: "hooks/pre.sh"}})
+	m.AddQuestions("pkg-1", []models.PackageQuestion{{PackageID: "pkg-1", QuestionID: "name"}})
+
+	b, err := m.GetBundle(ctx, "pkg-1", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkBundle(t, b)
+
+	if _, err := m.GetBundle(ctx, "missing", ListOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
@@ -2,14 +2,25 @@ package dolt
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
 )
 
 // MockClient is an in-memory implementation of Client for testing.
 // It stores test data that can be populated before test execution.
+// Its methods and AddX mutators are safe for concurrent use; the exported
+// maps and fields themselves are not, so populate them before sharing.
 type MockClient struct {
+	// mu guards the data maps, error fields, and Closed.
+	mu sync.RWMutex
+
 	Packages  map[string]*models.Package
 	Files     map[string][]models.PackageFile
 	Deps      map[string][]models.PackageDep
@@ -17,16 +28,53 @@ type MockClient struct {
 	Questions map[string][]models.PackageQuestion
 	Variants  map[string]string // key: "logicalID/agentProfile" -> variantPackageID
 
+	// ActiveBranch is returned by CurrentBranch. NewMockClient sets it to "main".
+	ActiveBranch string
+
+	// Status is returned by GetStatus. Nil means a clean working set.
+	Status []models.StatusEntry
+
+	// Conflicts is returned by GetConflicts. Nil means no conflicts.
+	Conflicts []models.Conflict
+
+	// Changed is returned by ChangedPackagesSince for any commit and
+	// branch, sorted and without duplicates.
+	Changed []string
+
+	// Databases holds the clients returned by WithDatabase, by database
+	// name. Tests may pre-populate it.
+	Databases map[string]*MockClient
+
+	// Delays holds a per-method delay, keyed by method name such as
+	// "ListPackages", applied before the method does anything else. A
+	// context canceled during the delay ends the call with its error, so
+	// tests can cancel mid-call.
+	Delays map[string]time.Duration
+
 	// Error fields allow tests to inject errors for specific operations.
 	ListErr      error
+	CountErr     error
 	GetErr       error
 	FilesErr     error
 	DepsErr      error
 	HooksErr     error
 	QuestionsErr error
 	VariantErr   error
+	StatsErr     error
+	TagsErr      error
+	BranchErr    error
+	ChangedErr   error
+	StatusErr    error
+	ConflictsErr error
+	RawErr       error
 	CloseErr     error
 
+	// RawRows is returned by QueryRaw for any allowed statement. Tests can
+	// build it from a *sql.DB backed by a fake driver.
+	RawRows *sql.Rows
+
+	// Closed is set by Close. Use IsClosed when other goroutines may still
+	// be using the client.
 	Closed bool
 }
 
@@ -39,82 +87,434 @@ func NewMockClient() *MockClient {
 		Hooks:     make(map[string][]models.PackageHook),
 		Questions: make(map[string][]models.PackageQuestion),
 		Variants:  make(map[string]string),
+
+		ActiveBranch: "main",
+	}
+}
+
+// enter is called first by every context-taking method. It waits out the
+// method's entry in Delays and returns ctx's error if ctx is done before or
+// during the wait, like the real client whose queries honor cancellation.
+func (m *MockClient) enter(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	delay := m.Delays[method]
+	m.mu.RUnlock()
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // AddPackage adds a package to the mock data store.
 func (m *MockClient) AddPackage(p *models.Package) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Packages[p.ID] = p
 }
 
 // AddFiles adds files for a package to the mock data store.
 func (m *MockClient) AddFiles(packageID string, files []models.PackageFile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Files[packageID] = files
 }
 
 // AddDeps adds dependencies for a package to the mock data store.
 func (m *MockClient) AddDeps(packageID string, deps []models.PackageDep) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Deps[packageID] = deps
 }
 
 // AddHooks adds hooks for a package to the mock data store.
 func (m *MockClient) AddHooks(packageID string, hooks []models.PackageHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Hooks[packageID] = hooks
 }
 
 // AddQuestions adds questions for a package to the mock data store.
 func (m *MockClient) AddQuestions(packageID string, questions []models.PackageQuestion) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Questions[packageID] = questions
 }
 
 // AddVariant adds a variant mapping to the mock data store.
 func (m *MockClient) AddVariant(logicalID, agentProfile, variantPackageID string) {
 	key := logicalID + "/" + agentProfile
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Variants[key] = variantPackageID
 }
 
-// ListPackages returns all packages in the mock store.
-func (m *MockClient) ListPackages(_ context.Context, _ ListOptions) ([]models.Package, error) {
+// filterPackages returns the stored packages matching opts, ordered by name
+// and then ID to match the SQL client's ORDER BY name deterministically. It is
+// shared by every listing method so they always agree, mirroring the shared
+// packageFilter in the SQL client. Branch is ignored by the mock.
+// The caller must hold m.mu.
+func (m *MockClient) filterPackages(opts ListOptions) []models.Package {
+	result := make([]models.Package, 0, len(m.Packages))
+	for _, p := range m.Packages {
+		if packageMatches(p, opts) {
+			result = append(result, *p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// packageMatches reports whether p satisfies the tag and scope filters in opts.
+func packageMatches(p *models.Package, opts ListOptions) bool {
+	match := containsAll
+	if opts.TagMatch == TagMatchAny {
+		match = containsAny
+	}
+	// Malformed tags match no tag filter, as in SQL.
+	tags, _ := p.TagsList()
+	return match(tags, opts.Tags) && scopeMatches(p.InstallScope, opts)
+}
+
+// scopeMatches reports whether scope satisfies the InstallScope filter in
+// opts, mirroring the install_scope clause built by packageFilter.
+func scopeMatches(scope models.InstallScope, opts ListOptions) bool {
+	if opts.InstallScope == "" || scope == opts.InstallScope {
+		return true
+	}
+	return opts.IncludeAnyScope && scope == models.InstallScopeAny
+}
+
+// containsAll reports whether have includes every non-blank entry of want.
+func containsAll(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, h := range have {
+		set[h] = true
+	}
+	for _, w := range want {
+		w = strings.TrimSpace(w)
+		if w != "" && !set[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny reports whether have includes at least one non-blank entry of
+// want. An empty want matches everything, as with containsAll.
+func containsAny(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, h := range have {
+		set[h] = true
+	}
+	requested := false
+	for _, w := range want {
+		w = strings.TrimSpace(w)
+		if w == "" {
+			continue
+		}
+		requested = true
+		if set[w] {
+			return true
+		}
+	}
+	return !requested
+}
+
+// ListPackages returns the packages in the mock store matching opts, ordered
+// by name.
+func (m *MockClient) ListPackages(ctx context.Context, opts ListOptions) ([]models.Package, error) {
+	if err := m.enter(ctx, "ListPackages"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	if m.ListErr != nil {
 		return nil, m.ListErr
 	}
-	result := make([]models.Package, 0, len(m.Packages))
-	for _, p := range m.Packages {
-		result = append(result, *p)
+	return pageOf(m.filterPackages(opts), opts), nil
+}
+
+// ForEachPackage calls fn for every package in the mock store matching
+// opts, one page of ListPackages at a time like SQLClient.ForEachPackage.
+func (m *MockClient) ForEachPackage(ctx context.Context, opts ListOptions, pageSize int, fn func(models.Package) error) error {
+	return forEachPackage(ctx, m.ListPackages, opts, pageSize, fn)
+}
+
+// pageOf applies opts.Offset and opts.Limit to pkgs.
+func pageOf(pkgs []models.Package, opts ListOptions) []models.Package {
+	pkgs = pkgs[min(opts.Offset, len(pkgs)):]
+	if opts.Limit > 0 && len(pkgs) > opts.Limit {
+		pkgs = pkgs[:opts.Limit]
+	}
+	return pkgs
+}
+
+// SearchByTags returns the packages in the mock store carrying tags, with
+// the same semantics and ordering as SQLClient.SearchByTags. It shares
+// ListErr with ListPackages.
+func (m *MockClient) SearchByTags(ctx context.Context, tags []string, opts ListOptions) ([]models.Package, error) {
+	if err := m.enter(ctx, "SearchByTags"); err != nil {
+		return nil, err
+	}
+	if !hasTag(tags) {
+		return nil, errors.New("searching by tags: no tags given")
+	}
+	opts.Tags = tags
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ListErr != nil {
+		return nil, m.ListErr
+	}
+	return pageOf(m.filterPackages(opts), opts), nil
+}
+
+// Search returns the packages in the mock store matching term, with the
+// same semantics and ordering as SQLClient.Search: case-insensitive
+// substring matches on name and description, case-insensitive exact tag
+// matches, and exact names first. It shares ListErr with ListPackages.
+func (m *MockClient) Search(ctx context.Context, term string, opts SearchOptions) ([]models.Package, error) {
+	if err := m.enter(ctx, "Search"); err != nil {
+		return nil, err
+	}
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil, errors.New("searching packages: empty search term")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.checkTerm(term); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ListErr != nil {
+		return nil, m.ListErr
+	}
+	var result []models.Package
+	for _, p := range m.filterPackages(ListOptions{}) {
+		if searchMatches(&p, term, opts) {
+			result = append(result, p)
+		}
+	}
+	// filterPackages sorted by name and ID; the stable sort keeps that order
+	// behind the exact matches.
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.EqualFold(result[i].Name, term) && !strings.EqualFold(result[j].Name, term)
+	})
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
 	}
 	return result, nil
 }
 
+// searchMatches reports whether p matches term in the fields opts selects.
+func searchMatches(p *models.Package, term string, opts SearchOptions) bool {
+	lower := strings.ToLower(term)
+	if opts.searches(SearchFieldName) && strings.Contains(strings.ToLower(p.Name), lower) {
+		return true
+	}
+	if opts.searches(SearchFieldDescription) && p.Description != nil &&
+		strings.Contains(strings.ToLower(*p.Description), lower) {
+		return true
+	}
+	if opts.searchesTag(term) {
+		tags, _ := p.TagsList()
+		for _, tag := range tags {
+			if strings.EqualFold(tag, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ListPackagesIter returns an iterator over the packages in the mock store
+// matching opts. It shares ListErr with ListPackages.
+func (m *MockClient) ListPackagesIter(ctx context.Context, opts ListOptions) (*PackageIterator, error) {
+	if err := m.enter(ctx, "ListPackagesIter"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if m.ListErr != nil {
+		return nil, m.ListErr
+	}
+	return newSlicePackageIterator(pageOf(m.filterPackages(opts), opts)), nil
+}
+
+// CountPackages returns the number of packages in the mock store matching opts.
+func (m *MockClient) CountPackages(ctx context.Context, opts ListOptions) (int, error) {
+	if err := m.enter(ctx, "CountPackages"); err != nil {
+		return 0, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return 0, err
+	}
+	if m.CountErr != nil {
+		return 0, m.CountErr
+	}
+	return len(m.filterPackages(opts)), nil
+}
+
 // GetPackage returns a package by ID from the mock store.
-func (m *MockClient) GetPackage(_ context.Context, id string) (*models.Package, error) {
+func (m *MockClient) GetPackage(ctx context.Context, id string) (*models.Package, error) {
+	if err := m.enter(ctx, "GetPackage"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.GetErr != nil {
 		return nil, m.GetErr
 	}
 	p, ok := m.Packages[id]
 	if !ok {
-		return nil, nil
+		return nil, fmt.Errorf("package %q: %w", id, ErrNotFound)
 	}
 	return p, nil
 }
 
+// GetPackages returns the stored packages with the given IDs that match
+// opts, in the order requested. It shares GetErr with GetPackage.
+func (m *MockClient) GetPackages(ctx context.Context, ids []string, opts ListOptions) ([]models.Package, error) {
+	if err := m.enter(ctx, "GetPackages"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if m.GetErr != nil {
+		return nil, m.GetErr
+	}
+	ids = uniqueIDs(ids)
+	found := make(map[string]models.Package, len(ids))
+	for _, id := range ids {
+		if p, ok := m.Packages[id]; ok && packageMatches(p, opts) {
+			found[id] = *p
+		}
+	}
+	return orderedPackages(ids, found), nil
+}
+
 // GetPackageFiles returns files for a package from the mock store.
-func (m *MockClient) GetPackageFiles(_ context.Context, packageID string) ([]models.PackageFile, error) {
+func (m *MockClient) GetPackageFiles(ctx context.Context, packageID string) ([]models.PackageFile, error) {
+	if err := m.enter(ctx, "GetPackageFiles"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.FilesErr != nil {
 		return nil, m.FilesErr
 	}
 	return m.Files[packageID], nil
 }
 
+// GetPackageFileMeta returns copies of a package's files from the mock store
+// with Content cleared.
+func (m *MockClient) GetPackageFileMeta(ctx context.Context, packageID string, _ ListOptions) ([]models.PackageFile, error) {
+	if err := m.enter(ctx, "GetPackageFileMeta"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.FilesErr != nil {
+		return nil, m.FilesErr
+	}
+	files := m.Files[packageID]
+	if files == nil {
+		return nil, nil
+	}
+	meta := make([]models.PackageFile, len(files))
+	for i, f := range files {
+		f.Content = ""
+		meta[i] = f
+	}
+	return meta, nil
+}
+
 // GetPackageDeps returns dependencies for a package from the mock store.
-func (m *MockClient) GetPackageDeps(_ context.Context, packageID string) ([]models.PackageDep, error) {
+func (m *MockClient) GetPackageDeps(ctx context.Context, packageID string) ([]models.PackageDep, error) {
+	if err := m.enter(ctx, "GetPackageDeps"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.DepsErr != nil {
 		return nil, m.DepsErr
 	}
 	return m.Deps[packageID], nil
 }
 
+// GetDependents scans Deps for packages with a skill dependency on id.
+func (m *MockClient) GetDependents(ctx context.Context, id string, _ ListOptions) ([]string, error) {
+	if err := m.enter(ctx, "GetDependents"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.DepsErr != nil {
+		return nil, m.DepsErr
+	}
+	ids := []string{}
+	for pkgID, deps := range m.Deps {
+		for _, d := range deps {
+			if d.DepType == models.DepTypeSkill && d.DepName == id {
+				ids = append(ids, pkgID)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
 // GetPackageHooks returns hooks for a package from the mock store.
-func (m *MockClient) GetPackageHooks(_ context.Context, packageID string) ([]models.PackageHook, error) {
+func (m *MockClient) GetPackageHooks(ctx context.Context, packageID string) ([]models.PackageHook, error) {
+	if err := m.enter(ctx, "GetPackageHooks"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.HooksErr != nil {
 		return nil, m.HooksErr
 	}
@@ -122,7 +522,13 @@ func (m *MockClient) GetPackageHooks(_ context.Context, packageID string) ([]mod
 }
 
 // GetPackageQuestions returns questions for a package from the mock store.
-func (m *MockClient) GetPackageQuestions(_ context.Context, packageID string) ([]models.PackageQuestion, error) {
+func (m *MockClient) GetPackageQuestions(ctx context.Context, packageID string) ([]models.PackageQuestion, error) {
+	if err := m.enter(ctx, "GetPackageQuestions"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.QuestionsErr != nil {
 		return nil, m.QuestionsErr
 	}
@@ -130,16 +536,236 @@ func (m *MockClient) GetPackageQuestions(_ context.Context, packageID string) ([
 }
 
 // ResolveVariant resolves a variant from the mock store.
-func (m *MockClient) ResolveVariant(_ context.Context, logicalID, agentProfile string) (string, error) {
+func (m *MockClient) ResolveVariant(ctx context.Context, logicalID, agentProfile string) (string, error) {
+	if err := m.enter(ctx, "ResolveVariant"); err != nil {
+		return "", err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if m.VariantErr != nil {
 		return "", m.VariantErr
 	}
 	key := logicalID + "/" + agentProfile
-	return m.Variants[key], nil
+	id, ok := m.Variants[key]
+	if !ok {
+		return "", fmt.Errorf("variant %q/%q: %w", logicalID, agentProfile, ErrNotFound)
+	}
+	return id, nil
+}
+
+// ResolveVariantChain returns the variant for the first profile in profiles
+// that has one in the mock store, or ErrNotFound if none do.
+func (m *MockClient) ResolveVariantChain(ctx context.Context, logicalID string, profiles []string) (string, error) {
+	if err := m.enter(ctx, "ResolveVariantChain"); err != nil {
+		return "", err
+	}
+	for _, profile := range profiles {
+		id, err := m.ResolveVariant(ctx, logicalID, profile)
+		if !errors.Is(err, ErrNotFound) {
+			return id, err
+		}
+	}
+	return "", fmt.Errorf("variant of %q for profiles %v: %w", logicalID, profiles, ErrNotFound)
+}
+
+// ListVariants returns the variants of logicalID in the mock store, ordered
+// by agent profile. Branch is ignored by the mock.
+func (m *MockClient) ListVariants(ctx context.Context, logicalID string, opts ListOptions) ([]models.PackageVariant, error) {
+	if err := m.enter(ctx, "ListVariants"); err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.VariantErr != nil {
+		return nil, m.VariantErr
+	}
+	prefix := logicalID + "/"
+	var variants []models.PackageVariant
+	for key, variantID := range m.Variants {
+		profile, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		variants = append(variants, models.PackageVariant{
+			LogicalID:        logicalID,
+			AgentProfile:     profile,
+			VariantPackageID: variantID,
+		})
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].AgentProfile < variants[j].AgentProfile
+	})
+	return variants, nil
+}
+
+// GetStats computes catalog statistics from the packages in the mock store
+// matching opts and their files.
+func (m *MockClient) GetStats(ctx context.Context, opts ListOptions) (*models.CatalogStats, error) {
+	if err := m.enter(ctx, "GetStats"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if m.StatsErr != nil {
+		return nil, m.StatsErr
+	}
+	stats := &models.CatalogStats{
+		ByScope:     make(map[string]int),
+		FilesByType: make(map[string]int),
+	}
+	tags := make(map[string]int)
+	for _, p := range m.filterPackages(opts) {
+		stats.TotalPackages++
+		stats.ByScope[string(p.InstallScope)]++
+		for _, f := range m.Files[p.ID] {
+			stats.FilesByType[string(f.FileType)]++
+		}
+		if err := addTagCounts(tags, &p); err != nil {
+			return nil, err
+		}
+	}
+	stats.TopTags = models.TopTags(tags, statsTopTags)
+	return stats, nil
+}
+
+// ListTags counts the tags of the packages in the mock store matching opts.
+func (m *MockClient) ListTags(ctx context.Context, opts ListOptions) ([]models.TagCount, error) {
+	if err := m.enter(ctx, "ListTags"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if m.TagsErr != nil {
+		return nil, m.TagsErr
+	}
+	counts := make(map[string]int)
+	for _, p := range m.filterPackages(opts) {
+		if err := addTagCounts(counts, &p); err != nil {
+			return nil, err
+		}
+	}
+	return models.TopTags(counts, 0), nil
+}
+
+// GetStatus returns a copy of Status, or an empty slice if it is nil.
+func (m *MockClient) GetStatus(ctx context.Context) ([]models.StatusEntry, error) {
+	if err := m.enter(ctx, "GetStatus"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.StatusErr != nil {
+		return nil, m.StatusErr
+	}
+	return append([]models.StatusEntry{}, m.Status...), nil
+}
+
+// GetConflicts returns a copy of Conflicts, or an empty slice if it is nil.
+func (m *MockClient) GetConflicts(ctx context.Context) ([]models.Conflict, error) {
+	if err := m.enter(ctx, "GetConflicts"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ConflictsErr != nil {
+		return nil, m.ConflictsErr
+	}
+	return append([]models.Conflict{}, m.Conflicts...), nil
+}
+
+// HasConflicts reports whether Conflicts is non-empty.
+func (m *MockClient) HasConflicts(ctx context.Context) (bool, error) {
+	conflicts, err := m.GetConflicts(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(conflicts) > 0, nil
+}
+
+// ChangedPackagesSince returns Changed, sorted and deduplicated like
+// SQLClient.ChangedPackagesSince. The commit and branch are only checked
+// for validity.
+func (m *MockClient) ChangedPackagesSince(ctx context.Context, sinceCommit, branch string) ([]string, error) {
+	if err := m.enter(ctx, "ChangedPackagesSince"); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(sinceCommit) == "" {
+		return nil, errors.New("changed packages: no commit given")
+	}
+	if branch != "" {
+		if err := ValidateBranchName(branch); err != nil {
+			return nil, err
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.ChangedErr != nil {
+		return nil, m.ChangedErr
+	}
+	set := make(map[string]bool, len(m.Changed))
+	for _, id := range m.Changed {
+		set[id] = true
+	}
+	return sortedKeys(set), nil
+}
+
+// CurrentBranch returns ActiveBranch.
+func (m *MockClient) CurrentBranch(ctx context.Context) (string, error) {
+	if err := m.enter(ctx, "CurrentBranch"); err != nil {
+		return "", err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.BranchErr != nil {
+		return "", m.BranchErr
+	}
+	return m.ActiveBranch, nil
+}
+
+// QueryRaw applies the same read-only check as SQLClient and then returns
+// RawErr, or RawRows if set. Without either it fails, since the mock holds no
+// SQL data.
+func (m *MockClient) QueryRaw(ctx context.Context, query string, _ ...any) (*sql.Rows, error) {
+	if err := m.enter(ctx, "QueryRaw"); err != nil {
+		return nil, err
+	}
+	if err := checkReadQuery(query); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.RawErr != nil {
+		return nil, m.RawErr
+	}
+	if m.RawRows == nil {
+		return nil, errors.New("mock: no canned rows for raw query")
+	}
+	return m.RawRows, nil
 }
 
 // Close marks the mock client as closed.
 func (m *MockClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.CloseErr != nil {
 		return m.CloseErr
 	}
@@ -147,6 +773,13 @@ func (m *MockClient) Close() error {
 	return nil
 }
 
+// IsClosed reports whether Close has succeeded.
+func (m *MockClient) IsClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Closed
+}
+
 // Verify MockClient implements Client at compile time.
 var _ Client = (*MockClient)(nil)
 
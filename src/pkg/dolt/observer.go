@@ -0,0 +1,40 @@
+package dolt
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Observer receives timing information for every statement SQLClient sends
+// to Dolt. name identifies the client operation (e.g. "ListPackages"), not
+// the SQL text. Implementations must be safe for concurrent use.
+type Observer interface {
+	OnQuery(name string, duration time.Duration, err error)
+}
+
+// NopObserver discards all query events. It is the default Observer.
+type NopObserver struct{}
+
+// OnQuery implements Observer and does nothing.
+func (NopObserver) OnQuery(string, time.Duration, error) {}
+
+// SlogObserver logs every query at Debug level, and queries whose duration
+// exceeds SlowThreshold at Warn level instead. A nil Logger uses
+// slog.Default(); a zero SlowThreshold disables the Warn path.
+type SlogObserver struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+// OnQuery implements Observer.
+func (o SlogObserver) OnQuery(name string, duration time.Duration, err error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if o.SlowThreshold > 0 && duration > o.SlowThreshold {
+		logger.Warn("slow dolt query", "query", name, "duration", duration, "error", err)
+		return
+	}
+	logger.Debug("dolt query", "query", name, "duration", duration, "error", err)
+}
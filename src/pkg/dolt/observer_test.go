@@ -0,0 +1,57 @@
+package dolt
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogObserver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		duration  time.Duration
+		wantLevel string
+	}{
+		{name: "fast query", threshold: 100 * time.Millisecond, duration: time.Millisecond, wantLevel: "level=DEBUG"},
+		{name: "slow query", threshold: 100 * time.Millisecond, duration: time.Second, wantLevel: "level=WARN"},
+		{name: "threshold disabled", duration: time.Hour, wantLevel: "level=DEBUG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			SlogObserver{Logger: logger, SlowThreshold: tt.threshold}.OnQuery("GetPackage", tt.duration, nil)
+
+			out := buf.String()
+			if strings.Count(out, "\n") != 1 {
+				t.Fatalf("want exactly one log line, got %q", out)
+			}
+			if !strings.Contains(out, tt.wantLevel) || !strings.Contains(out, "query=GetPackage") {
+				t.Errorf("log line %q should be %s and name the query", out, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestSlogObserverFastQueryNotWarned(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	o := SlogObserver{Logger: logger, SlowThreshold: time.Second}
+	o.OnQuery("ListPackages", time.Millisecond, errors.New("boom"))
+	if buf.Len() != 0 {
+		t.Errorf("fast query logged at Warn: %q", buf.String())
+	}
+	o.OnQuery("ListPackages", 2*time.Second, nil)
+	if !strings.Contains(buf.String(), `msg="slow dolt query"`) || !strings.Contains(buf.String(), "duration=2s") {
+		t.Errorf("slow query log = %q", buf.String())
+	}
+}
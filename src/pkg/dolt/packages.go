@@ -0,0 +1,96 @@
+package dolt
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// scanPackageDest returns scan destinations for packageColumns.
+func scanPackageDest(p *models.Package) []any {
+	return []any{
+		&p.ID, &p.Name, &p.Version, &p.Description, &p.AgentVariant,
+		&p.Author, &p.License, &p.Tags, &p.InstallScope,
+		rawJSON{&p.Variables}, rawJSON{&p.Options}, &p.SHA256, &p.MinClaudeVer,
+		nullTime{&p.CreatedAt}, nullTime{&p.UpdatedAt},
+	}
+}
+
+// nullTime scans a nullable TIMESTAMP column into a time.Time. A NULL
+// column leaves the zero time rather than failing the scan.
+type nullTime struct {
+	dst *time.Time
+}
+
+// Scan implements sql.Scanner.
+func (n nullTime) Scan(src any) error {
+	var t sql.NullTime
+	if err := t.Scan(src); err != nil {
+		return err
+	}
+	*n.dst = t.Time
+	return nil
+}
+
+// rawJSON scans a nullable JSON column into a json.RawMessage. A NULL
+// column yields a nil message rather than a scan error, and the bytes are
+// copied because the driver may reuse its buffer.
+type rawJSON struct {
+	dst *json.RawMessage
+}
+
+// Scan implements sql.Scanner.
+func (r rawJSON) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r.dst = nil
+	case []byte:
+		*r.dst = append(json.RawMessage(nil), v...)
+	case string:
+		*r.dst = json.RawMessage(v)
+	default:
+		return fmt.Errorf("scanning %T into json.RawMessage", src)
+	}
+	return nil
+}
+
+// hasTag reports whether tags holds at least one non-blank tag.
+func hasTag(tags []string) bool {
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueIDs returns ids without blanks or repeats, keeping first occurrences
+// in order.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// orderedPackages returns the packages in found in the order of ids,
+// skipping IDs that were not found.
+func orderedPackages(ids []string, found map[string]models.Package) []models.Package {
+	result := make([]models.Package, 0, len(found))
+	for _, id := range ids {
+		if p, ok := found[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,305 @@
+package dolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// packageRow returns a full packages row for the fake driver.
+func packageRow(id string) []driver.Value {
+	return []driver.Value{id, "name-" + id, "1.0.0", nil, "", nil, nil, "", "any", nil, nil, nil, nil, nil, nil}
+}
+
+var packageColumnNames = []string{
+	"id", "name", "version", "description", "agent_variant", "author", "license",
+	"tags", "install_scope", "variables", "options", "sha256", "min_claude_version",
+	"created_at", "updated_at",
+}
+
+func TestMockClientGetPackages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	m.AddPackage(NewTestPackage("pkg-a", "alpha", "1.0.0", nil))
+	m.AddPackage(NewTestPackage("pkg-b", "beta", "1.0.0", nil))
+	m.AddPackage(NewTestPackage("pkg-c", "gamma", "1.0.0", nil))
+
+	pkgs, err := m.GetPackages(ctx, []string{"pkg-c", "missing", "pkg-a", "pkg-c"}, ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, p := range pkgs {
+		ids = append(ids, p.ID)
+	}
+	if fmt.Sprint(ids) != "[pkg-c pkg-a]" {
+		t.Errorf("ids = %v, want [pkg-c pkg-a]", ids)
+	}
+}
+
+func TestSQLClientGetPackages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("request order and missing ids", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		ids := []string{"pkg-c", "missing", "pkg-a"}
+		query, _ := GetPackagesQuery(ids, ListOptions{})
+		// The server returns rows in its own order.
+		fc.setRows(query, packageColumnNames, packageRow("pkg-a"), packageRow("pkg-c"))
+		c := NewSQLClient(db, DefaultConfig())
+
+		pkgs, err := c.GetPackages(context.Background(), ids, ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pkgs) != 2 || pkgs[0].ID != "pkg-c" || pkgs[1].ID != "pkg-a" {
+			t.Errorf("got %+v, want pkg-c then pkg-a", pkgs)
+		}
+	})
+
+	t.Run("chunk boundary", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		ids := make([]string, getPackagesChunkSize+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("pkg-%04d", i)
+		}
+		first, _ := GetPackagesQuery(ids[:getPackagesChunkSize], ListOptions{})
+		second, _ := GetPackagesQuery(ids[getPackagesChunkSize:], ListOptions{})
+		fc.setRows(first, packageColumnNames, packageRow(ids[0]), packageRow(ids[getPackagesChunkSize-1]))
+		fc.setRows(second, packageColumnNames, packageRow(ids[getPackagesChunkSize]))
+		c := NewSQLClient(db, DefaultConfig())
+
+		pkgs, err := c.GetPackages(context.Background(), ids, ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pkgs) != 3 || pkgs[2].ID != ids[getPackagesChunkSize] {
+			t.Errorf("got %d packages, want 3 ending with %s", len(pkgs), ids[getPackagesChunkSize])
+		}
+		if calls := fc.recorded(); len(calls) != 2 {
+			t.Errorf("expected 2 chunked queries, got %d", len(calls))
+		}
+		// IN lists vary in length, so they must not fill the statement cache.
+		if n := len(c.stmts); n != 0 {
+			t.Errorf("cached %d statements for GetPackages, want 0", n)
+		}
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		c := NewSQLClient(db, DefaultConfig())
+
+		pkgs, err := c.GetPackages(context.Background(), nil, ListOptions{})
+		if err != nil || len(pkgs) != 0 {
+			t.Errorf("got %v, %v; want no packages and no error", pkgs, err)
+		}
+		if calls := fc.recorded(); len(calls) != 0 {
+			t.Errorf("empty input should not query, got %d calls", len(calls))
+		}
+	})
+
+	t.Run("blank ids only", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		c := NewSQLClient(db, DefaultConfig())
+
+		if pkgs, err := c.GetPackages(context.Background(), []string{"", ""}, ListOptions{}); err != nil || len(pkgs) != 0 {
+			t.Errorf("got %v, %v; want no packages and no error", pkgs, err)
+		}
+		if calls := fc.recorded(); len(calls) != 0 {
+			t.Errorf("blank ids should not query, got %d calls", len(calls))
+		}
+	})
+
+	t.Run("duplicates collapse", func(t *testing.T) {
+		t.Parallel()
+		db, fc := newFakeDB(t)
+		query, _ := GetPackagesQuery([]string{"pkg-a"}, ListOptions{})
+		fc.setRows(query, packageColumnNames, packageRow("pkg-a"))
+		c := NewSQLClient(db, DefaultConfig())
+
+		pkgs, err := c.GetPackages(context.Background(), []string{"pkg-a", "pkg-a", "pkg-a"}, ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pkgs) != 1 || pkgs[0].ID != "pkg-a" {
+			t.Errorf("got %+v, want one pkg-a", pkgs)
+		}
+		calls := fc.recorded()
+		if len(calls) != 1 || len(calls[0].args) != 1 {
+			t.Fatalf("calls = %+v, want one query with one parameter", calls)
+		}
+	})
+}
+
+func TestGetPackagesQueryEmpty(t *testing.T) {
+	t.Parallel()
+
+	q, args := GetPackagesQuery(nil, ListOptions{})
+	if strings.Contains(q, "IN ()") || !strings.HasSuffix(q, "id IN (NULL)") || len(args) != 0 {
+		t.Errorf("empty query = %q %v, want a valid IN (NULL)", q, args)
+	}
+	q, args = GetPackagesQuery([]string{"only"}, ListOptions{})
+	if !strings.HasSuffix(q, "id IN (?)") || fmt.Sprint(args) != "[only]" {
+		t.Errorf("single-id query = %q %v", q, args)
+	}
+}
+
+func TestSQLClientResolveVariantChainDedupesProfiles(t *testing.T) {
+	t.Parallel()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	query, _ := ResolveVariantChainQuery("logical-1", []string{"opus", "default"})
+	fc.setRows(query, []string{"variant_package_id"}, []driver.Value{"pkg-opus"})
+
+	id, err := c.ResolveVariantChain(context.Background(), "logical-1", []string{"opus", "", "default", "opus"})
+	if err != nil || id != "pkg-opus" {
+		t.Fatalf("got %q, %v; want pkg-opus", id, err)
+	}
+	if calls := fc.recorded(); len(calls) != 1 || len(calls[0].args) != 5 {
+		t.Errorf("calls = %+v, want one query with deduplicated profiles", calls)
+	}
+
+	if _, err := c.ResolveVariantChain(context.Background(), "logical-1", []string{""}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound for no profiles", err)
+	}
+}
+
+func TestRawJSONScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     any
+		want    string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "null", src: nil, wantNil: true},
+		{name: "bytes", src: []byte(`{"a":1}`), want: `{"a":1}`},
+		{name: "string", src: `["x"]`, want: `["x"]`},
+		{name: "unsupported", src: 42, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := json.RawMessage("stale")
+			err := rawJSON{&got}.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("got %q, want nil", got)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawJSONScanCopiesBytes(t *testing.T) {
+	t.Parallel()
+	buf := []byte(`{"a":1}`)
+	var got json.RawMessage
+	if err := (rawJSON{&got}).Scan(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf[2] = 'b'
+	if string(got) != `{"a":1}` {
+		t.Errorf("scanned value aliases driver buffer: %q", got)
+	}
+}
+
+func TestSQLClientStrictValidation(t *testing.T) {
+	t.Parallel()
+
+	bad := packageRow("pkg-bad")
+	bad[8] = "global"
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			t.Parallel()
+			db, fc := newFakeDB(t)
+			fc.setRows(GetPackageQuery(), packageColumnNames, bad)
+			list, _ := ListPackagesQuery(ListOptions{})
+			fc.setRows(list, listPackagesColumns, []driver.Value{"pkg-bad", "", "1.0.0", nil, "", "any", nil, nil})
+			cfg := DefaultConfig()
+			cfg.StrictValidation = strict
+			c := NewSQLClient(db, cfg)
+
+			_, getErr := c.GetPackage(context.Background(), "pkg-bad")
+			_, listErr := c.ListPackages(context.Background(), ListOptions{})
+			if !strict {
+				if getErr != nil || listErr != nil {
+					t.Errorf("lenient client rejected rows: %v, %v", getErr, listErr)
+				}
+				return
+			}
+			if getErr == nil || !strings.Contains(getErr.Error(), `invalid install scope "global"`) {
+				t.Errorf("GetPackage err = %v, want invalid install scope", getErr)
+			}
+			if listErr == nil || !strings.Contains(listErr.Error(), "name is empty") {
+				t.Errorf("ListPackages err = %v, want empty name", listErr)
+			}
+		})
+	}
+}
+
+func TestSQLClientPackageTimestamps(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+
+	db, fc := newFakeDB(t)
+	stamped := packageRow("pkg-1")
+	stamped[len(stamped)-2], stamped[len(stamped)-1] = created, updated
+	fc.setRows(GetPackageQuery(), packageColumnNames, stamped)
+	fc.setRows(listQuery(ListOptions{}), listPackagesColumns,
+		[]driver.Value{"pkg-1", "alpha", "1.0.0", nil, "", "any", created, updated},
+		[]driver.Value{"pkg-2", "beta", "1.0.0", nil, "", "any", nil, nil},
+	)
+	c := NewSQLClient(db, DefaultConfig())
+
+	p, err := c.GetPackage(ctx, "pkg-1")
+	if err != nil {
+		t.Fatalf("GetPackage failed: %v", err)
+	}
+	if !p.CreatedAt.Equal(created) || !p.UpdatedAt.Equal(updated) {
+		t.Errorf("timestamps = %v, %v; want %v, %v", p.CreatedAt, p.UpdatedAt, created, updated)
+	}
+
+	pkgs, err := c.ListPackages(ctx, ListOptions{})
+	if err != nil {
+		t.Fatalf("ListPackages failed: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+	if !pkgs[0].UpdatedAt.Equal(updated) {
+		t.Errorf("UpdatedAt = %v, want %v", pkgs[0].UpdatedAt, updated)
+	}
+	if !pkgs[1].CreatedAt.IsZero() || !pkgs[1].UpdatedAt.IsZero() {
+		t.Errorf("NULL timestamps = %v, %v; want zero", pkgs[1].CreatedAt, pkgs[1].UpdatedAt)
+	}
+}
@@ -0,0 +1,60 @@
+package dolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// DefaultRemote is the remote pulled from when none is configured.
+const DefaultRemote = "origin"
+
+// CommandRunner runs an external program in dir and returns its combined
+// output. It is an interface so tests can avoid needing a dolt binary.
+type CommandRunner interface {
+	Run(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands with os/exec.
+type ExecRunner struct{}
+
+// Run implements CommandRunner.
+func (ExecRunner) Run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// Pull refreshes the local Dolt clone in doltDir by running
+// "dolt pull <remote> [branch]". An empty remote means DefaultRemote and an
+// empty branch pulls the clone's current branch.
+func Pull(ctx context.Context, doltDir, remote, branch string) error {
+	return PullWith(ctx, ExecRunner{}, doltDir, remote, branch)
+}
+
+// PullWith is Pull with an explicit CommandRunner.
+func PullWith(ctx context.Context, runner CommandRunner, doltDir, remote, branch string) error {
+	if doltDir == "" {
+		return errors.New("pulling catalog: no dolt directory configured")
+	}
+	if remote == "" {
+		remote = DefaultRemote
+	}
+	args := []string{"pull", remote}
+	if branch != "" {
+		args = append(args, branch)
+	}
+
+	slog.Debug("pulling dolt catalog", "dir", doltDir, "remote", remote, "branch", branch)
+	out, err := runner.Run(ctx, doltDir, "dolt", args...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("dolt pull %s: %w: %s", remote, err, msg)
+		}
+		return fmt.Errorf("dolt pull %s: %w", remote, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package dolt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeRunner records the command it is asked to run.
+type fakeRunner struct {
+	dir  string
+	name string
+	args []string
+	out  []byte
+	err  error
+}
+
+func (r *fakeRunner) Run(_ context.Context, dir, name string, args ...string) ([]byte, error) {
+	r.dir, r.name, r.args = dir, name, args
+	return r.out, r.err
+}
+
+func TestPullWith(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		remote   string
+		branch   string
+		wantArgs string
+	}{
+		{name: "remote and branch", remote: "upstream", branch: "staging", wantArgs: "pull upstream staging"},
+		{name: "default remote", remote: "", branch: "", wantArgs: "pull origin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &fakeRunner{}
+			if err := PullWith(context.Background(), r, "/data/catalog", tt.remote, tt.branch); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.name != "dolt" || r.dir != "/data/catalog" {
+				t.Errorf("ran %q in %q, want dolt in /data/catalog", r.name, r.dir)
+			}
+			if got := strings.Join(r.args, " "); got != tt.wantArgs {
+				t.Errorf("args = %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPullWithErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing dolt dir", func(t *testing.T) {
+		t.Parallel()
+		r := &fakeRunner{}
+		if err := PullWith(context.Background(), r, "", "origin", ""); err == nil {
+			t.Fatal("expected error without a dolt directory")
+		}
+		if r.name != "" {
+			t.Error("no command should run without a dolt directory")
+		}
+	})
+
+	t.Run("command failure includes output", func(t *testing.T) {
+		t.Parallel()
+		exitErr := errors.New("exit status 1")
+		r := &fakeRunner{out: []byte("fatal: remote 'nope' not found\n"), err: exitErr}
+		err := PullWith(context.Background(), r, "/data/catalog", "nope", "")
+		if !errors.Is(err, exitErr) {
+			t.Fatalf("err = %v, want wrapped runner error", err)
+		}
+		if !strings.Contains(err.Error(), "remote 'nope' not found") {
+			t.Errorf("error %q should include dolt's output", err)
+		}
+	})
+}
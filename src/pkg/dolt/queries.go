@@ -1,19 +1,48 @@
 package dolt
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
 
 // SQL query constants for the Synaptic Canvas database.
 // These correspond to the schema defined in docs/synaptic-canvas-schema.md.
 
-// listPackagesQuery returns packages ordered by name.
-const listPackagesBaseQuery = `SELECT id, name, version, description, tags, install_scope FROM packages ORDER BY name`
+// listPackagesQuery returns packages ordered by name. The filter clause from
+// packageFilter is inserted before the ORDER BY.
+const listPackagesBaseQuery = `SELECT id, name, version, description, tags, install_scope, created_at, updated_at FROM packages`
+
+// countPackagesBaseQuery counts packages. It shares packageFilter with the
+// list query so a count can never disagree with the listed rows.
+const countPackagesBaseQuery = `SELECT COUNT(*) FROM packages`
+
+// tagMatchClause matches one tag against the tags column. Spaces, brackets,
+// and quotes are stripped so "go, cli", "go,cli", and ["go","cli"] are
+// treated alike, matching models.Package.TagsList.
+const tagMatchClause = `FIND_IN_SET(?, REPLACE(REPLACE(REPLACE(REPLACE(tags, ' ', ''), '[', ''), ']', ''), '"', '')) > 0`
+
+// packageColumns are the full set of packages columns, in the order scanned
+// by scanPackageDest.
+const packageColumns = `id, name, version, description, agent_variant, author, license, tags, install_scope, variables, options, sha256, min_claude_version, created_at, updated_at`
 
 // getPackageQuery retrieves a single package by ID.
-const getPackageBaseQuery = `SELECT id, name, version, description, agent_variant, author, license, tags, install_scope, variables, options, sha256, min_claude_version FROM packages WHERE id = ?`
+const getPackageBaseQuery = `SELECT ` + packageColumns + ` FROM packages WHERE id = ?`
+
+// getPackagesBaseQuery retrieves full packages; the filter and id IN list
+// are appended by GetPackagesQuery.
+const getPackagesBaseQuery = `SELECT ` + packageColumns + ` FROM packages`
 
 // getPackageFilesQuery retrieves all files for a package.
 const getPackageFilesBaseQuery = `SELECT package_id, dest_path, content, sha256, file_type, content_type, is_template, frontmatter, fm_name, fm_description, fm_version, fm_model FROM package_files WHERE package_id = ? ORDER BY dest_path`
 
+// getPackageFileMetaQuery retrieves every package_files column except
+// content; the AS OF clause and filter are appended by GetPackageFileMetaQuery.
+const getPackageFileMetaBaseQuery = `SELECT package_id, dest_path, sha256, file_type, content_type, is_template, frontmatter, fm_name, fm_description, fm_version, fm_model FROM package_files`
+
 // getPackageDepsQuery retrieves all dependencies for a package.
 const getPackageDepsBaseQuery = `SELECT package_id, dep_type, dep_name, dep_spec, install_cmd, cmd_sha256 FROM package_deps WHERE package_id = ? ORDER BY dep_name`
 
@@ -26,22 +55,289 @@ const getPackageQuestionsBaseQuery = `SELECT package_id, question_id, prompt, ty
 // resolveVariantQuery resolves a variant package ID from a logical ID and agent profile.
 const resolveVariantBaseQuery = `SELECT variant_package_id FROM package_variants WHERE logical_id = ? AND agent_profile = ?`
 
-// Branch switching is handled at the connection level via UseBranchQuery/switchBranch,
-// not via query modification.
+// listVariantsBaseQuery lists every profile variant of a logical package.
+const listVariantsBaseQuery = `SELECT logical_id, agent_profile, variant_package_id FROM package_variants WHERE logical_id = ? ORDER BY agent_profile`
+
+// resolveVariantChainBaseQuery resolves the first variant whose profile is
+// in a caller-supplied preference list. The IN list and FIELD ordering are
+// appended by ResolveVariantChainQuery.
+const resolveVariantChainBaseQuery = `SELECT variant_package_id FROM package_variants WHERE logical_id = ? AND agent_profile IN `
+
+// currentBranchQuery returns the branch the session is on. active_branch()
+// is a Dolt function and reflects the "database/branch" connected to.
+const currentBranchQuery = `SELECT active_branch()`
+
+// statusQuery lists uncommitted changes from the dolt_status system table.
+const statusQuery = `SELECT table_name, staged, status FROM dolt_status ORDER BY table_name, staged`
+
+// conflictsQuery lists tables with unresolved merge conflicts from the
+// dolt_conflicts system table.
+const conflictsQuery = "SELECT `table`, num_conflicts FROM dolt_conflicts ORDER BY `table`"
+
+// Branch switching is handled at the connection level by switchBranch,
+// which connects to "database/branch", not via query modification.
+
+// branchNameChars matches the characters Dolt allows in a branch name.
+var branchNameChars = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// ValidateBranchName checks that name is a usable Dolt branch name under
+// Git's ref rules: only alphanumerics and "-", "_", "/", "." (so never a
+// backtick or quote), no leading "-", no empty path component, no ".."
+// and no component starting with "." or ending in ".lock".
+func ValidateBranchName(name string) error {
+	if name == "" {
+		return fmt.Errorf("branch name must not be empty")
+	}
+	if !branchNameChars.MatchString(name) {
+		return fmt.Errorf("invalid branch name %q: only letters, digits, and - _ / . are allowed", name)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name %q: must not start with -", name)
+	}
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("invalid branch name %q: must not contain ..", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return fmt.Errorf("invalid branch name %q: bad path component %q", name, part)
+		}
+	}
+	return nil
+}
 
 // UseBranchQuery returns a USE statement for switching to a Dolt branch.
-// Returns empty string if branch is empty (use default branch).
-func UseBranchQuery(database, branch string) string {
+// Returns empty string if branch is empty (use default branch). The branch
+// is spliced into the statement, so it must pass ValidateBranchName.
+func UseBranchQuery(database, branch string) (string, error) {
 	if branch == "" {
-		return ""
+		return "", nil
+	}
+	if err := ValidateBranchName(branch); err != nil {
+		return "", err
 	}
 	// Dolt branch syntax: USE `database/branch`
-	return fmt.Sprintf("USE `%s/%s`", database, branch)
+	return fmt.Sprintf("USE `%s/%s`", database, branch), nil
+}
+
+// databaseNameChars matches the characters allowed in a database name.
+// MySQL permits more when quoted, but catalogs are plain identifiers.
+var databaseNameChars = regexp.MustCompile(`^[A-Za-z0-9_$-]+$`)
+
+// maxDatabaseNameLen is MySQL's limit on the length of a database name.
+const maxDatabaseNameLen = 64
+
+// ValidateDatabaseName checks that name is a usable database name: at most
+// 64 characters, only alphanumerics and "_", "$", "-" (so never a
+// backtick, quote, or the "/" that separates a Dolt branch), and no
+// leading "-".
+func ValidateDatabaseName(name string) error {
+	if name == "" {
+		return fmt.Errorf("database name must not be empty")
+	}
+	if len(name) > maxDatabaseNameLen {
+		return fmt.Errorf("invalid database name %q: longer than %d characters", name, maxDatabaseNameLen)
+	}
+	if !databaseNameChars.MatchString(name) {
+		return fmt.Errorf("invalid database name %q: only letters, digits, and _ $ - are allowed", name)
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid database name %q: must not start with -", name)
+	}
+	return nil
 }
 
-// ListPackagesQuery returns the SQL for listing packages.
-func ListPackagesQuery() string {
-	return listPackagesBaseQuery
+// tagSeparators are the characters tagMatchClause strips from or splits the
+// tags column on, so no stored tag can contain them.
+const tagSeparators = " \t\r\n,[]\""
+
+// ValidateSearchTag checks that tag could match a tag: tags are compared
+// with spaces, brackets, and quotes removed and split on commas, so a term
+// containing any of those can never match one.
+func ValidateSearchTag(tag string) error {
+	if strings.ContainsAny(tag, tagSeparators) {
+		return fmt.Errorf("invalid tag %q: tags cannot contain spaces, commas, brackets, or quotes", tag)
+	}
+	return nil
+}
+
+// CatalogTables are the tables of the catalog schema, in creation order.
+var CatalogTables = []string{
+	"packages",
+	"package_files",
+	"package_deps",
+	"package_variants",
+	"package_hooks",
+	"package_questions",
+}
+
+// DumpTableQuery returns a SELECT of up to limit rows of table, for
+// diagnostics. The table name is spliced into the statement, so only the
+// names in CatalogTables are accepted; limit must be positive.
+func DumpTableQuery(table string, limit int) (string, error) {
+	if !slices.Contains(CatalogTables, table) {
+		return "", fmt.Errorf("unknown table %q: want one of %s", table, strings.Join(CatalogTables, ", "))
+	}
+	if limit <= 0 {
+		return "", fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	return fmt.Sprintf("SELECT * FROM %s LIMIT %d", table, limit), nil
+}
+
+// changedPackageTables are the tables whose rows belong to a package, with
+// the column holding its ID. A change to any of them changes the package.
+var changedPackageTables = []struct{ table, idColumn string }{
+	{"packages", "id"},
+	{"package_files", "package_id"},
+	{"package_deps", "package_id"},
+	{"package_hooks", "package_id"},
+	{"package_questions", "package_id"},
+}
+
+// ChangedPackagesSinceQuery returns the SQL listing the from/to package ID
+// pair of every row that differs between two revisions, across
+// changedPackageTables, using Dolt's DOLT_DIFF table function. A row added
+// since the first revision has a NULL from ID and a removed row a NULL to
+// ID. It takes the two revisions once per table, alternating.
+func ChangedPackagesSinceQuery() (query string, revisionPairs int) {
+	parts := make([]string, len(changedPackageTables))
+	for i, t := range changedPackageTables {
+		parts[i] = fmt.Sprintf("SELECT from_%[2]s, to_%[2]s FROM DOLT_DIFF(?, ?, '%[1]s')", t.table, t.idColumn)
+	}
+	return strings.Join(parts, " UNION ALL "), len(parts)
+}
+
+// CurrentBranchQuery returns the SQL for reading the active Dolt branch.
+func CurrentBranchQuery() string {
+	return currentBranchQuery
+}
+
+// StatusQuery returns the SQL for reading the working-set status.
+func StatusQuery() string {
+	return statusQuery
+}
+
+// ConflictsQuery returns the SQL for reading unresolved merge conflicts.
+func ConflictsQuery() string {
+	return conflictsQuery
+}
+
+// packageFilter builds the WHERE clause (including the leading keyword, or
+// empty if unfiltered) and its arguments for the given list options.
+// Branch is not part of the filter; it is applied via switchBranch.
+func packageFilter(opts ListOptions) (string, []any) {
+	var conds []string
+	var args []any
+	for _, tag := range opts.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		conds = append(conds, tagMatchClause)
+		args = append(args, tag)
+	}
+	if len(conds) > 1 && opts.TagMatch == TagMatchAny {
+		conds = []string{"(" + strings.Join(conds, " OR ") + ")"}
+	}
+	if opts.InstallScope != "" {
+		if opts.IncludeAnyScope && opts.InstallScope != models.InstallScopeAny {
+			conds = append(conds, "install_scope IN (?, ?)")
+			args = append(args, string(opts.InstallScope), string(models.InstallScopeAny))
+		} else {
+			conds = append(conds, "install_scope = ?")
+			args = append(args, string(opts.InstallScope))
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// asOfClause returns the AS OF clause selecting opts.AsOfTime, or empty if
+// it is unset. The timestamp is formatted in UTC as a DATETIME literal
+// rather than bound, since AS OF takes an expression and not every Dolt
+// version accepts a placeholder there; Go formats it, so it cannot carry
+// user input.
+func asOfClause(opts ListOptions) string {
+	if opts.AsOfTime.IsZero() {
+		return ""
+	}
+	ts := opts.AsOfTime.UTC().Format("2006-01-02 15:04:05.000000")
+	return " AS OF CONVERT('" + ts + "', DATETIME)"
+}
+
+// ListPackagesQuery returns the SQL and arguments for listing packages.
+func ListPackagesQuery(opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	page, pageArgs := pageClause(opts)
+	return listPackagesBaseQuery + asOfClause(opts) + where + " ORDER BY name, id" + page, append(args, pageArgs...)
+}
+
+// pageClause returns the LIMIT/OFFSET clause for opts, or empty if it is
+// unpaged, and its arguments. The values are bound rather than inlined, so
+// every page of a listing shares one statement text and so one cached
+// prepared statement.
+func pageClause(opts ListOptions) (string, []any) {
+	switch {
+	case opts.Limit > 0:
+		return " LIMIT ? OFFSET ?", []any{opts.Limit, opts.Offset}
+	case opts.Offset > 0:
+		// MySQL has no OFFSET without LIMIT; this is its documented
+		// "all remaining rows" idiom.
+		return " LIMIT 18446744073709551615 OFFSET ?", []any{opts.Offset}
+	}
+	return "", nil
+}
+
+// SearchByTagsQuery returns the SQL and arguments for finding packages
+// carrying tags, combined per opts.TagMatch. Tags are stored as a
+// comma-separated string, so each tag is matched with FIND_IN_SET rather
+// than JSON_CONTAINS. It is ListPackagesQuery with opts.Tags set to tags.
+func SearchByTagsQuery(tags []string, opts ListOptions) (string, []any) {
+	opts.Tags = tags
+	return ListPackagesQuery(opts)
+}
+
+// SearchQuery returns the SQL and arguments for Search: a case-insensitive
+// substring match on name and description OR'd with an exact tag match,
+// limited to opts.Fields. Exact name matches sort first. term must not be
+// empty.
+func SearchQuery(term string, opts SearchOptions) (string, []any) {
+	pattern := "%" + escapeLike(term) + "%"
+	var conds []string
+	var args []any
+	if opts.searches(SearchFieldName) {
+		conds = append(conds, "name LIKE ?")
+		args = append(args, pattern)
+	}
+	if opts.searches(SearchFieldDescription) {
+		conds = append(conds, "description LIKE ?")
+		args = append(args, pattern)
+	}
+	if opts.searchesTag(term) {
+		conds = append(conds, tagMatchClause)
+		args = append(args, term)
+	}
+	query := listPackagesBaseQuery + " WHERE (" + strings.Join(conds, " OR ") + ")" +
+		" ORDER BY LOWER(name) = LOWER(?) DESC, name, id"
+	args = append(args, term)
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	return query, args
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// CountPackagesQuery returns the SQL and arguments for counting packages
+// with the same filters as ListPackagesQuery.
+func CountPackagesQuery(opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	return countPackagesBaseQuery + asOfClause(opts) + where, args
 }
 
 // GetPackageQuery returns the SQL for fetching a single package.
@@ -49,11 +345,50 @@ func GetPackageQuery() string {
 	return getPackageBaseQuery
 }
 
+// GetPackagesQuery returns the SQL and arguments for fetching the packages
+// with the given IDs that also match opts. ids must not be empty.
+func GetPackagesQuery(ids []string, opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	if where == "" {
+		where = " WHERE "
+	} else {
+		where += " AND "
+	}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return getPackagesBaseQuery + asOfClause(opts) + where + "id IN (" + inPlaceholders(len(ids)) + ")", args
+}
+
+// inPlaceholders returns n comma-separated placeholders for an IN list. For
+// n == 0 it returns NULL, since "IN ()" is a syntax error on Dolt and
+// "IN (NULL)" matches nothing; callers should still skip empty lookups.
+func inPlaceholders(n int) string {
+	if n == 0 {
+		return "NULL"
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
 // GetPackageFilesQuery returns the SQL for fetching package files.
 func GetPackageFilesQuery() string {
 	return getPackageFilesBaseQuery
 }
 
+// GetDependentsQuery returns the SQL for listing the packages that depend
+// on a package, as of opts.AsOfTime when set. It takes the dependency type
+// and the package ID as arguments.
+func GetDependentsQuery(opts ListOptions) string {
+	return "SELECT DISTINCT package_id FROM package_deps" + asOfClause(opts) +
+		" WHERE dep_type = ? AND dep_name = ? ORDER BY package_id"
+}
+
+// GetPackageFileMetaQuery returns the SQL for fetching package file
+// metadata without content, as of opts.AsOfTime when set.
+func GetPackageFileMetaQuery(opts ListOptions) string {
+	return getPackageFileMetaBaseQuery + asOfClause(opts) + " WHERE package_id = ? ORDER BY dest_path"
+}
+
 // GetPackageDepsQuery returns the SQL for fetching package dependencies.
 func GetPackageDepsQuery() string {
 	return getPackageDepsBaseQuery
@@ -73,3 +408,53 @@ func GetPackageQuestionsQuery() string {
 func ResolveVariantQuery() string {
 	return resolveVariantBaseQuery
 }
+
+// ListVariantsQuery returns the SQL for listing the variants of a logical package.
+func ListVariantsQuery() string {
+	return listVariantsBaseQuery
+}
+
+// ResolveVariantChainQuery returns the SQL and arguments for resolving the
+// first variant of logicalID among profiles, in the order given. profiles
+// must not be empty.
+func ResolveVariantChainQuery(logicalID string, profiles []string) (string, []any) {
+	placeholders := inPlaceholders(len(profiles))
+	args := make([]any, 0, 1+2*len(profiles))
+	args = append(args, logicalID)
+	for _, p := range profiles {
+		args = append(args, p)
+	}
+	for _, p := range profiles {
+		args = append(args, p)
+	}
+	query := resolveVariantChainBaseQuery + "(" + placeholders + ")" +
+		" ORDER BY FIELD(agent_profile, " + placeholders + ") LIMIT 1"
+	return query, args
+}
+
+// ScopeStatsQuery returns the SQL and arguments for counting the packages
+// matching opts per install scope.
+func ScopeStatsQuery(opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	return "SELECT install_scope, COUNT(*) FROM packages" + asOfClause(opts) + where + " GROUP BY install_scope", args
+}
+
+// FileTypeStatsQuery returns the SQL and arguments for counting the files of
+// the packages matching opts per file type.
+func FileTypeStatsQuery(opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	asOf := asOfClause(opts)
+	query := "SELECT file_type, COUNT(*) FROM package_files" + asOf
+	if where != "" {
+		query += " WHERE package_id IN (SELECT id FROM packages" + asOf + where + ")"
+	}
+	return query + " GROUP BY file_type", args
+}
+
+// TagStatsQuery returns the SQL and arguments for fetching the tags of the
+// packages matching opts. Tags are comma-separated, so they are tallied by
+// the caller rather than grouped in SQL.
+func TagStatsQuery(opts ListOptions) (string, []any) {
+	where, args := packageFilter(opts)
+	return "SELECT tags FROM packages" + asOfClause(opts) + where, args
+}
@@ -1,13 +1,21 @@
 package dolt
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
 )
 
 func TestListPackagesQuery(t *testing.T) {
 	t.Parallel()
-	q := ListPackagesQuery()
+	q, args := ListPackagesQuery(ListOptions{})
+	if len(args) != 0 {
+		t.Errorf("expected no args for unfiltered query, got %v", args)
+	}
 	if !strings.Contains(q, "SELECT") {
 		t.Error("expected SELECT in query")
 	}
@@ -94,6 +102,20 @@ func TestGetPackageQuestionsQuery(t *testing.T) {
 	}
 }
 
+func TestResolveVariantChainQuery(t *testing.T) {
+	t.Parallel()
+	q, args := ResolveVariantChainQuery("logical-1", []string{"a", "b"})
+	if !strings.Contains(q, "agent_profile IN (?, ?)") {
+		t.Errorf("expected IN list with two placeholders, got %q", q)
+	}
+	if !strings.HasSuffix(q, "ORDER BY FIELD(agent_profile, ?, ?) LIMIT 1") {
+		t.Errorf("expected preference ordering, got %q", q)
+	}
+	if want := "[logical-1 a b a b]"; fmt.Sprint(args) != want {
+		t.Errorf("args = %v, want %s", args, want)
+	}
+}
+
 func TestResolveVariantQuery(t *testing.T) {
 	t.Parallel()
 	q := ResolveVariantQuery()
@@ -113,18 +135,337 @@ func TestUseBranchQuery(t *testing.T) {
 
 	t.Run("empty branch returns empty", func(t *testing.T) {
 		t.Parallel()
-		got := UseBranchQuery("synaptic_canvas", "")
-		if got != "" {
-			t.Errorf("got %q, want empty string", got)
+		got, err := UseBranchQuery("synaptic_canvas", "")
+		if err != nil || got != "" {
+			t.Errorf("got %q, %v, want empty string", got, err)
 		}
 	})
 
 	t.Run("non-empty branch returns USE statement", func(t *testing.T) {
 		t.Parallel()
-		got := UseBranchQuery("synaptic_canvas", "staging")
+		got, err := UseBranchQuery("synaptic_canvas", "staging")
 		want := "USE `synaptic_canvas/staging`"
-		if got != want {
-			t.Errorf("got %q, want %q", got, want)
+		if err != nil || got != want {
+			t.Errorf("got %q, %v, want %q", got, err, want)
 		}
 	})
+
+	t.Run("invalid branch is rejected", func(t *testing.T) {
+		t.Parallel()
+		if got, err := UseBranchQuery("synaptic_canvas", "main`; DROP TABLE packages; --"); err == nil {
+			t.Errorf("got %q, want an error", got)
+		}
+	})
+}
+
+func TestValidateBranchName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		branch  string
+		wantErr bool
+	}{
+		{"simple", "main", false},
+		{"slash", "feature/x", false},
+		{"dots and dashes", "release-1.2_rc", false},
+		{"empty", "", true},
+		{"backtick", "main`x", true},
+		{"leading dash", "-main", true},
+		{"space", "my branch", true},
+		{"double dot", "a..b", true},
+		{"empty component", "a//b", true},
+		{"trailing slash", "a/", true},
+		{"hidden component", "a/.b", true},
+		{"lock suffix", "a.lock", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateBranchName(tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBranchName(%q) = %v, wantErr %v", tt.branch, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDatabaseName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		database string
+		wantErr  bool
+	}{
+		{"simple", "synaptic_canvas", false},
+		{"dash and dollar", "sc-prod$1", false},
+		{"empty", "", true},
+		{"backtick", "db`x", true},
+		{"slash", "db/main", true},
+		{"leading dash", "-db", true},
+		{"too long", strings.Repeat("a", 65), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateDatabaseName(tt.database)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDatabaseName(%q) = %v, wantErr %v", tt.database, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListAndCountPackagesQueryTagFilter(t *testing.T) {
+	t.Parallel()
+	opts := ListOptions{Tags: []string{"go", " ", "cli"}}
+
+	list, listArgs := ListPackagesQuery(opts)
+	count, countArgs := CountPackagesQuery(opts)
+
+	if !strings.HasPrefix(count, "SELECT COUNT(*) FROM packages") {
+		t.Errorf("unexpected count query %q", count)
+	}
+	if !strings.HasSuffix(list, "ORDER BY name, id") {
+		t.Errorf("list query should end with ORDER BY name, id, got %q", list)
+	}
+	// Both queries must carry the identical filter clause and args.
+	where := " WHERE " + tagMatchClause + " AND " + tagMatchClause
+	if !strings.Contains(list, where) || !strings.HasSuffix(count, where) {
+		t.Errorf("filter mismatch:\nlist:  %q\ncount: %q", list, count)
+	}
+	if len(listArgs) != 2 || listArgs[0] != "go" || listArgs[1] != "cli" {
+		t.Errorf("list args = %v, want [go cli]", listArgs)
+	}
+	if len(countArgs) != len(listArgs) {
+		t.Errorf("count args = %v, want %v", countArgs, listArgs)
+	}
+}
+
+func TestPackageFilterTagMatch(t *testing.T) {
+	t.Parallel()
+	tags := []string{"go", "cli"}
+
+	all, _ := packageFilter(ListOptions{Tags: tags})
+	if want := " WHERE " + tagMatchClause + " AND " + tagMatchClause; all != want {
+		t.Errorf("TagMatchAll filter = %q, want %q", all, want)
+	}
+
+	anyClause, args := packageFilter(ListOptions{Tags: tags, TagMatch: TagMatchAny})
+	if want := " WHERE (" + tagMatchClause + " OR " + tagMatchClause + ")"; anyClause != want {
+		t.Errorf("TagMatchAny filter = %q, want %q", anyClause, want)
+	}
+	if len(args) != 2 {
+		t.Errorf("got %d args, want 2", len(args))
+	}
+}
+
+func TestPackageFilterInstallScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			name:      "local-only exact",
+			opts:      ListOptions{InstallScope: models.InstallScopeLocalOnly},
+			wantWhere: " WHERE install_scope = ?",
+			wantArgs:  []any{"local-only"},
+		},
+		{
+			name:      "local-only including any",
+			opts:      ListOptions{InstallScope: models.InstallScopeLocalOnly, IncludeAnyScope: true},
+			wantWhere: " WHERE install_scope IN (?, ?)",
+			wantArgs:  []any{"local-only", "any"},
+		},
+		{
+			name:      "any with IncludeAnyScope stays exact",
+			opts:      ListOptions{InstallScope: models.InstallScopeAny, IncludeAnyScope: true},
+			wantWhere: " WHERE install_scope = ?",
+			wantArgs:  []any{"any"},
+		},
+		{
+			name:      "combined with any-tag match",
+			opts:      ListOptions{Tags: []string{"go", "cli"}, TagMatch: TagMatchAny, InstallScope: models.InstallScopeAny},
+			wantWhere: " WHERE (" + tagMatchClause + " OR " + tagMatchClause + ") AND install_scope = ?",
+			wantArgs:  []any{"go", "cli", "any"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			where, args := packageFilter(tt.opts)
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestFileTypeStatsQuery(t *testing.T) {
+	t.Parallel()
+
+	q, args := FileTypeStatsQuery(ListOptions{})
+	if q != "SELECT file_type, COUNT(*) FROM package_files GROUP BY file_type" || len(args) != 0 {
+		t.Errorf("unfiltered query = %q %v", q, args)
+	}
+
+	q, args = FileTypeStatsQuery(ListOptions{InstallScope: models.InstallScopeAny})
+	if !strings.Contains(q, "WHERE package_id IN (SELECT id FROM packages WHERE install_scope = ?)") {
+		t.Errorf("filtered query should restrict by package subquery, got %q", q)
+	}
+	if len(args) != 1 {
+		t.Errorf("got %d args, want 1", len(args))
+	}
+}
+
+func TestGetPackagesQuery(t *testing.T) {
+	t.Parallel()
+
+	q, args := GetPackagesQuery([]string{"a", "b"}, ListOptions{})
+	if !strings.HasSuffix(q, "FROM packages WHERE id IN (?, ?)") {
+		t.Errorf("unfiltered query = %q", q)
+	}
+	if fmt.Sprint(args) != "[a b]" {
+		t.Errorf("args = %v, want [a b]", args)
+	}
+
+	q, args = GetPackagesQuery([]string{"a"}, ListOptions{InstallScope: models.InstallScopeAny})
+	if !strings.HasSuffix(q, "WHERE install_scope = ? AND id IN (?)") {
+		t.Errorf("filtered query = %q", q)
+	}
+	if fmt.Sprint(args) != "[any a]" {
+		t.Errorf("args = %v, want [any a]", args)
+	}
+}
+
+func TestSearchByTagsQuery(t *testing.T) {
+	t.Parallel()
+
+	single, args := SearchByTagsQuery([]string{"go"}, ListOptions{})
+	if !strings.Contains(single, "WHERE "+tagMatchClause+" ORDER BY name") || fmt.Sprint(args) != "[go]" {
+		t.Errorf("single-tag query = %q %v", single, args)
+	}
+	if strings.Contains(single, "JSON_CONTAINS") {
+		t.Error("tags are a comma-separated string; JSON_CONTAINS cannot match them")
+	}
+
+	multi, args := SearchByTagsQuery([]string{"go", "cli"}, ListOptions{TagMatch: TagMatchAny})
+	if !strings.Contains(multi, "("+tagMatchClause+" OR "+tagMatchClause+")") || fmt.Sprint(args) != "[go cli]" {
+		t.Errorf("multi-tag query = %q %v", multi, args)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	t.Parallel()
+
+	q, args := SearchQuery("50%_off", SearchOptions{Fields: []string{SearchFieldName}, Limit: 5})
+	if !strings.Contains(q, "WHERE (name LIKE ?) ORDER BY") {
+		t.Errorf("name-only query = %q", q)
+	}
+	if !strings.HasSuffix(q, "ORDER BY LOWER(name) = LOWER(?) DESC, name, id LIMIT ?") {
+		t.Errorf("query should rank exact names first and apply the limit: %q", q)
+	}
+	if fmt.Sprint(args) != `[%50\%\_off% 50%_off 5]` {
+		t.Errorf("args = %v, want escaped pattern, the raw term, then the limit", args)
+	}
+
+	q, args = SearchQuery("go", SearchOptions{})
+	if !strings.Contains(q, "name LIKE ? OR description LIKE ? OR FIND_IN_SET") || len(args) != 4 {
+		t.Errorf("all-fields query = %q %v", q, args)
+	}
+
+	// A term with a space cannot be a tag, so only name and description
+	// are matched.
+	q, args = SearchQuery("code review", SearchOptions{})
+	if strings.Contains(q, "FIND_IN_SET") || len(args) != 3 {
+		t.Errorf("multi-word query = %q %v, want no tag match", q, args)
+	}
+}
+
+func TestValidateSearchTag(t *testing.T) {
+	t.Parallel()
+	for _, tag := range []string{"go", "code-review", "c++", "v1.2"} {
+		if err := ValidateSearchTag(tag); err != nil {
+			t.Errorf("ValidateSearchTag(%q) = %v, want nil", tag, err)
+		}
+	}
+	for _, tag := range []string{"code review", "go,cli", "[go]", `"go"`, "go\t"} {
+		if err := ValidateSearchTag(tag); err == nil {
+			t.Errorf("ValidateSearchTag(%q) = nil, want an error", tag)
+		}
+	}
+}
+
+func TestAsOfTimeQueries(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 3, 4, 9, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	opts := ListOptions{AsOfTime: at, Tags: []string{"go"}}
+	const asOf = " AS OF CONVERT('2025-03-04 14:30:00.000000', DATETIME)"
+
+	list, args := ListPackagesQuery(opts)
+	if want := listPackagesBaseQuery + asOf + " WHERE " + tagMatchClause + " ORDER BY name, id"; list != want {
+		t.Errorf("list query =\n%s\nwant\n%s", list, want)
+	}
+	if fmt.Sprint(args) != "[go]" {
+		t.Errorf("args = %v, want the timestamp inlined and only the tag bound", args)
+	}
+	if count, _ := CountPackagesQuery(opts); !strings.HasPrefix(count, countPackagesBaseQuery+asOf+" WHERE") {
+		t.Errorf("count query = %s", count)
+	}
+	if files, _ := FileTypeStatsQuery(opts); strings.Count(files, asOf) != 2 {
+		t.Errorf("file stats query should read both tables as of the time: %s", files)
+	}
+	if plain, _ := ListPackagesQuery(ListOptions{}); strings.Contains(plain, "AS OF") {
+		t.Errorf("zero AsOfTime should add no clause: %s", plain)
+	}
+}
+
+func TestAsOfTimeConflictsWithBranch(t *testing.T) {
+	t.Parallel()
+
+	opts := ListOptions{AsOfTime: time.Now(), Branch: "beta"}
+	if err := opts.validate(); err == nil {
+		t.Error("expected error combining AsOfTime and Branch")
+	}
+	if _, err := NewMockClient().ListPackages(context.Background(), opts); err == nil {
+		t.Error("mock should reject the same combination")
+	}
+}
+
+func TestPageClause(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		opts     ListOptions
+		want     string
+		wantArgs []any
+	}{
+		{ListOptions{}, "", nil},
+		{ListOptions{Limit: 10}, " LIMIT ? OFFSET ?", []any{10, 0}},
+		{ListOptions{Limit: 10, Offset: 20}, " LIMIT ? OFFSET ?", []any{10, 20}},
+		{ListOptions{Offset: 5}, " LIMIT 18446744073709551615 OFFSET ?", []any{5}},
+	}
+	for _, tt := range tests {
+		got, args := pageClause(tt.opts)
+		if got != tt.want || fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+			t.Errorf("pageClause(%+v) = %q %v, want %q %v", tt.opts, got, args, tt.want, tt.wantArgs)
+		}
+	}
+	first, _ := ListPackagesQuery(ListOptions{Limit: 10})
+	next, _ := ListPackagesQuery(ListOptions{Limit: 10, Offset: 10})
+	if first != next {
+		t.Errorf("pages should share one statement:\n%s\n%s", first, next)
+	}
+	if err := (ListOptions{Offset: -1}).validate(); err == nil {
+		t.Error("negative offset should be rejected")
+	}
 }
@@ -0,0 +1,78 @@
+package dolt
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxLoggedArgLen caps the length of an argument value in query logs.
+const maxLoggedArgLen = 64
+
+// secretPrefixLen is how much of a secret-looking argument is logged.
+const secretPrefixLen = 4
+
+// logQuery logs the text and arguments of a statement at Debug before it
+// runs, when Config.LogQueries is set. Arguments are passed through
+// loggedArg, so token-like values and long contents are not logged whole.
+// The DSN, and so the password, is never part of a statement.
+func (c *SQLClient) logQuery(op, query string, args []any) {
+	if !c.cfg.LogQueries {
+		return
+	}
+	logged := make([]string, len(args))
+	for i, a := range args {
+		logged[i] = loggedArg(a)
+	}
+	c.log.Debug("sql query", "op", op, "query", strings.Join(strings.Fields(query), " "), "args", logged)
+}
+
+// loggedArg formats a statement argument for the query log. Values that
+// look like secrets (see looksSecret) keep only their first few characters,
+// and other long values are truncated to maxLoggedArgLen.
+func loggedArg(a any) string {
+	var s string
+	switch v := a.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+	switch {
+	case looksSecret(s):
+		return fmt.Sprintf("%q (redacted, %d chars)", s[:secretPrefixLen]+"…", len(s))
+	case len(s) > maxLoggedArgLen:
+		r := []rune(s)
+		if len(r) > maxLoggedArgLen {
+			return fmt.Sprintf("%q (truncated, %d chars)", string(r[:maxLoggedArgLen])+"…", len(r))
+		}
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// looksSecret reports whether s resembles a token, key, or hash: at least
+// 16 characters drawn only from letters, digits, and the base64 symbols
+// "+/=_", mixing letters and digits. Identifiers such as package IDs and
+// dest paths contain "-" or "." and are logged in full.
+func looksSecret(s string) bool {
+	if len(s) < 16 {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			letter = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case strings.ContainsRune("+/=_", r):
+		default:
+			return false
+		}
+	}
+	return letter && digit
+}
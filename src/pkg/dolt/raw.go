@@ -0,0 +1,112 @@
+package dolt
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// rawReadVerbs are the leading keywords QueryRaw accepts.
+var rawReadVerbs = map[string]bool{
+	"SELECT":   true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"WITH":     true,
+}
+
+// checkReadQuery returns ErrWriteQuery unless query is a single statement
+// starting with a read verb. Any ";" other than one trailing the statement
+// is rejected, even inside a string literal, since it could start a second
+// statement. This is a guard against mistakes, not a SQL parser: a read
+// verb can still lead a write, as in "WITH ... DELETE", and the read-only
+// session (Config.ReadOnly) is what makes the server refuse it.
+func checkReadQuery(query string) error {
+	q := strings.TrimSpace(query)
+	if strings.Contains(strings.TrimSuffix(q, ";"), ";") {
+		return fmt.Errorf("raw query must be a single statement: %w", ErrWriteQuery)
+	}
+	end := strings.IndexFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(q)
+	}
+	verb := strings.ToUpper(q[:end])
+	if !rawReadVerbs[verb] {
+		return fmt.Errorf("raw query must start with SELECT, SHOW, DESCRIBE, or WITH, got %q: %w", verb, ErrWriteQuery)
+	}
+	return nil
+}
+
+// QueryRaw runs a read-only statement, such as a query against a Dolt system
+// table, that the client has no dedicated method for. Statements not starting
+// with SELECT, SHOW, DESCRIBE, or WITH, or containing more than one
+// statement, are rejected with ErrWriteQuery; writes that get past this
+// check are refused by the read-only session when Config.ReadOnly is set,
+// as it is by default. The caller owns the returned rows and must Close
+// them. QueryTimeout is not applied, since the rows outlive the call; bound
+// the query with ctx.
+func (c *SQLClient) QueryRaw(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := checkReadQuery(query); err != nil {
+		return nil, err
+	}
+	c.log.Debug("running raw query", "query", query)
+	c.logQuery("QueryRaw", query, args)
+
+	// Raw queries are not prepared, so ad hoc SQL does not grow the
+	// statement cache.
+	start := time.Now()
+	var rows *sql.Rows
+	err := c.withReconnect(ctx, func() error {
+		var err error
+		rows, err = c.handle().QueryContext(ctx, query, args...)
+		return err
+	})
+	c.observer.OnQuery("QueryRaw", time.Since(start), err)
+	if err != nil {
+		return nil, &QueryError{Op: "QueryRaw", Query: query, Err: err}
+	}
+	return rows, nil
+}
+
+// RawTable holds rows read by ReadRawRows, with values as the driver
+// returned them, so they can be printed for diagnostics.
+type RawTable struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// ReadRawRows reads every row of rows, which it closes. Text the driver
+// returns as bytes is converted to a string; NULL is nil.
+func ReadRawRows(rows *sql.Rows) (*RawTable, error) {
+	defer func() { _ = rows.Close() }()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+	t := &RawTable{Columns: cols, Rows: [][]any{}}
+	for rows.Next() {
+		values := make([]any, len(cols))
+		dest := make([]any, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scanning raw row: %w", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		t.Rows = append(t.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating raw rows: %w", err)
+	}
+	return t, nil
+}
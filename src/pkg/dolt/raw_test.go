@@ -0,0 +1,142 @@
+package dolt
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCheckReadQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query   string
+		allowed bool
+	}{
+		{query: "SELECT * FROM dolt_status", allowed: true},
+		{query: "  select 1", allowed: true},
+		{query: "SHOW TABLES", allowed: true},
+		{query: "describe packages", allowed: true},
+		{query: "WITH t AS (SELECT 1) SELECT * FROM t", allowed: true},
+		{query: "SELECT(1)", allowed: true},
+		{query: "SELECT 1; ", allowed: true},
+		{query: "SELECT 1; DROP TABLE packages"},
+		{query: "SELECT 1;;"},
+		{query: "SHOW TABLES; CALL DOLT_COMMIT('-m', 'x')"},
+		{query: "SELECT ';'"},
+		{query: "INSERT INTO packages VALUES (1)"},
+		{query: "update packages set name = 'x'"},
+		{query: "CALL DOLT_COMMIT('-m', 'x')"},
+		{query: "/* SELECT */ DELETE FROM packages"},
+		{query: "SELECTED"},
+		{query: ""},
+	}
+	for _, tt := range tests {
+		err := checkReadQuery(tt.query)
+		if tt.allowed && err != nil {
+			t.Errorf("checkReadQuery(%q) rejected a read: %v", tt.query, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrWriteQuery) {
+			t.Errorf("checkReadQuery(%q) = %v, want ErrWriteQuery", tt.query, err)
+		}
+	}
+}
+
+func TestSQLClientQueryRaw(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	const query = "SELECT table_name, status FROM dolt_status"
+	fc.setRows(query, []string{"table_name", "status"}, []driver.Value{"packages", "modified"})
+
+	rows, err := c.QueryRaw(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var table, status string
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+	if err := rows.Scan(&table, &status); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if table != "packages" || status != "modified" {
+		t.Errorf("got (%q, %q), want (packages, modified)", table, status)
+	}
+
+	calls := len(fc.recorded())
+	if _, err := c.QueryRaw(ctx, "INSERT INTO packages (id) VALUES (?)", "x"); !errors.Is(err, ErrWriteQuery) {
+		t.Fatalf("err = %v, want ErrWriteQuery", err)
+	}
+	if len(fc.recorded()) != calls {
+		t.Error("a rejected statement must not reach the database")
+	}
+}
+
+func TestMockClientQueryRaw(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	m := NewMockClient()
+	if _, err := m.QueryRaw(ctx, "DROP TABLE packages"); !errors.Is(err, ErrWriteQuery) {
+		t.Errorf("err = %v, want ErrWriteQuery", err)
+	}
+	if _, err := m.QueryRaw(ctx, "SELECT 1"); err == nil {
+		t.Error("expected error without canned rows")
+	}
+
+	m.RawErr = errors.New("raw failed")
+	if _, err := m.QueryRaw(ctx, "SELECT 1"); !errors.Is(err, m.RawErr) {
+		t.Errorf("err = %v, want RawErr", err)
+	}
+}
+
+func TestReadRawRows(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, fc := newFakeDB(t)
+	c := NewSQLClient(db, DefaultConfig())
+
+	query, err := DumpTableQuery("packages", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fc.setRows(query, []string{"id", "description", "priority"},
+		[]driver.Value{[]byte("pkg-1"), nil, int64(3)})
+
+	rows, err := c.QueryRaw(ctx, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	table, err := ReadRawRows(rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(table.Columns) != "[id description priority]" {
+		t.Errorf("columns = %v", table.Columns)
+	}
+	if len(table.Rows) != 1 || table.Rows[0][0] != "pkg-1" || table.Rows[0][1] != nil || table.Rows[0][2] != int64(3) {
+		t.Errorf("rows = %#v, want bytes as a string and NULL as nil", table.Rows)
+	}
+}
+
+func TestDumpTableQuery(t *testing.T) {
+	t.Parallel()
+
+	if q, err := DumpTableQuery("package_files", 10); err != nil || q != "SELECT * FROM package_files LIMIT 10" {
+		t.Errorf("DumpTableQuery = %q, %v", q, err)
+	}
+	for _, table := range []string{"users", "packages; DROP TABLE packages", "dolt_status", ""} {
+		if _, err := DumpTableQuery(table, 10); err == nil {
+			t.Errorf("DumpTableQuery(%q) should be rejected", table)
+		}
+	}
+	if _, err := DumpTableQuery("packages", 0); err == nil {
+		t.Error("a zero limit should be rejected")
+	}
+}
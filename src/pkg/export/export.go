@@ -0,0 +1,268 @@
+// Package export materializes catalog packages onto the filesystem in the
+// layout described in docs/synaptic-canvas-export-pipeline.md: every
+// package file at its dest_path plus a reconstructed manifest.yaml.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// ManifestFile is the name of the manifest written at the package root.
+const ManifestFile = "manifest.yaml"
+
+// Options controls ExportPackage.
+type Options struct {
+	// Branch is the Dolt branch to export from. Empty means the current
+	// branch.
+	Branch string
+	// DryRun plans the export without touching the filesystem.
+	DryRun bool
+	// OnConflict decides what happens to files that already exist at a
+	// target path. The zero value is ConflictFail.
+	OnConflict ConflictPolicy
+	// Install lays the package out as an install instead of a marketplace
+	// export: files go under the root from models.ResolveDestRoot, with
+	// outDir as the project directory (empty for a global install), and no
+	// manifest.yaml is written.
+	Install bool
+	// HomeDir is the home directory used for global installs.
+	HomeDir string
+}
+
+// ConflictPolicy is how ExportPackage treats an existing file at a target
+// path.
+type ConflictPolicy int
+
+const (
+	// ConflictFail aborts the export, before writing anything, if any
+	// target path exists.
+	ConflictFail ConflictPolicy = iota
+	// ConflictOverwrite replaces existing files.
+	ConflictOverwrite
+	// ConflictSkip leaves existing files untouched.
+	ConflictSkip
+)
+
+// Action is what an export does, or would do, at one target path.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionOverwrite Action = "overwrite"
+	ActionSkip      Action = "skip"
+)
+
+// PlannedWrite describes one file an export writes, or would write in
+// dry-run mode.
+type PlannedWrite struct {
+	// Path is the target path, under the package directory.
+	Path       string `json:"path"`
+	Bytes      int    `json:"bytes"`
+	IsTemplate bool   `json:"is_template"`
+	Action     Action `json:"action"`
+}
+
+// Result summarizes an export.
+type Result struct {
+	PackageID string `json:"package_id"`
+	// Dir is the package directory, <outDir>/<package id>, or the install
+	// root when Options.Install is set.
+	Dir    string         `json:"dir"`
+	DryRun bool           `json:"dry_run"`
+	Writes []PlannedWrite `json:"writes"`
+	// Warnings are non-fatal findings for the caller to show, e.g. through
+	// output.Formatter.Warning.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Skipped returns the paths left untouched under ConflictSkip.
+func (r *Result) Skipped() []string {
+	var paths []string
+	for _, w := range r.Writes {
+		if w.Action == ActionSkip {
+			paths = append(paths, w.Path)
+		}
+	}
+	return paths
+}
+
+// pendingWrite is a PlannedWrite with the bytes to write.
+type pendingWrite struct {
+	PlannedWrite
+	data []byte
+	mode fs.FileMode
+}
+
+// ExportPackage exports package id into <outDir>/<id>, or installs it when
+// opts.Install is set. Every file is verified against its stored SHA-256,
+// dest paths are checked for duplicates, and every target path is checked
+// against opts.OnConflict before anything is written, so a corrupt package
+// or a conflict fails without leaving a partial export. Template files are
+// written unrendered; rendering happens at install time.
+//
+// A package that does not exist fails with an error wrapping
+// dolt.ErrNotFound. A package that exists but has no files is still
+// exported, as just its manifest.yaml, with a warning in the Result.
+func ExportPackage(ctx context.Context, client dolt.Client, id, outDir string, opts Options) (*Result, error) {
+	// GetManifest selects opts.Branch, so the reads below see the same branch.
+	manifest, err := client.GetManifest(ctx, id, dolt.ListOptions{Branch: opts.Branch})
+	if err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	pkg, err := client.GetPackage(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	files, err := client.GetPackageFiles(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	if err := models.VerifyPackage(pkg, files); err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	if err := models.CheckDuplicatePaths(files); err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	var warnings []string
+	if len(files) == 0 {
+		msg := fmt.Sprintf("package %q has no files; only %s is written", id, ManifestFile)
+		if opts.Install {
+			msg = fmt.Sprintf("package %q has no files; nothing to install", id)
+		}
+		warnings = append(warnings, msg)
+	}
+
+	dir := filepath.Join(outDir, id)
+	if opts.Install {
+		dir, err = models.ResolveDestRoot(pkg.InstallScope, outDir, opts.HomeDir)
+		if err != nil {
+			return nil, fmt.Errorf("exporting %q: %w", id, err)
+		}
+		// Installs carry no manifest.yaml; that is a marketplace artifact.
+		manifest = nil
+	}
+	writes, err := planWrites(dir, manifest, files)
+	if err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+	if err := resolveConflicts(writes, opts.OnConflict); err != nil {
+		return nil, fmt.Errorf("exporting %q: %w", id, err)
+	}
+
+	res := &Result{
+		PackageID: id,
+		Dir:       dir,
+		DryRun:    opts.DryRun,
+		Writes:    make([]PlannedWrite, len(writes)),
+		Warnings:  warnings,
+	}
+	for i, w := range writes {
+		res.Writes[i] = w.PlannedWrite
+	}
+	if opts.DryRun {
+		slog.Debug("planned export", "package_id", id, "dir", dir, "files", len(writes))
+		return res, nil
+	}
+
+	for _, w := range writes {
+		if w.Action == ActionSkip {
+			continue
+		}
+		if err := writeFile(w); err != nil {
+			return nil, fmt.Errorf("exporting %q: %w", id, err)
+		}
+	}
+	slog.Debug("exported package", "package_id", id, "dir", dir, "files", len(writes))
+	return res, nil
+}
+
+// planWrites resolves the target path and content of every file plus the
+// manifest, if one is given.
+func planWrites(dir string, manifest *models.Manifest, files []models.PackageFile) ([]pendingWrite, error) {
+	writes := make([]pendingWrite, 0, len(files)+1)
+	for _, f := range files {
+		path, err := safeJoin(dir, f.DestPath)
+		if err != nil {
+			return nil, err
+		}
+		data, err := f.DecodedContent()
+		if err != nil {
+			return nil, err
+		}
+		mode := fs.FileMode(0o644)
+		if f.FileType == models.FileTypeScript || f.FileType == models.FileTypeHook {
+			mode = 0o755
+		}
+		writes = append(writes, pendingWrite{
+			PlannedWrite: PlannedWrite{Path: path, Bytes: len(data), IsTemplate: f.IsTemplate},
+			data:         data,
+			mode:         mode,
+		})
+	}
+
+	if manifest == nil {
+		return writes, nil
+	}
+	data := EncodeManifest(manifest)
+	writes = append(writes, pendingWrite{
+		PlannedWrite: PlannedWrite{Path: filepath.Join(dir, ManifestFile), Bytes: len(data)},
+		data:         data,
+		mode:         0o644,
+	})
+	return writes, nil
+}
+
+// resolveConflicts sets the Action of every write from whether its target
+// exists and policy. Under ConflictFail it returns an error naming every
+// existing path.
+func resolveConflicts(writes []pendingWrite, policy ConflictPolicy) error {
+	var conflicts []string
+	for i := range writes {
+		w := &writes[i]
+		w.Action = ActionCreate
+		if _, err := os.Lstat(w.Path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("checking %s: %w", w.Path, err)
+		}
+		switch policy {
+		case ConflictOverwrite:
+			w.Action = ActionOverwrite
+		case ConflictSkip:
+			w.Action = ActionSkip
+		default:
+			conflicts = append(conflicts, w.Path)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("refusing to overwrite existing files: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// safeJoin joins destPath under dir with models.JoinDestPath, rejecting
+// absolute paths and paths that escape dir.
+func safeJoin(dir, destPath string) (string, error) {
+	return models.JoinDestPath(dir, destPath)
+}
+
+func writeFile(w pendingWrite) error {
+	if err := os.MkdirAll(filepath.Dir(w.Path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", w.Path, err)
+	}
+	if err := os.WriteFile(w.Path, w.data, w.mode); err != nil {
+		return fmt.Errorf("writing %s: %w", w.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/dolt"
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// testFile returns a package file whose stored SHA matches content.
+func testFile(destPath string, ft models.FileType, content string) models.PackageFile {
+	return models.PackageFile{
+		PackageID:   "pkg-1",
+		DestPath:    destPath,
+		Content:     content,
+		SHA256:      models.FileSHA256([]byte(content)),
+		FileType:    ft,
+		ContentType: models.ContentTypeMarkdown,
+	}
+}
+
+func newTestClient() *dolt.MockClient {
+	m := dolt.NewMockClient()
+	m.AddPackage(dolt.NewTestPackage("pkg-1", "alpha", "1.0.0", []string{"go"}))
+	tmpl := testFile("agents/b.md", models.FileTypeAgent, "Hello {{NAME}}\n")
+	tmpl.IsTemplate = true
+	m.AddFiles("pkg-1", []models.PackageFile{
+		testFile("skills/a/SKILL.md", models.FileTypeSkill, "# A\n"),
+		tmpl,
+		testFile("scripts/run.py", models.FileTypeScript, "print('hi')\n"),
+	})
+	return m
+}
+
+func TestExportPackageDryRun(t *testing.T) {
+	t.Parallel()
+	out := t.TempDir()
+
+	res, err := ExportPackage(context.Background(), newTestClient(), "pkg-1", out, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir := filepath.Join(out, "pkg-1")
+	want := []PlannedWrite{
+		{Path: filepath.Join(dir, "skills", "a", "SKILL.md"), Bytes: 4, Action: ActionCreate},
+		{Path: filepath.Join(dir, "agents", "b.md"), Bytes: 15, IsTemplate: true, Action: ActionCreate},
+		{Path: filepath.Join(dir, "scripts", "run.py"), Bytes: 12, Action: ActionCreate},
+	}
+	if !res.DryRun || res.Dir != dir {
+		t.Errorf("result = %+v, want dry run into %s", res, dir)
+	}
+	if len(res.Writes) != len(want)+1 {
+		t.Fatalf("got %d planned writes, want %d", len(res.Writes), len(want)+1)
+	}
+	for i, w := range want {
+		if res.Writes[i] != w {
+			t.Errorf("write[%d] = %+v, want %+v", i, res.Writes[i], w)
+		}
+	}
+	manifest := res.Writes[len(want)]
+	if manifest.Path != filepath.Join(dir, ManifestFile) || manifest.Bytes == 0 {
+		t.Errorf("manifest write = %+v", manifest)
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatalf("reading output dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("dry run created %d entries in the output dir", len(entries))
+	}
+}
+
+func TestExportPackageWritesFiles(t *testing.T) {
+	t.Parallel()
+	out := t.TempDir()
+
+	res, err := ExportPackage(context.Background(), newTestClient(), "pkg-1", out, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, w := range res.Writes {
+		data, err := os.ReadFile(w.Path)
+		if err != nil {
+			t.Fatalf("planned file not written: %v", err)
+		}
+		if len(data) != w.Bytes {
+			t.Errorf("%s: wrote %d bytes, planned %d", w.Path, len(data), w.Bytes)
+		}
+	}
+
+	tmpl, err := os.ReadFile(filepath.Join(out, "pkg-1", "agents", "b.md"))
+	if err != nil || string(tmpl) != "Hello {{NAME}}\n" {
+		t.Errorf("template should be written unrendered, got %q, %v", tmpl, err)
+	}
+	info, err := os.Stat(filepath.Join(out, "pkg-1", "scripts", "run.py"))
+	if err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("scripts should be executable, got %v, %v", info, err)
+	}
+	manifest, err := os.ReadFile(filepath.Join(out, "pkg-1", ManifestFile))
+	if err != nil || !strings.HasPrefix(string(manifest), "name: alpha\nversion: 1.0.0\n") {
+		t.Errorf("manifest = %q, %v", manifest, err)
+	}
+}
+
+func TestExportPackageConflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		policy      ConflictPolicy
+		wantErr     bool
+		wantContent string
+		wantAction  Action
+	}{
+		{name: "fail", policy: ConflictFail, wantErr: true, wantContent: "user edit\n"},
+		{name: "overwrite", policy: ConflictOverwrite, wantContent: "# A\n", wantAction: ActionOverwrite},
+		{name: "skip", policy: ConflictSkip, wantContent: "user edit\n", wantAction: ActionSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := t.TempDir()
+			existing := filepath.Join(out, "pkg-1", "skills", "a", "SKILL.md")
+			if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(existing, []byte("user edit\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := ExportPackage(context.Background(), newTestClient(), "pkg-1", out, Options{OnConflict: tt.policy})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), existing) {
+					t.Fatalf("err = %v, want error naming %s", err, existing)
+				}
+				if _, statErr := os.Stat(filepath.Join(out, "pkg-1", ManifestFile)); statErr == nil {
+					t.Error("a conflict must abort before writing anything")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if res.Writes[0].Action != tt.wantAction {
+					t.Errorf("action = %q, want %q", res.Writes[0].Action, tt.wantAction)
+				}
+				for _, w := range res.Writes[1:] {
+					if w.Action != ActionCreate {
+						t.Errorf("%s: action = %q, want create", w.Path, w.Action)
+					}
+				}
+				skipped := res.Skipped()
+				if (tt.policy == ConflictSkip) != (len(skipped) == 1 && skipped[0] == existing) {
+					t.Errorf("Skipped() = %v", skipped)
+				}
+			}
+
+			data, err := os.ReadFile(existing)
+			if err != nil || string(data) != tt.wantContent {
+				t.Errorf("existing file = %q, %v; want %q", data, err, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestExportPackageInstallLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		scope    models.InstallScope
+		project  bool
+		wantRoot func(project, home string) string
+		wantErr  bool
+	}{
+		{name: "local-only into project", scope: models.InstallScopeLocalOnly, project: true,
+			wantRoot: func(project, _ string) string { return filepath.Join(project, ".claude") }},
+		{name: "any defaults to project", scope: models.InstallScopeAny, project: true,
+			wantRoot: func(project, _ string) string { return filepath.Join(project, ".claude") }},
+		{name: "any installed globally", scope: models.InstallScopeAny,
+			wantRoot: func(_, home string) string { return filepath.Join(home, ".claude") }},
+		{name: "local-only refused globally", scope: models.InstallScopeLocalOnly, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := newTestClient()
+			m.Packages["pkg-1"].InstallScope = tt.scope
+			project, home := t.TempDir(), t.TempDir()
+			outDir := ""
+			if tt.project {
+				outDir = project
+			}
+
+			res, err := ExportPackage(context.Background(), m, "pkg-1", outDir, Options{Install: true, HomeDir: home, DryRun: true})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			root := tt.wantRoot(project, home)
+			if res.Dir != root {
+				t.Errorf("Dir = %q, want %q", res.Dir, root)
+			}
+			if len(res.Writes) != 3 {
+				t.Fatalf("got %d writes, want 3 files and no manifest", len(res.Writes))
+			}
+			if want := filepath.Join(root, "skills", "a", "SKILL.md"); res.Writes[0].Path != want {
+				t.Errorf("Path = %q, want %q", res.Writes[0].Path, want)
+			}
+		})
+	}
+}
+
+func TestExportPackageErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		setup func(m *dolt.MockClient)
+		id    string
+	}{
+		{name: "unknown package", id: "missing", setup: func(*dolt.MockClient) {}},
+		{
+			name: "sha mismatch",
+			id:   "pkg-1",
+			setup: func(m *dolt.MockClient) {
+				f := testFile("skills/a/SKILL.md", models.FileTypeSkill, "# A\n")
+				f.SHA256 = "bad"
+				m.AddFiles("pkg-1", []models.PackageFile{f})
+			},
+		},
+		{
+			name: "path traversal",
+			id:   "pkg-1",
+			setup: func(m *dolt.MockClient) {
+				m.AddFiles("pkg-1", []models.PackageFile{testFile("../escape.md", models.FileTypeAgent, "x")})
+			},
+		},
+		{
+			name: "files error",
+			id:   "pkg-1",
+			setup: func(m *dolt.MockClient) {
+				m.FilesErr = errors.New("boom")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := newTestClient()
+			tt.setup(m)
+			out := t.TempDir()
+			if _, err := ExportPackage(context.Background(), m, tt.id, out, Options{}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if entries, _ := os.ReadDir(out); len(entries) != 0 {
+				t.Errorf("failed export left %d entries behind", len(entries))
+			}
+		})
+	}
+}
+
+func TestExportPackageMissingVersusEmpty(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing package", func(t *testing.T) {
+		t.Parallel()
+		_, err := ExportPackage(context.Background(), newTestClient(), "missing", t.TempDir(), Options{})
+		if !errors.Is(err, dolt.ErrNotFound) {
+			t.Errorf("err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("package without files", func(t *testing.T) {
+		t.Parallel()
+		m := newTestClient()
+		m.AddPackage(dolt.NewTestPackage("pkg-empty", "empty", "1.0.0", nil))
+		out := t.TempDir()
+
+		res, err := ExportPackage(context.Background(), m, "pkg-empty", out, Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(res.Writes) != 1 || filepath.Base(res.Writes[0].Path) != ManifestFile {
+			t.Errorf("writes = %+v, want only %s", res.Writes, ManifestFile)
+		}
+		if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "has no files") {
+			t.Errorf("warnings = %v, want a no-files warning", res.Warnings)
+		}
+		entries, _ := os.ReadDir(filepath.Join(out, "pkg-empty"))
+		if len(entries) != 1 || entries[0].Name() != ManifestFile {
+			t.Errorf("export dir holds %v, want only %s", entries, ManifestFile)
+		}
+	})
+}
+
+func TestSafeJoin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dest    string
+		want    string
+		wantErr bool
+	}{
+		{dest: "agents/a.md"},
+		{dest: ".claude-plugin/plugin.json"},
+		{dest: `./agents\a.md`, want: "agents/a.md"},
+		{dest: "skills//a/./SKILL.md", want: "skills/a/SKILL.md"},
+		{dest: "../a.md", wantErr: true},
+		{dest: "skills/../../a.md", wantErr: true},
+		{dest: "/etc/passwd", wantErr: true},
+		{dest: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := safeJoin("/out/pkg", tt.dest)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("safeJoin(%q) = %q, want error", tt.dest, got)
+			}
+			continue
+		}
+		want := tt.want
+		if want == "" {
+			want = tt.dest
+		}
+		if err != nil || got != filepath.Join("/out/pkg", filepath.FromSlash(want)) {
+			t.Errorf("safeJoin(%q) = %q, %v", tt.dest, got, err)
+		}
+	}
+}
+
+func TestExportPackageRejectsDuplicatePaths(t *testing.T) {
+	t.Parallel()
+	out := t.TempDir()
+
+	m := dolt.NewMockClient()
+	m.AddPackage(dolt.NewTestPackage("pkg-1", "alpha", "1.0.0", nil))
+	m.AddFiles("pkg-1", []models.PackageFile{
+		testFile("skills/a/SKILL.md", models.FileTypeSkill, "# A\n"),
+		testFile("skills/a/SKILL.md", models.FileTypeSkill, "# B\n"),
+	})
+
+	_, err := ExportPackage(context.Background(), m, "pkg-1", out, Options{})
+	if err == nil || !strings.Contains(err.Error(), "duplicate dest_path: skills/a/SKILL.md") {
+		t.Fatalf("err = %v, want duplicate dest_path", err)
+	}
+	if entries, _ := os.ReadDir(out); len(entries) != 0 {
+		t.Errorf("export wrote %d entries despite the duplicate", len(entries))
+	}
+}
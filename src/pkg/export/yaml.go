@@ -0,0 +1,203 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// EncodeManifest renders m as manifest.yaml in the layout of the export
+// pipeline spec: name, version, description, author, license, tags,
+// min_claude_version, artifacts, variables, install.scope, options, and
+// requires. Empty fields are omitted. Hooks and Questions are install-time
+// extensions and are not written; neither is the package ID, which is the
+// directory name.
+func EncodeManifest(m *models.Manifest) []byte {
+	var w yamlWriter
+	w.scalarField("name", m.Name)
+	w.scalarField("version", m.Version)
+	w.scalarField("description", m.Description)
+	w.scalarField("author", m.Author)
+	w.scalarField("license", m.License)
+	w.listField("tags", m.Tags)
+	w.scalarField("min_claude_version", m.MinClaudeVersion)
+
+	if len(m.Artifacts) > 0 {
+		w.line(0, "artifacts:")
+		for _, key := range sortedKeys(m.Artifacts) {
+			w.line(2, key+":")
+			for _, p := range m.Artifacts[key] {
+				w.line(4, "- "+yamlString(p))
+			}
+		}
+	}
+	if len(m.Variables) > 0 {
+		w.value(0, "variables", m.Variables)
+	}
+	if !m.InstallScope.OmitFromManifest() {
+		w.line(0, "install:")
+		w.line(2, "scope: "+yamlString(string(m.InstallScope)))
+	}
+	if len(m.Options) > 0 {
+		w.value(0, "options", m.Options)
+	}
+	w.listField("requires", m.Requires)
+	return []byte(w.b.String())
+}
+
+// yamlWriter builds block-style YAML from the JSON-shaped values found in
+// manifests: maps, slices, strings, float64s, bools, and nil.
+type yamlWriter struct {
+	b strings.Builder
+}
+
+func (w *yamlWriter) line(indent int, s string) {
+	w.b.WriteString(strings.Repeat(" ", indent))
+	w.b.WriteString(s)
+	w.b.WriteByte('\n')
+}
+
+func (w *yamlWriter) scalarField(key, v string) {
+	if v != "" {
+		w.line(0, key+": "+yamlString(v))
+	}
+}
+
+func (w *yamlWriter) listField(key string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	w.line(0, key+":")
+	for _, item := range items {
+		w.line(2, "- "+yamlString(item))
+	}
+}
+
+// value writes "key: v" at indent, nesting maps and slices below the key.
+func (w *yamlWriter) value(indent int, key string, v any) {
+	prefix := yamlString(key) + ":"
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			w.line(indent, prefix+" {}")
+			return
+		}
+		w.line(indent, prefix)
+		w.mapEntries(indent+2, v)
+	case []any:
+		if len(v) == 0 {
+			w.line(indent, prefix+" []")
+			return
+		}
+		w.line(indent, prefix)
+		w.listItems(indent+2, v)
+	default:
+		w.line(indent, prefix+" "+yamlScalar(v))
+	}
+}
+
+func (w *yamlWriter) mapEntries(indent int, m map[string]any) {
+	for _, k := range sortedKeys(m) {
+		w.value(indent, k, m[k])
+	}
+}
+
+func (w *yamlWriter) listItems(indent int, items []any) {
+	for _, item := range items {
+		switch v := item.(type) {
+		case map[string]any:
+			if len(v) == 0 {
+				w.line(indent, "- {}")
+				continue
+			}
+			w.line(indent, "-")
+			w.mapEntries(indent+2, v)
+		case []any:
+			if len(v) == 0 {
+				w.line(indent, "- []")
+				continue
+			}
+			w.line(indent, "-")
+			w.listItems(indent+2, v)
+		default:
+			w.line(indent, "- "+yamlScalar(v))
+		}
+	}
+}
+
+// yamlScalar formats a JSON scalar.
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return yamlString(v)
+	default:
+		return yamlString(fmt.Sprint(v))
+	}
+}
+
+// yamlReserved are plain scalars a YAML 1.1 or 1.2 parser would not read
+// back as strings.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// yamlDate matches the leading form of a YAML timestamp.
+var yamlDate = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}`)
+
+// yamlNumber matches the YAML 1.1 and 1.2 int and float forms that
+// strconv.ParseFloat rejects: base prefixes, "_" separators, sexagesimal
+// numbers, and the special floats.
+var yamlNumber = regexp.MustCompile(`^[-+]?(0[xob][0-9A-Fa-f_]+|[0-9][0-9_]*(:[0-5]?[0-9])*(\.[0-9_]*)?([eE][-+]?[0-9]+)?|\.[0-9_]+([eE][-+]?[0-9]+)?|\.(inf|Inf|INF))$|^\.(nan|NaN|NAN)$`)
+
+// yamlString returns s as a plain scalar when that reads back as the same
+// string, and double-quoted otherwise.
+func yamlString(s string) string {
+	if yamlPlainSafe(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+func yamlPlainSafe(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	if yamlReserved[strings.ToLower(s)] || yamlDate.MatchString(s) {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlNumber.MatchString(s) {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || !strconv.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestEncodeManifest(t *testing.T) {
+	t.Parallel()
+
+	m := &models.Manifest{
+		ID:           "sc-git-worktree",
+		Name:         "sc-git-worktree",
+		Version:      "0.9.0",
+		Description:  "Manage git worktrees: safely",
+		License:      "MIT",
+		Tags:         []string{"git", "worktree"},
+		InstallScope: models.InstallScopeLocalOnly,
+		Artifacts: map[string][]string{
+			"skills": {"skills/sc-git-worktree/SKILL.md"},
+			"agents": {"agents/create.md", "agents/scan.md"},
+		},
+		Variables: map[string]any{
+			"REPO_NAME": map[string]any{"auto": "git-repo-basename"},
+		},
+		Options: map[string]any{
+			"no-tracking": map[string]any{"type": "boolean", "default": false, "levels": []any{1.0, "yes"}},
+		},
+		Requires:    []string{"git >= 2.20", "python3"},
+		ConfigFiles: []string{".claude-plugin/plugin.json"},
+	}
+
+	want := `name: sc-git-worktree
+version: 0.9.0
+description: "Manage git worktrees: safely"
+license: MIT
+tags:
+  - git
+  - worktree
+artifacts:
+  agents:
+    - agents/create.md
+    - agents/scan.md
+  skills:
+    - skills/sc-git-worktree/SKILL.md
+variables:
+  REPO_NAME:
+    auto: git-repo-basename
+install:
+  scope: local-only
+options:
+  no-tracking:
+    default: false
+    levels:
+      - 1
+      - "yes"
+    type: boolean
+requires:
+  - git >= 2.20
+  - python3
+`
+	if got := string(EncodeManifest(m)); got != want {
+		t.Errorf("EncodeManifest =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncodeManifestOmitsAnyScope(t *testing.T) {
+	t.Parallel()
+	m := &models.Manifest{Name: "a", Version: "1.0.0", InstallScope: models.InstallScopeAny}
+	if got := string(EncodeManifest(m)); got != "name: a\nversion: 1.0.0\n" {
+		t.Errorf("EncodeManifest = %q", got)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "1.0.0", want: "1.0.0"},
+		{in: "git >= 2.20", want: "git >= 2.20"},
+		{in: "", want: `""`},
+		{in: "true", want: `"true"`},
+		{in: "No", want: `"No"`},
+		{in: "1.5", want: `"1.5"`},
+		{in: "42", want: `"42"`},
+		{in: "+1", want: `"+1"`},
+		{in: "0x1F", want: `"0x1F"`},
+		{in: "0o17", want: `"0o17"`},
+		{in: "0b101", want: `"0b101"`},
+		{in: "1_000", want: `"1_000"`},
+		{in: "1_000.5", want: `"1_000.5"`},
+		{in: "1:20", want: `"1:20"`},
+		{in: ".5", want: `".5"`},
+		{in: ".inf", want: `".inf"`},
+		{in: "-.Inf", want: `"-.Inf"`},
+		{in: ".nan", want: `".nan"`},
+		{in: ".NaN", want: `".NaN"`},
+		{in: "0x", want: "0x"},
+		{in: "v1_000", want: "v1_000"},
+		{in: "2024-01-02", want: `"2024-01-02"`},
+		{in: ">=1.0", want: `">=1.0"`},
+		{in: "a: b", want: `"a: b"`},
+		{in: "a #b", want: `"a #b"`},
+		{in: " padded", want: `" padded"`},
+		{in: "two\nlines", want: `"two\nlines"`},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
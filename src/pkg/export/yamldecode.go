@@ -0,0 +1,788 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+// DecodeManifest parses manifest.yaml as written by EncodeManifest, or
+// edited by hand, into a Manifest. Besides the fields EncodeManifest writes
+// it accepts hooks and questions lists, so an author can describe the
+// install-time extensions for validation. Unknown fields and values of the
+// wrong shape are errors. The Manifest is not validated; use
+// Manifest.Validate.
+//
+// Only the block-style YAML subset manifests use is supported: nested maps
+// and lists, plain and quoted scalars (quoted ones may span lines), literal
+// (|) and folded (>) block scalars, comments, and single-line flow lists of
+// scalars such as [a, b]. Anchors and flow maps other than {} are rejected.
+func DecodeManifest(data []byte) (*models.Manifest, error) {
+	tree, err := parseYAML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	root, ok := tree.(map[string]any)
+	if tree != nil && !ok {
+		return nil, errors.New("parsing manifest: top level is not a map")
+	}
+
+	d := &manifestDecoder{}
+	m := &models.Manifest{}
+	for _, key := range sortedKeys(root) {
+		v := root[key]
+		switch key {
+		case "name":
+			m.Name = d.str(key, v)
+		case "version":
+			m.Version = d.str(key, v)
+		case "description":
+			m.Description = d.str(key, v)
+		case "author":
+			m.Author = d.str(key, v)
+		case "license":
+			m.License = d.str(key, v)
+		case "tags":
+			m.Tags = d.strList(key, v)
+		case "min_claude_version":
+			m.MinClaudeVersion = d.str(key, v)
+		case "artifacts":
+			for group, paths := range d.mapping(key, v) {
+				if m.Artifacts == nil {
+					m.Artifacts = make(map[string][]string)
+				}
+				m.Artifacts[group] = d.strList(key+"."+group, paths)
+			}
+		case "variables":
+			m.Variables = d.object(key, v)
+		case "options":
+			m.Options = d.object(key, v)
+		case "install":
+			for field, fv := range d.mapping(key, v) {
+				if field != "scope" {
+					d.fail("%s: unknown field %q", key, field)
+					continue
+				}
+				m.InstallScope = models.InstallScope(d.str("install.scope", fv))
+			}
+		case "requires":
+			m.Requires = d.strList(key, v)
+		case "hooks":
+			for i, item := range d.list(key, v) {
+				m.Hooks = append(m.Hooks, d.hook(fmt.Sprintf("hooks[%d]", i), item))
+			}
+		case "questions":
+			for i, item := range d.list(key, v) {
+				m.Questions = append(m.Questions, d.question(fmt.Sprintf("questions[%d]", i), item))
+			}
+		default:
+			d.fail("unknown field %q", key)
+		}
+	}
+	if len(d.errs) > 0 {
+		return nil, fmt.Errorf("decoding manifest: %s", strings.Join(d.errs, "; "))
+	}
+	return m, nil
+}
+
+// manifestDecoder converts parsed YAML values to manifest fields,
+// collecting every shape error instead of stopping at the first.
+type manifestDecoder struct {
+	errs []string
+}
+
+func (d *manifestDecoder) fail(format string, args ...any) {
+	d.errs = append(d.errs, fmt.Sprintf(format, args...))
+}
+
+// str returns a scalar as its text; YAML null is the empty string.
+func (d *manifestDecoder) str(path string, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case plainScalar:
+		if v.isNull() {
+			return ""
+		}
+		return string(v)
+	}
+	d.fail("%s: expected a string", path)
+	return ""
+}
+
+func (d *manifestDecoder) integer(path string, v any) int {
+	f, ok := plainValue(v).(float64)
+	if !ok || f != math.Trunc(f) {
+		d.fail("%s: expected an integer", path)
+		return 0
+	}
+	return int(f)
+}
+
+func (d *manifestDecoder) boolean(path string, v any) bool {
+	b, ok := plainValue(v).(bool)
+	if !ok {
+		d.fail("%s: expected true or false", path)
+	}
+	return b
+}
+
+func (d *manifestDecoder) list(path string, v any) []any {
+	if v == nil {
+		return nil
+	}
+	items, ok := v.([]any)
+	if !ok {
+		d.fail("%s: expected a list", path)
+	}
+	return items
+}
+
+func (d *manifestDecoder) strList(path string, v any) []string {
+	items := d.list(path, v)
+	if items == nil {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for i, item := range items {
+		out = append(out, d.str(fmt.Sprintf("%s[%d]", path, i), item))
+	}
+	return out
+}
+
+func (d *manifestDecoder) mapping(path string, v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		d.fail("%s: expected a map", path)
+	}
+	return m
+}
+
+// object converts a map to the JSON-shaped values manifests hold.
+func (d *manifestDecoder) object(path string, v any) map[string]any {
+	m := d.mapping(path, v)
+	if m == nil {
+		return nil
+	}
+	obj, _ := plainValue(m).(map[string]any)
+	return obj
+}
+
+func (d *manifestDecoder) hook(path string, v any) models.ManifestHook {
+	var h models.ManifestHook
+	fields := d.mapping(path, v)
+	for _, key := range sortedKeys(fields) {
+		fv := fields[key]
+		switch key {
+		case "event":
+			h.Event = models.HookEvent(d.str(path+".event", fv))
+		case "matcher":
+			h.Matcher = d.str(path+".matcher", fv)
+		case "script_path":
+			h.ScriptPath = d.str(path+".script_path", fv)
+		case "priority":
+			h.Priority = d.integer(path+".priority", fv)
+		case "blocking":
+			h.Blocking = d.boolean(path+".blocking", fv)
+		default:
+			d.fail("%s: unknown field %q", path, key)
+		}
+	}
+	return h
+}
+
+func (d *manifestDecoder) question(path string, v any) models.ManifestQuestion {
+	var q models.ManifestQuestion
+	fields := d.mapping(path, v)
+	for _, key := range sortedKeys(fields) {
+		fv := fields[key]
+		switch key {
+		case "question_id":
+			q.QuestionID = d.str(path+".question_id", fv)
+		case "prompt":
+			q.Prompt = d.str(path+".prompt", fv)
+		case "type":
+			q.Type = models.QuestionType(d.str(path+".type", fv))
+		case "default_val":
+			q.DefaultVal = d.str(path+".default_val", fv)
+		case "choices":
+			if _, isList := fv.([]any); isList {
+				q.Choices = d.strList(path+".choices", fv)
+				continue
+			}
+			// The schema stores choices comma-separated; accept that too.
+			pq := models.PackageQuestion{Choices: d.str(path+".choices", fv)}
+			choices, err := pq.ChoicesList()
+			if err != nil {
+				d.fail("%s.choices: %v", path, err)
+			}
+			if len(choices) > 0 {
+				q.Choices = choices
+			}
+		case "sort_order":
+			q.SortOrder = d.integer(path+".sort_order", fv)
+		default:
+			d.fail("%s: unknown field %q", path, key)
+		}
+	}
+	return q
+}
+
+// plainScalar is an unquoted YAML scalar, kept as text until its type is
+// known: a manifest's version "1.10" must stay a string, while an option
+// default of 3 is a number.
+type plainScalar string
+
+func (s plainScalar) isNull() bool {
+	return s == "null" || s == "Null" || s == "NULL" || s == "~"
+}
+
+// plainValue converts parsed YAML to JSON-shaped values: plain scalars
+// become nil, bools, or float64s where they read as such, and strings
+// otherwise.
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case plainScalar:
+		switch {
+		case v.isNull():
+			return nil
+		case v == "true" || v == "True" || v == "TRUE":
+			return true
+		case v == "false" || v == "False" || v == "FALSE":
+			return false
+		}
+		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			return f
+		}
+		return string(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = plainValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = plainValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// yamlLine is one non-blank, comment-stripped line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+	// block is the decoded block scalar a line ending in | or > introduces.
+	block *string
+}
+
+// yamlParser parses the block-style YAML subset described on
+// DecodeManifest into maps, lists, quoted strings, and plainScalars.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func parseYAML(src string) (any, error) {
+	var p yamlParser
+	raws := strings.Split(src, "\n")
+	for i := 0; i < len(raws); i++ {
+		num := i + 1
+		raw := strings.TrimSuffix(raws[i], "\r")
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", num)
+		}
+		stripped, open := stripComment(text)
+		// A quoted scalar left open continues on the following lines.
+		for open && i+1 < len(raws) {
+			i++
+			text += "\n" + strings.TrimSuffix(raws[i], "\r")
+			stripped, open = stripComment(text)
+		}
+		text = strings.TrimRight(stripped, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		line := yamlLine{num: num, indent: len(raw) - len(strings.TrimLeft(raw, " ")), text: text}
+		if header, parent, ok := blockHeader(text, line.indent); ok {
+			// The scalar's lines are content, not YAML: take them raw.
+			end := i + 1
+			for end < len(raws) {
+				raw := strings.TrimSuffix(raws[end], "\r")
+				if strings.TrimLeft(raw, " ") != "" && len(raw)-len(strings.TrimLeft(raw, " ")) <= parent {
+					break
+				}
+				end++
+			}
+			block, err := blockScalar(header, parent, raws[i+1:end])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			line.block = &block
+			i = end - 1
+		}
+		p.lines = append(p.lines, line)
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	v, err := p.block(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return v, nil
+}
+
+// blockHeader reports whether text, a line at indent, ends in a block
+// scalar header such as | or >-, returning the header and the indentation
+// of the node holding the scalar, which its content must exceed.
+func blockHeader(text string, indent int) (header string, parent int, ok bool) {
+	rest, col := text, indent
+	for isSeqItem(rest) {
+		trimmed := strings.TrimLeft(strings.TrimPrefix(rest, "-"), " ")
+		parent = col
+		col += len(rest) - len(trimmed)
+		rest = trimmed
+	}
+	if _, value, isKey := splitKey(rest); isKey {
+		rest, parent = value, col
+	} else if rest == text {
+		return "", 0, false
+	}
+	if rest == "" || (rest[0] != '|' && rest[0] != '>') {
+		return "", 0, false
+	}
+	// The indentation digit and chomping indicator may come in either order.
+	digits, chomps := 0, 0
+	for _, c := range []byte(rest[1:]) {
+		switch {
+		case c == '-' || c == '+':
+			chomps++
+		case c >= '1' && c <= '9':
+			digits++
+		default:
+			return "", 0, false
+		}
+	}
+	return rest, parent, digits <= 1 && chomps <= 1
+}
+
+// blockScalar decodes the lines of a block scalar with the given header
+// whose node is at indent parent. The content is indented by the header's
+// digit beyond parent, or else by its first non-blank line. A literal (|)
+// scalar keeps its line breaks; a folded (>) one joins adjacent lines with
+// a space, except around more-indented lines, and turns each blank line
+// between them into a newline. The chomping indicator decides the final
+// line breaks: - drops them, + keeps them all, and the default keeps one.
+func blockScalar(header string, parent int, raws []string) (string, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	indent := 0
+	for _, c := range []byte(header[1:]) {
+		if c == '-' || c == '+' {
+			chomp = c
+		} else {
+			indent = parent + int(c-'0')
+		}
+	}
+
+	var lines []string
+	for _, raw := range raws {
+		raw = strings.TrimSuffix(raw, "\r")
+		spaces := len(raw) - len(strings.TrimLeft(raw, " "))
+		if indent == 0 && spaces < len(raw) {
+			indent = spaces
+		}
+		lines = append(lines, raw)
+	}
+	if indent == 0 {
+		indent = parent + 1
+	}
+	for i, line := range lines {
+		switch {
+		case len(line) >= indent && strings.TrimLeft(line[:indent], " ") == "":
+			lines[i] = line[indent:]
+		case strings.TrimLeft(line, " ") == "":
+			lines[i] = ""
+		default:
+			return "", errors.New("block scalar line is less indented than its first line")
+		}
+	}
+
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	body := lines[:len(lines)-trailing]
+
+	var b strings.Builder
+	if !folded {
+		b.WriteString(strings.Join(body, "\n"))
+	} else {
+		// folds reports whether a line break next to line may become a space.
+		folds := func(line string) bool {
+			return line != "" && line[0] != ' ' && line[0] != '\t'
+		}
+		prev, blanks := "", 0
+		for i, line := range body {
+			switch {
+			case line == "":
+				blanks++
+				continue
+			case i == blanks:
+				b.WriteString(strings.Repeat("\n", blanks))
+			case folds(prev) && folds(line) && blanks == 0:
+				b.WriteByte(' ')
+			case folds(prev) && folds(line):
+				b.WriteString(strings.Repeat("\n", blanks))
+			default:
+				b.WriteString(strings.Repeat("\n", blanks+1))
+			}
+			b.WriteString(line)
+			prev, blanks = line, 0
+		}
+	}
+	switch {
+	case chomp == '+':
+		if len(body) > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.Repeat("\n", trailing))
+	case chomp == 0 && len(body) > 0:
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// stripComment removes a # comment that is outside quotes and starts the
+// line or follows a space. open reports that s ends inside a quoted scalar
+// that starts s or follows a space, and so continues on the next line; a
+// quote inside a plain word, as in it's, never does.
+func stripComment(s string) (text string, open bool) {
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote, start = c, i
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i], false
+		}
+	}
+	return s, quote != 0 && (start == 0 || s[start-1] == ' ')
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the map or list starting at the current line.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isSeqItem(p.lines[p.pos].text) {
+		return p.seq(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := make(map[string]any)
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if isSeqItem(line.text) {
+			return nil, fmt.Errorf("line %d: list item where a key was expected", line.num)
+		}
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		var (
+			v   any
+			err error
+		)
+		switch {
+		case line.block != nil:
+			v = *line.block
+		case rest != "":
+			v, err = parseScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			v, err = p.block(p.lines[p.pos].indent)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSeqItem(p.lines[p.pos].text):
+			// A list may sit at its key's indentation.
+			v, err = p.seq(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+func (p *yamlParser) seq(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSeqItem(line.text) {
+			if line.indent > indent {
+				return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+			}
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				v, err := p.block(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			} else {
+				items = append(items, nil)
+			}
+			continue
+		}
+		if _, _, ok := splitKey(rest); ok {
+			// "- key: value" starts a map whose keys align with "key".
+			p.lines[p.pos] = yamlLine{num: line.num, indent: indent + len(line.text) - len(rest), text: rest, block: line.block}
+			v, err := p.mapping(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			continue
+		}
+		if line.block != nil {
+			items = append(items, *line.block)
+			p.pos++
+			continue
+		}
+		v, err := parseScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		items = append(items, v)
+		p.pos++
+	}
+	return items, nil
+}
+
+// splitKey splits "key: value" or "key:" into the key and the value text.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := models.ClosingQuote(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		k, err := parseQuoted(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		after := text[end+2:]
+		if after != "" && after[0] != ' ' {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(after), true
+	}
+	if strings.ContainsAny(text[:1], "[{") {
+		return "", "", false
+	}
+	if i := strings.Index(text, ": "); i > 0 {
+		return text[:i], strings.TrimSpace(text[i+2:]), true
+	}
+	if strings.HasSuffix(text, ":") && len(text) > 1 {
+		return text[:len(text)-1], "", true
+	}
+	return "", "", false
+}
+
+// parseQuoted decodes a single- or double-quoted scalar whose closing
+// quote ends s. Double quotes take the YAML 1.2 escapes. A scalar spanning
+// lines is folded as YAML does: white space around each line break is
+// dropped, and the break becomes a space, or n-1 newlines for a run of n
+// breaks. A backslash before a break in double quotes removes the break.
+func parseQuoted(s string) (string, error) {
+	double := s[0] == '"'
+	body := s[1 : len(s)-1]
+	var out []byte
+	// keep is the length of out that a fold must not trim: white space
+	// written by an escape is content.
+	keep := 0
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\n':
+			for len(out) > keep && (out[len(out)-1] == ' ' || out[len(out)-1] == '\t') {
+				out = out[:len(out)-1]
+			}
+			breaks := 0
+			for ; i < len(body) && strings.IndexByte(" \t\n", body[i]) >= 0; i++ {
+				if body[i] == '\n' {
+					breaks++
+				}
+			}
+			i--
+			if breaks == 1 {
+				out = append(out, ' ')
+			}
+			for ; breaks > 1; breaks-- {
+				out = append(out, '\n')
+			}
+			keep = len(out)
+		case !double && c == '\'':
+			// ClosingQuote guarantees a quote inside the body is doubled.
+			out = append(out, '\'')
+			i++
+		case double && c == '\\':
+			i++
+			if body[i] == '\n' {
+				for i+1 < len(body) && (body[i+1] == ' ' || body[i+1] == '\t') {
+					i++
+				}
+				continue
+			}
+			r, n, err := yamlEscape(body[i:])
+			if err != nil {
+				return "", fmt.Errorf("invalid double-quoted string %s: %w", s, err)
+			}
+			out = utf8.AppendRune(out, r)
+			i += n - 1
+			keep = len(out)
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out), nil
+}
+
+// yamlEscapes maps the single-character escapes of YAML double-quoted
+// scalars to what they stand for.
+var yamlEscapes = map[byte]rune{
+	'0': 0, 'a': '\a', 'b': '\b', 't': '\t', '\t': '\t', 'n': '\n', 'v': '\v',
+	'f': '\f', 'r': '\r', 'e': 0x1b, ' ': ' ', '"': '"', '/': '/', '\\': '\\',
+	'N': 0x85, '_': 0xa0, 'L': 0x2028, 'P': 0x2029,
+}
+
+// yamlEscapeDigits is the number of hex digits each numeric escape takes.
+var yamlEscapeDigits = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// yamlEscape decodes the escape at the start of s, which follows a
+// backslash, returning the rune and how many bytes of s it used.
+func yamlEscape(s string) (rune, int, error) {
+	if r, ok := yamlEscapes[s[0]]; ok {
+		return r, 1, nil
+	}
+	digits, ok := yamlEscapeDigits[s[0]]
+	if !ok {
+		r, _ := utf8.DecodeRuneInString(s)
+		return 0, 0, fmt.Errorf("unknown escape \\%c", r)
+	}
+	if len(s) <= digits {
+		return 0, 0, fmt.Errorf("short escape \\%s", s)
+	}
+	n, err := strconv.ParseUint(s[1:1+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, 0, fmt.Errorf("bad escape \\%s", s[:1+digits])
+	}
+	return rune(n), 1 + digits, nil
+}
+
+// parseScalar parses an inline value: a quoted or plain scalar, or a flow
+// list of scalars.
+func parseScalar(s string) (any, error) {
+	switch s[0] {
+	case '"', '\'':
+		if models.ClosingQuote(s) != len(s)-1 {
+			return nil, fmt.Errorf("unterminated or trailing text after quoted string %s", s)
+		}
+		return parseQuoted(s)
+	case '[':
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated flow list %s", s)
+		}
+		return parseFlowList(strings.TrimSpace(s[1 : len(s)-1]))
+	case '{':
+		if strings.TrimSpace(s[1:len(s)-1]) == "" && strings.HasSuffix(s, "}") {
+			return map[string]any{}, nil
+		}
+		return nil, errors.New("flow maps are not supported")
+	case '|', '>':
+		return nil, fmt.Errorf("invalid block scalar header %s", s)
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases, and tags are not supported")
+	}
+	return plainScalar(s), nil
+}
+
+func parseFlowList(s string) (any, error) {
+	items := []any{}
+	for s != "" {
+		var item string
+		if s[0] == '"' || s[0] == '\'' {
+			end := models.ClosingQuote(s)
+			if end < 0 {
+				return nil, errors.New("unterminated quoted string in flow list")
+			}
+			item, s = s[:end+1], strings.TrimSpace(s[end+1:])
+		} else {
+			i := strings.IndexByte(s, ',')
+			if i < 0 {
+				i = len(s)
+			}
+			item, s = strings.TrimSpace(s[:i]), s[i:]
+		}
+		if item == "" || strings.ContainsAny(item[:1], "[{") {
+			return nil, errors.New("empty items and nested collections are not supported in flow lists")
+		}
+		if s != "" && s[0] != ',' {
+			return nil, fmt.Errorf("expected a comma after %s", item)
+		}
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+
+		v, err := parseScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
@@ -0,0 +1,323 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/randlee/synaptic-canvas-dolt/pkg/models"
+)
+
+func TestDecodeManifestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	m := &models.Manifest{
+		Name:             "sc-git-worktree",
+		Version:          "0.10",
+		Description:      "Manage git worktrees: safely # really",
+		Author:           "yes",
+		Tags:             []string{"git", "2024-01-01"},
+		MinClaudeVersion: "1.0.32",
+		InstallScope:     models.InstallScopeLocalOnly,
+		Artifacts: map[string][]string{
+			"skills": {"skills/sc-git-worktree/SKILL.md"},
+			"agents": {"agents/create.md", "agents/scan.md"},
+		},
+		Variables: map[string]any{
+			"REPO_NAME": map[string]any{"auto": "git-repo-basename"},
+		},
+		Options: map[string]any{
+			"no-tracking": map[string]any{"type": "boolean", "default": false, "levels": []any{1.5, "yes", nil}},
+			"empty":       map[string]any{},
+			"none":        []any{},
+		},
+		Requires: []string{"git >= 2.20", "python3"},
+	}
+
+	got, err := DecodeManifest(EncodeManifest(m))
+	if err != nil {
+		t.Fatalf("DecodeManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", got, m)
+	}
+}
+
+func TestDecodeManifestExtensions(t *testing.T) {
+	t.Parallel()
+
+	src := `# hand-written manifest
+name: demo
+version: 1.0.0
+tags: [git, "commit, msg"]
+artifacts:
+  hooks:
+  - hooks/pre.sh
+hooks:
+  - event: PreToolUse
+    matcher: Bash
+    script_path: hooks/pre.sh
+    priority: 10
+    blocking: true
+questions:
+  - question_id: style
+    prompt: 'Commit style?'
+    type: choice
+    choices: conventional,plain
+    default_val: plain
+  - question_id: scopes
+    type: multi
+    choices:
+      - api
+      - ui
+    sort_order: 2
+`
+	m, err := DecodeManifest([]byte(src))
+	if err != nil {
+		t.Fatalf("DecodeManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(m.Tags, []string{"git", "commit, msg"}) {
+		t.Errorf("Tags = %q", m.Tags)
+	}
+	if !reflect.DeepEqual(m.Artifacts, map[string][]string{"hooks": {"hooks/pre.sh"}}) {
+		t.Errorf("Artifacts = %v", m.Artifacts)
+	}
+	wantHook := models.ManifestHook{Event: models.HookPreToolUse, Matcher: "Bash", ScriptPath: "hooks/pre.sh", Priority: 10, Blocking: true}
+	if len(m.Hooks) != 1 || m.Hooks[0] != wantHook {
+		t.Errorf("Hooks = %+v, want %+v", m.Hooks, wantHook)
+	}
+	if len(m.Questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(m.Questions))
+	}
+	if q := m.Questions[0]; q.Prompt != "Commit style?" || !reflect.DeepEqual(q.Choices, []string{"conventional", "plain"}) {
+		t.Errorf("question 0 = %+v", q)
+	}
+	if q := m.Questions[1]; !reflect.DeepEqual(q.Choices, []string{"api", "ui"}) || q.SortOrder != 2 {
+		t.Errorf("question 1 = %+v", q)
+	}
+	if problems := m.Problems(); len(problems) != 0 {
+		t.Errorf("decoded manifest should be valid: %v", problems)
+	}
+}
+
+func TestDecodeManifestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "unknown field", src: "name: x\nbogus: 1\n", want: `unknown field "bogus"`},
+		{name: "wrong shape", src: "tags: git\n", want: "tags: expected a list"},
+		{name: "bad priority", src: "hooks:\n  - priority: high\n", want: "hooks[0].priority: expected an integer"},
+		{name: "duplicate key", src: "name: a\nname: b\n", want: `duplicate key "name"`},
+		{name: "bad indentation", src: "name: a\n   version: 1\n", want: "line 2: unexpected indentation"},
+		{name: "bad block scalar header", src: "description: |x\n  text\n", want: "invalid block scalar header |x"},
+		{name: "block scalar underindented", src: "description: |4\n  text\n", want: "line 1: block scalar line is less indented"},
+		{name: "not a map", src: "- a\n", want: "top level is not a map"},
+		{name: "tab indentation", src: "artifacts:\n\tskills: []\n", want: "tabs are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := DecodeManifest([]byte(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeManifestQuotedScalars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "go escapes", src: `"tab\there\nnew \"q\" \\ \x41é\U0001F600"`, want: "tab\there\nnew \"q\" \\ Aé\U0001F600"},
+		{name: "yaml escapes", src: `"a\/b \e\0 \N\_\L\P\ \	x"`, want: "a/b \x1b\x00 \u0085    \tx"},
+		{name: "bell and vertical tab", src: `"\a\v\f\r\b"`, want: "\a\v\f\r\b"},
+		{name: "single quotes", src: `'it''s \n raw'`, want: `it's \n raw`},
+		{name: "folded", src: "\"one  \n   two\"", want: "one two"},
+		{name: "blank line keeps a newline", src: "\"one\n\n  two\"", want: "one\ntwo"},
+		{name: "escaped break", src: "\"one \\\n   two\"", want: "one two"},
+		{name: "escaped break joins", src: "\"con\\\n  cat\"", want: "concat"},
+		{name: "escaped space survives fold", src: "\"one\\ \n two\"", want: "one  two"},
+		{name: "single-quoted fold", src: "'one\n  two # not a comment'", want: "one two # not a comment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := DecodeManifest([]byte("description: " + tt.src + "\nname: x\n"))
+			if err != nil {
+				t.Fatalf("DecodeManifest failed: %v", err)
+			}
+			if m.Description != tt.want || m.Name != "x" {
+				t.Errorf("Description = %q, Name = %q; want %q, x", m.Description, m.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeManifestBlockScalars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "literal", src: "|\n  one\n    two\n  # kept\n", want: "one\n  two\n# kept\n"},
+		{name: "literal strip", src: "|-\n  one\n  two\n\n", want: "one\ntwo"},
+		{name: "literal keep", src: "|+\n  one\n\n\n", want: "one\n\n\n"},
+		{name: "literal indentation digit", src: "|2\n    one\n  two\n", want: "  one\ntwo\n"},
+		{name: "folded", src: ">\n  one\n  two\n\n  three\n", want: "one two\nthree\n"},
+		{name: "folded more indented", src: ">\n  one\n    code\n  two\n", want: "one\n  code\ntwo\n"},
+		{name: "folded strip with comment", src: ">- # note\n  one\n  two\n", want: "one two"},
+		{name: "leading blank lines", src: ">\n\n  one\n", want: "\none\n"},
+		{name: "empty", src: "|\n", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := DecodeManifest([]byte("description: " + tt.src + "name: x\n"))
+			if err != nil {
+				t.Fatalf("DecodeManifest failed: %v", err)
+			}
+			if m.Description != tt.want || m.Name != "x" {
+				t.Errorf("Description = %q, Name = %q; want %q, x", m.Description, m.Name, tt.want)
+			}
+		})
+	}
+
+	m, err := DecodeManifest([]byte("tags:\n  - |\n    a\n  - b\nquestions:\n  - prompt: >-\n      Which\n      style?\n    type: text\n"))
+	if err != nil {
+		t.Fatalf("DecodeManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(m.Tags, []string{"a\n", "b"}) || m.Questions[0].Prompt != "Which style?" || m.Questions[0].Type != "text" {
+		t.Errorf("Tags = %q, Questions = %+v", m.Tags, m.Questions)
+	}
+}
+
+// TestDecodeManifestExportPipelineExample decodes the sc-git-worktree
+// manifest from docs/synaptic-canvas-export-pipeline.md.
+func TestDecodeManifestExportPipelineExample(t *testing.T) {
+	t.Parallel()
+
+	src := `name: sc-git-worktree
+version: 0.9.0
+description: >
+  Manage git worktrees with optional tracking and protected branch safeguards.
+author: randlee
+license: MIT
+tags:
+  - git
+  - worktree
+  - workflow
+
+artifacts:
+  commands:
+    - commands/sc-git-worktree.md
+  skills:
+    - skills/sc-git-worktree/SKILL.md
+  agents:
+    - agents/sc-git-worktree-create.md
+    - agents/sc-git-worktree-scan.md
+    - agents/sc-git-worktree-cleanup.md
+    - agents/sc-git-worktree-abort.md
+    - agents/sc-git-worktree-update.md
+  scripts:
+    - scripts/envelope.py
+    - scripts/worktree_shared.py
+    - scripts/worktree_scan.py
+
+# From packages.variables JSON column
+variables:
+  REPO_NAME:
+    auto: git-repo-basename
+    description: Repository name for default worktree paths
+
+# From packages.install_scope column
+install:
+  scope: local-only
+
+# From packages.options JSON column
+options:
+  no-tracking:
+    type: boolean
+    default: false
+    description: Disable worktree tracking document references
+
+requires:
+  - python3
+  - pydantic
+  - git >= 2.20
+`
+	got, err := DecodeManifest([]byte(src))
+	if err != nil {
+		t.Fatalf("DecodeManifest failed: %v", err)
+	}
+	want := &models.Manifest{
+		Name:         "sc-git-worktree",
+		Version:      "0.9.0",
+		Description:  "Manage git worktrees with optional tracking and protected branch safeguards.\n",
+		Author:       "randlee",
+		License:      "MIT",
+		Tags:         []string{"git", "worktree", "workflow"},
+		InstallScope: models.InstallScopeLocalOnly,
+		Artifacts: map[string][]string{
+			"commands": {"commands/sc-git-worktree.md"},
+			"skills":   {"skills/sc-git-worktree/SKILL.md"},
+			"agents": {
+				"agents/sc-git-worktree-create.md",
+				"agents/sc-git-worktree-scan.md",
+				"agents/sc-git-worktree-cleanup.md",
+				"agents/sc-git-worktree-abort.md",
+				"agents/sc-git-worktree-update.md",
+			},
+			"scripts": {"scripts/envelope.py", "scripts/worktree_shared.py", "scripts/worktree_scan.py"},
+		},
+		Variables: map[string]any{
+			"REPO_NAME": map[string]any{
+				"auto":        "git-repo-basename",
+				"description": "Repository name for default worktree paths",
+			},
+		},
+		Options: map[string]any{
+			"no-tracking": map[string]any{
+				"type":        "boolean",
+				"default":     false,
+				"description": "Disable worktree tracking document references",
+			},
+		},
+		Requires: []string{"python3", "pydantic", "git >= 2.20"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded manifest mismatch:\n got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestDecodeManifestBadEscapes(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{`"\q"`, `"\x4"`, `"\uZZZZ"`, `"\UFFFFFFFF"`, "\"never closed\nname: x\n"} {
+		if _, err := DecodeManifest([]byte("description: " + src + "\n")); err == nil {
+			t.Errorf("DecodeManifest(%q) succeeded, want an error", src)
+		}
+	}
+}
+
+func TestDecodeManifestApostropheInPlainScalar(t *testing.T) {
+	t.Parallel()
+
+	m, err := DecodeManifest([]byte("description: it's fine\nname: x\n"))
+	if err != nil {
+		t.Fatalf("DecodeManifest failed: %v", err)
+	}
+	if m.Description != "it's fine" || m.Name != "x" {
+		t.Errorf("got %q, %q; an apostrophe must not open a quote", m.Description, m.Name)
+	}
+}
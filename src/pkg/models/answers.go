@@ -0,0 +1,245 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LoadAnswers reads an answer file mapping question IDs to answers, as
+// used for non-interactive installs. The file is a JSON object when it
+// starts with "{", and otherwise a flat YAML mapping of "question_id:
+// value" lines with optional quoting and comments. Booleans and numbers
+// become their text, and a list answer to a multi question becomes a
+// comma-separated string. A null or empty YAML value counts as no answer.
+func LoadAnswers(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading answers: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return decodeJSONAnswers(data)
+	}
+	return decodeYAMLAnswers(string(data))
+}
+
+// decodeJSONAnswers parses a JSON object of answers.
+func decodeJSONAnswers(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing answers: %w", err)
+	}
+	answers := make(map[string]string, len(raw))
+	for id, v := range raw {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			answers[id] = v
+		case bool:
+			answers[id] = strconv.FormatBool(v)
+		case json.Number:
+			answers[id] = v.String()
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("parsing answers: %q: list items must be strings", id)
+				}
+				items[i] = s
+			}
+			answers[id] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("parsing answers: %q: unsupported value %v", id, v)
+		}
+	}
+	return answers, nil
+}
+
+// decodeYAMLAnswers parses a flat YAML mapping of answers. Nested values
+// are rejected; a flow list such as [a, b] is read as "a,b".
+func decodeYAMLAnswers(src string) (map[string]string, error) {
+	answers := make(map[string]string)
+	seen := make(map[string]bool)
+	for n, line := range strings.Split(src, "\n") {
+		text := strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if text[0] == ' ' || text[0] == '\t' {
+			return nil, fmt.Errorf("parsing answers: line %d: nested values are not supported", n+1)
+		}
+		id, rest, ok := strings.Cut(trimmed, ":")
+		if !ok || id == "" || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			return nil, fmt.Errorf("parsing answers: line %d: want \"question_id: value\"", n+1)
+		}
+		id = strings.TrimSpace(id)
+		if seen[id] {
+			return nil, fmt.Errorf("parsing answers: line %d: %q is answered more than once", n+1, id)
+		}
+		seen[id] = true
+		value, present, err := yamlAnswerValue(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("parsing answers: line %d: %w", n+1, err)
+		}
+		if present {
+			answers[id] = value
+		}
+	}
+	return answers, nil
+}
+
+// yamlAnswerValue unquotes a YAML scalar or flow list. present is false for
+// an empty or null value.
+func yamlAnswerValue(s string) (value string, present bool, err error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := ClosingQuote(s)
+		if end < 0 || !isComment(s[end+1:]) {
+			return "", false, fmt.Errorf("unterminated or trailing text after %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", false, fmt.Errorf("bad quoted value %s: %w", s, err)
+		}
+		return v, true, nil
+	case strings.HasPrefix(s, "'"):
+		end := ClosingQuote(s)
+		if end < 0 || !isComment(s[end+1:]) {
+			return "", false, fmt.Errorf("unterminated or trailing text after %s", s)
+		}
+		return strings.ReplaceAll(s[1:end], "''", "'"), true, nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return "", false, fmt.Errorf("unterminated list %s", s)
+		}
+		items := strings.Split(s[1:len(s)-1], ",")
+		for i, item := range items {
+			items[i] = strings.Trim(strings.TrimSpace(item), `"'`)
+		}
+		return strings.Join(items, ","), true, nil
+	}
+	if s == "" || s == "~" || s == "null" {
+		return "", false, nil
+	}
+	return s, true, nil
+}
+
+// isComment reports whether s is empty or only a trailing comment.
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// ClosingQuote returns the index of the quote closing the YAML quoted
+// scalar at the start of s, or -1. Backslash escapes are skipped in double
+// quotes and doubled quotes in single quotes, so a later comment holding a
+// quote character is not mistaken for the end. The manifest decoder in
+// package export shares it.
+func ClosingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateAnswer checks value against question q: a choice answer must be
+// one of its choices, every item of a multi answer must be, and a confirm
+// answer must be a boolean such as true, false, yes, or no. Text and auto
+// questions accept any answer.
+func ValidateAnswer(q ManifestQuestion, value string) error {
+	switch q.Type {
+	case QuestionChoice:
+		if !slices.Contains(q.Choices, value) {
+			return fmt.Errorf("question %q: %q is not one of %s", q.QuestionID, value, strings.Join(q.Choices, ", "))
+		}
+	case QuestionMulti:
+		items, err := splitList(value)
+		if err != nil {
+			return fmt.Errorf("question %q: parsing answer: %w", q.QuestionID, err)
+		}
+		for _, item := range items {
+			if !slices.Contains(q.Choices, item) {
+				return fmt.Errorf("question %q: %q is not one of %s", q.QuestionID, item, strings.Join(q.Choices, ", "))
+			}
+		}
+	case QuestionConfirm:
+		if _, ok := parseConfirm(value); !ok {
+			return fmt.Errorf("question %q: %q is not a yes/no answer", q.QuestionID, value)
+		}
+	case QuestionText, QuestionAuto:
+	default:
+		return fmt.Errorf("question %q: invalid type %q", q.QuestionID, q.Type)
+	}
+	return nil
+}
+
+// parseConfirm parses a confirm answer.
+func parseConfirm(s string) (value, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "y", "1":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// ResolveAnswers completes answers for questions: each answer is checked
+// with ValidateAnswer, and a question left unanswered takes its default.
+// An auto question with neither is left out for the installer to fill in;
+// any other question with neither an answer nor a default, and an answer
+// to a question that does not exist, are errors. Every problem is reported
+// in a single error.
+func ResolveAnswers(questions []ManifestQuestion, answers map[string]string) (map[string]string, error) {
+	var problems []string
+	resolved := make(map[string]string, len(questions))
+	known := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		known[q.QuestionID] = true
+		value, ok := answers[q.QuestionID]
+		if !ok {
+			if q.DefaultVal == "" && q.Type == QuestionAuto {
+				continue
+			}
+			if q.DefaultVal == "" {
+				problems = append(problems, fmt.Sprintf("question %q has no answer and no default", q.QuestionID))
+				continue
+			}
+			value = q.DefaultVal
+		}
+		if err := ValidateAnswer(q, value); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		resolved[q.QuestionID] = value
+	}
+	for _, id := range sortedKeys(answers) {
+		if !known[id] {
+			problems = append(problems, fmt.Sprintf("answer for unknown question %q", id))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid answers: %s", strings.Join(problems, "; "))
+	}
+	return resolved, nil
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+var answerQuestions = []ManifestQuestion{
+	{QuestionID: "mode", Type: QuestionChoice, Choices: []string{"fast", "slow"}},
+	{QuestionID: "langs", Type: QuestionMulti, Choices: []string{"go", "python", "rust"}},
+	{QuestionID: "telemetry", Type: QuestionConfirm, DefaultVal: "no"},
+	{QuestionID: "name", Type: QuestionText},
+	{QuestionID: "os", Type: QuestionAuto},
+}
+
+func TestLoadAnswers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "json",
+			input: `{"mode": "fast", "langs": ["go", "rust"], "telemetry": true, "port": 8080, "skip": null}`,
+			want:  map[string]string{"mode": "fast", "langs": "go,rust", "telemetry": "true", "port": "8080"},
+		},
+		{
+			name: "yaml",
+			input: "# answers\nmode: fast  # fastest\nlangs: [go, rust]\nname: \"Ada: Lovelace\"\n" +
+				"quote: 'it''s'\nempty:\n",
+			want: map[string]string{"mode": "fast", "langs": "go,rust", "name": "Ada: Lovelace", "quote": "it's"},
+		},
+		{
+			name:  "yaml quote in trailing comment",
+			input: "name: \"a\"  # say \"hi\"\nquote: 'b'  # it's\nesc: \"x\\\"y\"  # z\n",
+			want:  map[string]string{"name": "a", "quote": "b", "esc": `x"y`},
+		},
+		{name: "yaml unterminated", input: "name: \"a  # say\n", wantErr: true},
+		{name: "json list of numbers", input: `{"langs": [1]}`, wantErr: true},
+		{name: "json not an object", input: `{"mode"`, wantErr: true},
+		{name: "yaml nested", input: "mode:\n  fast: true\n", wantErr: true},
+		{name: "yaml duplicate", input: "mode: fast\nmode: slow\n", wantErr: true},
+		{name: "yaml no colon", input: "mode fast\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := LoadAnswers(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadAnswers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("answer %q = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAnswer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		q       int
+		value   string
+		wantErr bool
+	}{
+		{"choice", 0, "fast", false},
+		{"bad choice", 0, "medium", true},
+		{"multi", 1, "go, python", false},
+		{"bad multi item", 1, "go,java", true},
+		{"confirm", 2, "Yes", false},
+		{"bad confirm", 2, "maybe", true},
+		{"text", 3, "anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateAnswer(answerQuestions[tt.q], tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAnswer(%q) = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveAnswers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("complete", func(t *testing.T) {
+		t.Parallel()
+		answers, err := LoadAnswers(strings.NewReader(`{"mode": "slow", "langs": ["go"], "name": "demo"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ResolveAnswers(answerQuestions, answers)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]string{"mode": "slow", "langs": "go", "telemetry": "no", "name": "demo"}
+		if len(got) != len(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("answer %q = %q, want %q", k, got[k], v)
+			}
+		}
+	})
+
+	t.Run("invalid choice", func(t *testing.T) {
+		t.Parallel()
+		answers := map[string]string{"mode": "medium", "langs": "java", "name": "demo"}
+		_, err := ResolveAnswers(answerQuestions, answers)
+		if err == nil {
+			t.Fatal("expected an error for invalid choices")
+		}
+		// Every invalid answer is reported at once.
+		for _, want := range []string{`"medium" is not one of fast, slow`, `"java" is not one of`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("missing required", func(t *testing.T) {
+		t.Parallel()
+		answers := map[string]string{"mode": "fast", "langs": "go", "extra": "x"}
+		_, err := ResolveAnswers(answerQuestions, answers)
+		if err == nil {
+			t.Fatal("expected an error for a missing answer")
+		}
+		for _, want := range []string{`question "name" has no answer and no default`, `unknown question "extra"`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should mention %q", err, want)
+			}
+		}
+	})
+}
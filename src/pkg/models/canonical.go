@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// CanonicalJSON returns m encoded as JSON with a deterministic layout, for
+// output that is diffed or cached. encoding/json already writes map keys,
+// including those nested in Variables and Options, in sorted order; on top
+// of that Tags, Requires, and each Artifacts list are sorted, Hooks are
+// ordered by event, priority, and script path, and Questions by sort order
+// and ID. Choices keep their order since it is the display order. Empty
+// fields are omitted exactly as in json.Marshal, and m is not modified.
+func (m *Manifest) CanonicalJSON() ([]byte, error) {
+	c := *m
+	c.Tags = sortedStrings(m.Tags)
+	c.Requires = sortedStrings(m.Requires)
+	if m.Artifacts != nil {
+		c.Artifacts = make(map[string][]string, len(m.Artifacts))
+		for k, paths := range m.Artifacts {
+			c.Artifacts[k] = sortedStrings(paths)
+		}
+	}
+
+	c.Hooks = slices.Clone(m.Hooks)
+	sort.SliceStable(c.Hooks, func(i, j int) bool {
+		a, b := c.Hooks[i], c.Hooks[j]
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.ScriptPath != b.ScriptPath {
+			return a.ScriptPath < b.ScriptPath
+		}
+		return a.Matcher < b.Matcher
+	})
+
+	c.Questions = slices.Clone(m.Questions)
+	sort.SliceStable(c.Questions, func(i, j int) bool {
+		a, b := c.Questions[i], c.Questions[j]
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		return a.QuestionID < b.QuestionID
+	})
+
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest %q: %w", m.ID, err)
+	}
+	return data, nil
+}
+
+// sortedStrings returns a sorted copy of s, preserving nil.
+func sortedStrings(s []string) []string {
+	c := slices.Clone(s)
+	sort.Strings(c)
+	return c
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func canonicalFixture(vars, opts map[string]any) *Manifest {
+	return &Manifest{
+		ID:        "pkg-1",
+		Name:      "demo",
+		Version:   "1.0.0",
+		Tags:      []string{"zeta", "alpha"},
+		Variables: vars,
+		Options:   opts,
+		Artifacts: map[string][]string{"skills": {"skills/b.md", "skills/a.md"}},
+		Requires:  []string{"jq", "git >= 2.0"},
+		Hooks: []ManifestHook{
+			{Event: HookPreToolUse, ScriptPath: "hooks/b.sh", Priority: 2},
+			{Event: HookPreToolUse, ScriptPath: "hooks/a.sh", Priority: 1},
+		},
+		Questions: []ManifestQuestion{
+			{QuestionID: "q2", SortOrder: 2, Choices: []string{"y", "x"}},
+			{QuestionID: "q1", SortOrder: 1},
+		},
+	}
+}
+
+func TestCanonicalJSONStable(t *testing.T) {
+	t.Parallel()
+
+	m := canonicalFixture(map[string]any{"b": 1.0, "a": map[string]any{"y": true, "x": false}}, nil)
+	first, err := m.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 10 {
+		again, err := m.CanonicalJSON()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("output changed between calls:\n%s\n%s", first, again)
+		}
+	}
+
+	var decoded Manifest
+	if err := json.Unmarshal(first, &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if strings.Join(decoded.Tags, ",") != "alpha,zeta" || decoded.Requires[0] != "git >= 2.0" {
+		t.Errorf("lists not sorted: tags %v, requires %v", decoded.Tags, decoded.Requires)
+	}
+	if decoded.Artifacts["skills"][0] != "skills/a.md" || decoded.Hooks[0].ScriptPath != "hooks/a.sh" {
+		t.Errorf("artifacts or hooks not sorted: %v %v", decoded.Artifacts, decoded.Hooks)
+	}
+	if decoded.Questions[0].QuestionID != "q1" || strings.Join(decoded.Questions[1].Choices, ",") != "y,x" {
+		t.Errorf("questions = %+v, want q1 first with choices in original order", decoded.Questions)
+	}
+	if m.Tags[0] != "zeta" || m.Hooks[0].ScriptPath != "hooks/b.sh" {
+		t.Error("CanonicalJSON modified the manifest")
+	}
+	if strings.Contains(string(first), `"options"`) || strings.Contains(string(first), `"description"`) {
+		t.Errorf("empty fields should be omitted: %s", first)
+	}
+}
+
+func TestCanonicalJSONInsertionOrder(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"delta", "alpha", "charlie", "bravo", "echo"}
+	forward := map[string]any{}
+	for i, k := range keys {
+		forward[k] = map[string]any{"n": float64(i), "auto": k}
+	}
+	backward := map[string]any{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		backward[keys[i]] = map[string]any{"auto": keys[i], "n": float64(i)}
+	}
+
+	a, err := canonicalFixture(forward, map[string]any{"x": 1.0, "a": 2.0}).CanonicalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := canonicalFixture(backward, map[string]any{"a": 2.0, "x": 1.0}).CanonicalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("output depends on insertion order:\n%s\n%s", a, b)
+	}
+}
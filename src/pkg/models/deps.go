@@ -0,0 +1,78 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// CycleError reports a dependency cycle found by ResolveInstallOrder.
+type CycleError struct {
+	// Cycle lists the package IDs around the cycle, starting and ending
+	// with the same ID, e.g. [a b a].
+	Cycle []string
+}
+
+// Error implements error.
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// ResolveInstallOrder returns root and every package it transitively
+// depends on, ordered so that each package comes after all of its
+// dependencies and root comes last. deps maps a package ID to the IDs of
+// the packages it depends on; an ID without an entry has none. Siblings are
+// visited in sorted order so the result is deterministic. A cycle reachable
+// from root is reported as a *CycleError.
+func ResolveInstallOrder(root string, deps map[string][]string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order, stack []string
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case done:
+			return nil
+		case visiting:
+			start := slices.Index(stack, id)
+			cycle := append(slices.Clone(stack[start:]), id)
+			return &CycleError{Cycle: cycle}
+		}
+		state[id] = visiting
+		stack = append(stack, id)
+
+		children := slices.Clone(deps[id])
+		slices.Sort(children)
+		for _, dep := range slices.Compact(children) {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		order = append(order, id)
+		return nil
+	}
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// PackageDepIDs returns the IDs of the catalog packages among deps, the
+// dep_type "skill" entries, sorted and without duplicates. Tool and CLI
+// dependencies are external and not included.
+func PackageDepIDs(deps []PackageDep) []string {
+	var ids []string
+	for _, d := range deps {
+		if d.DepType == DepTypeSkill {
+			ids = append(ids, d.DepName)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
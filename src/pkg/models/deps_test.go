@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestResolveInstallOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		root      string
+		deps      map[string][]string
+		want      string
+		wantCycle string
+	}{
+		{name: "no deps", root: "a", want: "[a]"},
+		{name: "chain", root: "a", deps: map[string][]string{"a": {"b"}, "b": {"c"}}, want: "[c b a]"},
+		{
+			name: "diamond installs shared dep once",
+			root: "app",
+			deps: map[string][]string{"app": {"web", "cli"}, "web": {"util"}, "cli": {"util"}},
+			want: "[util cli web app]",
+		},
+		{name: "duplicate edges", root: "a", deps: map[string][]string{"a": {"b", "b"}}, want: "[b a]"},
+		{name: "self cycle", root: "a", deps: map[string][]string{"a": {"a"}}, wantCycle: "[a a]"},
+		{
+			name:      "cycle below root",
+			root:      "a",
+			deps:      map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}},
+			wantCycle: "[b c b]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ResolveInstallOrder(tt.root, tt.deps)
+			if tt.wantCycle != "" {
+				var cycleErr *CycleError
+				if !errors.As(err, &cycleErr) || fmt.Sprint(cycleErr.Cycle) != tt.wantCycle {
+					t.Fatalf("err = %v, want cycle %s", err, tt.wantCycle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("order = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageDepIDs(t *testing.T) {
+	t.Parallel()
+
+	deps := []PackageDep{
+		{DepType: DepTypeSkill, DepName: "util"},
+		{DepType: DepTypeTool, DepName: "python3"},
+		{DepType: DepTypeSkill, DepName: "base"},
+		{DepType: DepTypeSkill, DepName: "util"},
+	}
+	if got := PackageDepIDs(deps); fmt.Sprint(got) != "[base util]" {
+		t.Errorf("PackageDepIDs = %v, want [base util]", got)
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NormalizeDestPath returns p in the canonical dest_path form: forward
+// slashes, no "." segments (so no leading "./"), and no empty segments from
+// repeated separators. A leading "/" is kept so ValidateDestPath can reject
+// absolute paths, and ".." segments are kept for the same reason.
+func NormalizeDestPath(p string) string {
+	p = strings.ReplaceAll(p, `\`, "/")
+	parts := strings.Split(p, "/")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part != "" && part != "." {
+			kept = append(kept, part)
+		}
+	}
+	norm := strings.Join(kept, "/")
+	if strings.HasPrefix(p, "/") {
+		norm = "/" + norm
+	}
+	return norm
+}
+
+// ValidateDestPath reports whether p, after NormalizeDestPath, is a usable
+// dest_path: non-empty, relative, without a drive letter, and free of ".."
+// segments that would escape the install root.
+func ValidateDestPath(p string) error {
+	norm := NormalizeDestPath(p)
+	switch {
+	case norm == "" || norm == "/":
+		return fmt.Errorf("dest path %q is empty", p)
+	case strings.HasPrefix(norm, "/"):
+		return fmt.Errorf("dest path %q is absolute", p)
+	case len(norm) >= 2 && norm[1] == ':':
+		return fmt.Errorf("dest path %q has a drive letter", p)
+	}
+	for _, part := range strings.Split(norm, "/") {
+		if part == ".." {
+			return fmt.Errorf("dest path %q contains a \"..\" segment", p)
+		}
+	}
+	return nil
+}
+
+// JoinDestPath joins destPath, normalized with NormalizeDestPath, under dir,
+// rejecting absolute paths and paths that escape dir.
+func JoinDestPath(dir, destPath string) (string, error) {
+	if err := ValidateDestPath(destPath); err != nil {
+		return "", err
+	}
+	rel := filepath.FromSlash(NormalizeDestPath(destPath))
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("dest path %q escapes the package directory", destPath)
+	}
+	return filepath.Join(dir, rel), nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import "testing"
+
+func TestNormalizeDestPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "skills/a/SKILL.md", want: "skills/a/SKILL.md"},
+		{in: `skills\a\SKILL.md`, want: "skills/a/SKILL.md"},
+		{in: "skills//a///SKILL.md", want: "skills/a/SKILL.md"},
+		{in: "./skills/a.md", want: "skills/a.md"},
+		{in: `.\scripts\.\run.sh`, want: "scripts/run.sh"},
+		{in: "skills/a/", want: "skills/a"},
+		{in: "/etc/passwd", want: "/etc/passwd"},
+		{in: "skills/../a.md", want: "skills/../a.md"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDestPath(tt.in); got != tt.want {
+			t.Errorf("NormalizeDestPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDestPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "skills/a/SKILL.md"},
+		{in: `.\agents\b.md`},
+		{in: "..hidden/file"},
+		{in: "", wantErr: true},
+		{in: "./", wantErr: true},
+		{in: "/etc/passwd", wantErr: true},
+		{in: `\\server\share\x`, wantErr: true},
+		{in: `C:\Windows\x`, wantErr: true},
+		{in: "../a.md", wantErr: true},
+		{in: `skills\..\..\a.md`, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := ValidateDestPath(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDestPath(%q) = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBuildManifestNormalizesPaths(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0"}
+	files := []PackageFile{
+		{DestPath: `skills\b\SKILL.md`, FileType: FileTypeSkill},
+		{DestPath: "./skills/a/SKILL.md", FileType: FileTypeSkill},
+		{DestPath: ".claude-plugin//plugin.json", FileType: FileTypeConfig},
+	}
+	m, err := BuildManifest(pkg, files, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	skills := m.Artifacts["skills"]
+	if len(skills) != 2 || skills[0] != "skills/a/SKILL.md" || skills[1] != "skills/b/SKILL.md" {
+		t.Errorf("skills = %v, want normalized and sorted", skills)
+	}
+	if len(m.ConfigFiles) != 1 || m.ConfigFiles[0] != ".claude-plugin/plugin.json" {
+		t.Errorf("ConfigFiles = %v", m.ConfigFiles)
+	}
+
+	dup := append(files, PackageFile{DestPath: "skills/a/SKILL.md", FileType: FileTypeSkill})
+	if err := CheckDuplicatePaths(dup); err == nil {
+		t.Error("paths differing only in form should count as duplicates")
+	}
+}
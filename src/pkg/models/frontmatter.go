@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Frontmatter is the typed view of the YAML frontmatter stored (as JSON) in
+// package_files.frontmatter. It covers the fields Claude Code recognizes on
+// skills, agents, and commands; unknown keys are available through
+// PackageFile.ParsedFrontmatter.
+type Frontmatter struct {
+	Name         string          `json:"name,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Version      string          `json:"version,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	Tools        FrontmatterList `json:"tools,omitempty"`
+	AllowedTools FrontmatterList `json:"allowed-tools,omitempty"`
+}
+
+// FrontmatterList is a frontmatter value that authors write either as a YAML
+// list or as a comma-separated string (e.g. "tools: Read, Grep, Glob").
+// Both forms decode to the same slice.
+type FrontmatterList []string
+
+// UnmarshalJSON accepts a JSON array of strings or a single comma-separated string.
+func (l *FrontmatterList) UnmarshalJSON(data []byte) error {
+	var items []string
+	if err := json.Unmarshal(data, &items); err == nil {
+		*l = items
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("frontmatter list must be a string or array of strings: %w", err)
+	}
+	parts := strings.Split(s, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	*l = result
+	return nil
+}
+
+// hasFrontmatter reports whether the raw frontmatter column holds a value.
+// SQL NULL and JSON null are both treated as "no frontmatter".
+func (f PackageFile) hasFrontmatter() bool {
+	return len(f.Frontmatter) > 0 && string(f.Frontmatter) != "null"
+}
+
+// ParsedFrontmatter decodes the frontmatter JSON into a generic map.
+// Returns an empty map if the file has no frontmatter.
+func (f PackageFile) ParsedFrontmatter() (map[string]any, error) {
+	result := make(map[string]any)
+	if !f.hasFrontmatter() {
+		return result, nil
+	}
+	if err := json.Unmarshal(f.Frontmatter, &result); err != nil {
+		return nil, fmt.Errorf("parsing frontmatter of %q: %w", f.DestPath, err)
+	}
+	return result, nil
+}
+
+// TypedFrontmatter decodes the frontmatter JSON into a Frontmatter struct.
+// Returns a zero Frontmatter if the file has no frontmatter.
+func (f PackageFile) TypedFrontmatter() (Frontmatter, error) {
+	var fm Frontmatter
+	if !f.hasFrontmatter() {
+		return fm, nil
+	}
+	if err := json.Unmarshal(f.Frontmatter, &fm); err != nil {
+		return Frontmatter{}, fmt.Errorf("parsing frontmatter of %q: %w", f.DestPath, err)
+	}
+	return fm, nil
+}
+
+// FrontmatterConsistent checks that the promoted fm_* columns agree with the
+// embedded frontmatter JSON. A promoted column that is set must equal the
+// corresponding JSON field, and a JSON field that is set must have been
+// promoted. All disagreements are reported in a single error.
+func (f PackageFile) FrontmatterConsistent() error {
+	fm, err := f.TypedFrontmatter()
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		column   string
+		promoted *string
+		embedded string
+	}{
+		{"fm_name", f.FMName, fm.Name},
+		{"fm_description", f.FMDescription, fm.Description},
+		{"fm_version", f.FMVersion, fm.Version},
+		{"fm_model", f.FMModel, fm.Model},
+	}
+
+	var problems []string
+	for _, c := range checks {
+		promoted := ""
+		if c.promoted != nil {
+			promoted = *c.promoted
+		}
+		if promoted != c.embedded {
+			problems = append(problems, fmt.Sprintf("%s = %q but frontmatter has %q", c.column, promoted, c.embedded))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("frontmatter of %q is inconsistent: %s", f.DestPath, strings.Join(problems, "; "))
+	}
+	return nil
+}
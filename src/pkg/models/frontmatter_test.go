@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestPackageFileParsedFrontmatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		frontmatter json.RawMessage
+		wantKeys    int
+		wantErr     bool
+	}{
+		{
+			name:        "valid frontmatter",
+			frontmatter: json.RawMessage(`{"name":"worktree","description":"Manage worktrees","color":"blue"}`),
+			wantKeys:    3,
+		},
+		{
+			name:        "nil frontmatter",
+			frontmatter: nil,
+			wantKeys:    0,
+		},
+		{
+			name:        "json null",
+			frontmatter: json.RawMessage(`null`),
+			wantKeys:    0,
+		},
+		{
+			name:        "malformed json",
+			frontmatter: json.RawMessage(`{"name":`),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := PackageFile{DestPath: "skills/x/SKILL.md", Frontmatter: tt.frontmatter}
+			got, err := f.ParsedFrontmatter()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tt.wantKeys {
+				t.Errorf("got %d keys, want %d", len(got), tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestPackageFileTypedFrontmatter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("array and string tool lists", func(t *testing.T) {
+		t.Parallel()
+		f := PackageFile{Frontmatter: json.RawMessage(
+			`{"name":"scan","model":"sonnet","tools":"Read, Grep,Glob","allowed-tools":["Bash","Edit"]}`,
+		)}
+		fm, err := f.TypedFrontmatter()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fm.Name != "scan" {
+			t.Errorf("Name = %q, want %q", fm.Name, "scan")
+		}
+		if fm.Model != "sonnet" {
+			t.Errorf("Model = %q, want %q", fm.Model, "sonnet")
+		}
+		if strings.Join(fm.Tools, "|") != "Read|Grep|Glob" {
+			t.Errorf("Tools = %v, want [Read Grep Glob]", fm.Tools)
+		}
+		if strings.Join(fm.AllowedTools, "|") != "Bash|Edit" {
+			t.Errorf("AllowedTools = %v, want [Bash Edit]", fm.AllowedTools)
+		}
+	})
+
+	t.Run("empty frontmatter", func(t *testing.T) {
+		t.Parallel()
+		fm, err := PackageFile{}.TypedFrontmatter()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fm.Name != "" || len(fm.Tools) != 0 {
+			t.Errorf("expected zero Frontmatter, got %+v", fm)
+		}
+	})
+
+	t.Run("invalid tools type", func(t *testing.T) {
+		t.Parallel()
+		f := PackageFile{Frontmatter: json.RawMessage(`{"tools":42}`)}
+		if _, err := f.TypedFrontmatter(); err == nil {
+			t.Fatal("expected error for numeric tools value")
+		}
+	})
+}
+
+func TestPackageFileFrontmatterConsistent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consistent", func(t *testing.T) {
+		t.Parallel()
+		f := PackageFile{
+			DestPath:      "agents/scan.md",
+			Frontmatter:   json.RawMessage(`{"name":"scan","description":"Scan things","model":"sonnet"}`),
+			FMName:        strPtr("scan"),
+			FMDescription: strPtr("Scan things"),
+			FMModel:       strPtr("sonnet"),
+		}
+		if err := f.FrontmatterConsistent(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("empty frontmatter and no promoted columns", func(t *testing.T) {
+		t.Parallel()
+		f := PackageFile{DestPath: "scripts/run.py"}
+		if err := f.FrontmatterConsistent(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("conflicting name and missing version", func(t *testing.T) {
+		t.Parallel()
+		f := PackageFile{
+			DestPath:    "agents/scan.md",
+			Frontmatter: json.RawMessage(`{"name":"scan","version":"1.0.0"}`),
+			FMName:      strPtr("scanner"),
+		}
+		err := f.FrontmatterConsistent()
+		if err == nil {
+			t.Fatal("expected inconsistency error, got nil")
+		}
+		for _, want := range []string{"fm_name", "fm_version", "agents/scan.md"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should mention %q", err, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package models
+
+import "sort"
+
+// PlanHooks groups hooks by event and orders each group for execution:
+// ascending Priority, as the database's ORDER BY event, priority does, then
+// blocking before non-blocking so a failing gate short-circuits early, then
+// ScriptPath so the plan is deterministic. The input is not modified.
+func PlanHooks(hooks []PackageHook) map[HookEvent][]PackageHook {
+	plan := make(map[HookEvent][]PackageHook)
+	for _, h := range hooks {
+		plan[h.Event] = append(plan[h.Event], h)
+	}
+	for _, group := range plan {
+		sort.SliceStable(group, func(i, j int) bool {
+			a, b := group[i], group[j]
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			if a.Blocking != b.Blocking {
+				return a.Blocking
+			}
+			return a.ScriptPath < b.ScriptPath
+		})
+	}
+	return plan
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanHooks(t *testing.T) {
+	t.Parallel()
+
+	hooks := []PackageHook{
+		{Event: HookPostToolUse, ScriptPath: "hooks/z.py", Priority: 5},
+		{Event: HookPreToolUse, ScriptPath: "hooks/lint.py", Priority: 10},
+		{Event: HookPreToolUse, ScriptPath: "hooks/b.py", Priority: 1},
+		{Event: HookPreToolUse, ScriptPath: "hooks/gate.py", Priority: 10, Blocking: true},
+		{Event: HookPostToolUse, ScriptPath: "hooks/a.py", Priority: 5},
+		{Event: HookPreToolUse, ScriptPath: "hooks/audit.py", Priority: 10},
+	}
+
+	plan := PlanHooks(hooks)
+
+	tests := []struct {
+		event HookEvent
+		want  string
+	}{
+		{event: HookPreToolUse, want: "hooks/b.py hooks/gate.py hooks/audit.py hooks/lint.py"},
+		{event: HookPostToolUse, want: "hooks/a.py hooks/z.py"},
+	}
+	for _, tt := range tests {
+		paths := make([]string, len(plan[tt.event]))
+		for i, h := range plan[tt.event] {
+			paths[i] = h.ScriptPath
+		}
+		if got := strings.Join(paths, " "); got != tt.want {
+			t.Errorf("%s plan = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+	if len(plan) != 2 {
+		t.Errorf("got %d events, want 2", len(plan))
+	}
+	if hooks[0].ScriptPath != "hooks/z.py" {
+		t.Error("PlanHooks must not reorder its input")
+	}
+}
+
+func TestPlanHooksEmpty(t *testing.T) {
+	t.Parallel()
+	if plan := PlanHooks(nil); len(plan) != 0 {
+		t.Errorf("PlanHooks(nil) = %v, want empty", plan)
+	}
+}
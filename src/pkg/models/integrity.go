@@ -0,0 +1,82 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FileSHA256 returns the lowercase hex SHA-256 of data, the format stored in
+// package_files.sha256.
+func FileSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AggregateSHA256 computes the package-level SHA from the files' stored
+// per-file SHAs: the SHA-256 of the sorted "dest_path:sha256" lines joined
+// by newlines (see docs/synaptic-canvas-cli.md). The result does not depend
+// on the order of files.
+func AggregateSHA256(files []PackageFile) string {
+	lines := make([]string, 0, len(files))
+	for _, f := range files {
+		lines = append(lines, f.DestPath+":"+f.SHA256)
+	}
+	sort.Strings(lines)
+	return FileSHA256([]byte(strings.Join(lines, "\n")))
+}
+
+// CheckDuplicatePaths returns an error listing every dest_path that appears
+// on more than one file, comparing paths after NormalizeDestPath.
+// Duplicates are a data bug: only one of the files can be written, so the
+// other would be lost silently.
+func CheckDuplicatePaths(files []PackageFile) error {
+	seen := make(map[string]int, len(files))
+	var dups []string
+	for _, f := range files {
+		p := NormalizeDestPath(f.DestPath)
+		seen[p]++
+		if seen[p] == 2 {
+			dups = append(dups, p)
+		}
+	}
+	if len(dups) > 0 {
+		sort.Strings(dups)
+		return fmt.Errorf("duplicate dest_path: %s", strings.Join(dups, ", "))
+	}
+	return nil
+}
+
+// VerifyPackage is the integrity gate for installers. It recomputes the
+// SHA-256 of every non-template file from its decoded content and compares
+// it with the stored value, then recomputes the aggregate and compares it
+// with pkg.SHA256 when that is set. Template files are rendered at install
+// time, so only their stored source SHA contributes to the aggregate. All
+// mismatches are reported in a single error.
+func VerifyPackage(pkg *Package, files []PackageFile) error {
+	var problems []string
+	for _, f := range files {
+		if f.IsTemplate {
+			continue
+		}
+		data, err := f.DecodedContent()
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if got := FileSHA256(data); got != f.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s: sha256 is %s, stored %s", f.DestPath, got, f.SHA256))
+		}
+	}
+	if pkg.SHA256 != nil && *pkg.SHA256 != "" {
+		if got := AggregateSHA256(files); got != *pkg.SHA256 {
+			problems = append(problems, fmt.Sprintf("aggregate sha256 is %s, stored %s", got, *pkg.SHA256))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("package %q failed integrity check: %s", pkg.ID, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// verifyFixture returns a package whose stored SHAs all match its content.
+func verifyFixture() (*Package, []PackageFile) {
+	files := []PackageFile{
+		{DestPath: "skills/x/SKILL.md", Content: "# Skill\n", ContentType: ContentTypeMarkdown},
+		{DestPath: "scripts/run.py", Content: "print('hi')\n", ContentType: ContentTypePython},
+		{DestPath: "config/settings.json", Content: `{"a":{{.A}}}`, ContentType: ContentTypeJSON, IsTemplate: true},
+	}
+	for i := range files {
+		files[i].SHA256 = FileSHA256([]byte(files[i].Content))
+	}
+	return &Package{ID: "pkg-1", SHA256: strPtr(AggregateSHA256(files))}, files
+}
+
+func TestAggregateSHA256OrderIndependent(t *testing.T) {
+	t.Parallel()
+	_, files := verifyFixture()
+	reversed := []PackageFile{files[2], files[1], files[0]}
+	if AggregateSHA256(files) != AggregateSHA256(reversed) {
+		t.Error("aggregate SHA should not depend on file order")
+	}
+}
+
+func TestVerifyPackage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("clean package", func(t *testing.T) {
+		t.Parallel()
+		pkg, files := verifyFixture()
+		if err := VerifyPackage(pkg, files); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		t.Parallel()
+		pkg, files := verifyFixture()
+		files[1].Content = "print('tampered')\n"
+		err := VerifyPackage(pkg, files)
+		if err == nil {
+			t.Fatal("expected integrity error, got nil")
+		}
+		if !strings.Contains(err.Error(), "scripts/run.py") {
+			t.Errorf("error %q should name the corrupted file", err)
+		}
+		if strings.Contains(err.Error(), "aggregate") {
+			t.Errorf("stored SHAs are unchanged, aggregate should still match: %v", err)
+		}
+	})
+
+	t.Run("template content differs from source sha", func(t *testing.T) {
+		t.Parallel()
+		pkg, files := verifyFixture()
+		files[2].Content = `{"a":1}`
+		if err := VerifyPackage(pkg, files); err != nil {
+			t.Errorf("template content must not trip the check: %v", err)
+		}
+	})
+
+	t.Run("every mismatch reported", func(t *testing.T) {
+		t.Parallel()
+		pkg, files := verifyFixture()
+		files[0].Content = "changed"
+		files[1].SHA256 = "deadbeef"
+		err := VerifyPackage(pkg, files)
+		if err == nil {
+			t.Fatal("expected integrity error, got nil")
+		}
+		for _, want := range []string{"skills/x/SKILL.md", "scripts/run.py", "aggregate"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q should mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("binary content is decoded", func(t *testing.T) {
+		t.Parallel()
+		raw := []byte{0x89, 'P', 'N', 'G', 0x00}
+		f := PackageFile{DestPath: "assets/icon.png", ContentType: ContentTypeBinary, SHA256: FileSHA256(raw)}
+		f.EncodeContent(raw)
+		if err := VerifyPackage(&Package{ID: "pkg-bin"}, []PackageFile{f}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCheckDuplicatePaths(t *testing.T) {
+	t.Parallel()
+
+	files := []PackageFile{
+		{DestPath: "skills/a/SKILL.md"},
+		{DestPath: "scripts/run.sh"},
+		{DestPath: "skills/a/SKILL.md"},
+		{DestPath: "skills/a/SKILL.md"},
+	}
+	err := CheckDuplicatePaths(files)
+	if err == nil {
+		t.Fatal("expected error for a duplicated dest_path")
+	}
+	if !strings.Contains(err.Error(), "skills/a/SKILL.md") || strings.Count(err.Error(), "SKILL.md") != 1 {
+		t.Errorf("error should name the duplicate once: %v", err)
+	}
+	if err := CheckDuplicatePaths(files[:2]); err != nil {
+		t.Errorf("unexpected error for distinct paths: %v", err)
+	}
+
+	pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0"}
+	if _, err := BuildManifest(pkg, files, nil, nil, nil); err != nil {
+		t.Fatalf("the check must be opt-in: %v", err)
+	}
+	_, err = BuildManifestWithOptions(pkg, files, nil, nil, nil, BuildManifestOptions{CheckDuplicatePaths: true})
+	if err == nil || !strings.Contains(err.Error(), "skills/a/SKILL.md") {
+		t.Errorf("err = %v, want duplicate dest_path", err)
+	}
+}
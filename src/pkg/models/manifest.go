@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 )
 
@@ -37,7 +39,7 @@ type Manifest struct {
 	License          string              `json:"license,omitempty"`
 	Tags             []string            `json:"tags,omitempty"`
 	MinClaudeVersion string              `yaml:"min_claude_version,omitempty" json:"min_claude_version,omitempty"`
-	InstallScope     string              `json:"install_scope,omitempty"`
+	InstallScope     InstallScope        `json:"install_scope,omitempty"`
 	Variables        map[string]any      `json:"variables,omitempty"`
 	Options          map[string]any      `json:"options,omitempty"`
 	Artifacts        map[string][]string `json:"artifacts,omitempty"`
@@ -49,6 +51,11 @@ type Manifest struct {
 	// manifest.yaml output.
 	Hooks     []ManifestHook     `json:"hooks,omitempty"`
 	Questions []ManifestQuestion `json:"questions,omitempty"`
+
+	// ConfigFiles lists the dest paths of FileTypeConfig files, sorted. They
+	// are not manifest.yaml artifacts; the exporter writes them as
+	// plugin.json, so the field is excluded from serialization.
+	ConfigFiles []string `json:"-" yaml:"-"`
 }
 
 // ManifestHook is the hook entry within a manifest.
@@ -70,13 +77,234 @@ type ManifestQuestion struct {
 	SortOrder  int          `json:"sort_order"`
 }
 
+// FindArtifact returns the Artifacts key (e.g. "skills") of the group
+// listing destPath. destPath is compared after NormalizeDestPath.
+func (m *Manifest) FindArtifact(destPath string) (fileType string, ok bool) {
+	want := NormalizeDestPath(destPath)
+	for key, paths := range m.Artifacts {
+		for _, p := range paths {
+			if p == want {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// HasQuestion reports whether the manifest has a question with the given ID.
+func (m *Manifest) HasQuestion(id string) bool {
+	for _, q := range m.Questions {
+		if q.QuestionID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HookScripts returns the script paths of the manifest's hooks, sorted and
+// without duplicates.
+func (m *Manifest) HookScripts() []string {
+	seen := make(map[string]bool, len(m.Hooks))
+	scripts := make([]string, 0, len(m.Hooks))
+	for _, h := range m.Hooks {
+		if !seen[h.ScriptPath] {
+			seen[h.ScriptPath] = true
+			scripts = append(scripts, h.ScriptPath)
+		}
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// Clone returns a deep copy of m, so callers such as upgrade and merge logic
+// can modify it without aliasing m's slices and maps. Nested maps and slices
+// inside Variables and Options are copied as well. Clone of nil is nil.
+func (m *Manifest) Clone() *Manifest {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.Tags = slices.Clone(m.Tags)
+	c.Requires = slices.Clone(m.Requires)
+	c.ConfigFiles = slices.Clone(m.ConfigFiles)
+	c.Variables = cloneAnyMap(m.Variables)
+	c.Options = cloneAnyMap(m.Options)
+	c.Hooks = slices.Clone(m.Hooks)
+	if m.Artifacts != nil {
+		c.Artifacts = make(map[string][]string, len(m.Artifacts))
+		for key, paths := range m.Artifacts {
+			c.Artifacts[key] = slices.Clone(paths)
+		}
+	}
+	if m.Questions != nil {
+		c.Questions = make([]ManifestQuestion, len(m.Questions))
+		for i, q := range m.Questions {
+			q.Choices = slices.Clone(q.Choices)
+			c.Questions[i] = q
+		}
+	}
+	return &c
+}
+
+// cloneAnyMap deep-copies a map of decoded values, recursing into nested
+// maps and slices. Other values are copied as-is.
+func cloneAnyMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = cloneAny(v)
+	}
+	return c
+}
+
+// cloneAny deep-copies a single decoded value: maps and slices are copied
+// recursively and scalars are returned as-is.
+func cloneAny(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return cloneAnyMap(v)
+	case []any:
+		c := make([]any, len(v))
+		for i, item := range v {
+			c[i] = cloneAny(item)
+		}
+		return c
+	case []string:
+		return slices.Clone(v)
+	}
+	return v
+}
+
+// Validate checks a manifest, such as one read from a hand-edited
+// manifest.yaml, and reports every problem from Problems in a single error.
+func (m *Manifest) Validate() error {
+	if problems := m.Problems(); len(problems) > 0 {
+		return fmt.Errorf("manifest %q is invalid: %s", m.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Problems returns every problem found in the manifest, in field order:
+// name and version are set, version and min_claude_version are semantic
+// versions, the install scope is valid, artifacts are under known groups
+// with valid dest paths listed once, hooks reference a script listed in
+// the artifacts, and questions have unique IDs, known types, and choices
+// consistent with their type and default. It returns nil for a valid
+// manifest.
+func (m *Manifest) Problems() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(m.Name) == "" {
+		add("name is empty")
+	}
+	if strings.TrimSpace(m.Version) == "" {
+		add("version is empty")
+	} else if _, err := parseSemver(m.Version); err != nil {
+		add("%v", err)
+	}
+	if strings.TrimSpace(m.MinClaudeVersion) != "" {
+		if _, err := parseMinVersion(m.MinClaudeVersion); err != nil {
+			add("min_claude_version: %v", err)
+		}
+	}
+	if m.InstallScope != "" && !m.InstallScope.IsValid() {
+		add("invalid install scope %q", m.InstallScope)
+	}
+
+	groups := make(map[string]bool, len(fileTypePluralKey))
+	for _, key := range fileTypePluralKey {
+		groups[key] = true
+	}
+	listed := make(map[string]string)
+	for _, key := range sortedKeys(m.Artifacts) {
+		if !groups[key] {
+			add("unknown artifact group %q", key)
+		}
+		for _, p := range m.Artifacts[key] {
+			if err := ValidateDestPath(p); err != nil {
+				add("artifacts.%s: %v", key, err)
+				continue
+			}
+			norm := NormalizeDestPath(p)
+			if prev, ok := listed[norm]; ok {
+				add("artifact %q is listed more than once (in %s and %s)", norm, prev, key)
+				continue
+			}
+			listed[norm] = key
+		}
+	}
+
+	for i, h := range m.Hooks {
+		switch {
+		case h.Event == "":
+			add("hook %d: event is empty", i+1)
+		case strings.TrimSpace(h.ScriptPath) == "":
+			add("hook %d (%s): script_path is empty", i+1, h.Event)
+		case listed[NormalizeDestPath(h.ScriptPath)] == "":
+			add("hook %d (%s): script_path %q is not listed in artifacts", i+1, h.Event, h.ScriptPath)
+		}
+	}
+
+	seen := make(map[string]bool, len(m.Questions))
+	for _, q := range m.Questions {
+		if q.QuestionID == "" {
+			add("question with prompt %q has no question_id", q.Prompt)
+			continue
+		}
+		if seen[q.QuestionID] {
+			add("question %q is defined more than once", q.QuestionID)
+		}
+		seen[q.QuestionID] = true
+		problems = append(problems, questionProblems(q)...)
+	}
+	return problems
+}
+
+// questionProblems checks a question's type against its choices and
+// default value.
+func questionProblems(q ManifestQuestion) []string {
+	var problems []string
+	switch q.Type {
+	case QuestionChoice, QuestionMulti:
+		if len(q.Choices) == 0 {
+			return append(problems, fmt.Sprintf("question %q: %s question has no choices", q.QuestionID, q.Type))
+		}
+		if q.DefaultVal == "" {
+			return problems
+		}
+		defaults := []string{q.DefaultVal}
+		if q.Type == QuestionMulti {
+			defaults, _ = splitList(q.DefaultVal)
+		}
+		for _, d := range defaults {
+			if !slices.Contains(q.Choices, d) {
+				problems = append(problems, fmt.Sprintf("question %q: default %q is not one of its choices", q.QuestionID, d))
+			}
+		}
+	case QuestionText, QuestionConfirm, QuestionAuto:
+		if len(q.Choices) > 0 {
+			problems = append(problems, fmt.Sprintf("question %q: %s question cannot have choices", q.QuestionID, q.Type))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("question %q: invalid type %q", q.QuestionID, q.Type))
+	}
+	return problems
+}
+
 // BuildManifest reconstructs a Manifest from a Package and its related data.
 // The content of files is intentionally omitted from the manifest; the export
 // pipeline writes file content separately.
 //
 // Artifacts are grouped by pluralized file_type key (skills, agents, etc.)
 // and contain only dest_path strings, matching the export pipeline spec.
-// Tool dependencies are formatted into the Requires list.
+// Paths are normalized with NormalizeDestPath.
+// Tool dependencies are formatted into the Requires list. Artifact and
+// Requires lists are sorted so the output is canonical.
 // InstallScope is omitted if the value is "any".
 func BuildManifest(
 	pkg *Package,
@@ -84,6 +312,38 @@ func BuildManifest(
 	deps []PackageDep,
 	hooks []PackageHook,
 	questions []PackageQuestion,
+) (*Manifest, error) {
+	return BuildManifestWithOptions(pkg, files, deps, hooks, questions, BuildManifestOptions{})
+}
+
+// FromBundle is BuildManifest applied to the rows of b.
+func FromBundle(b *PackageBundle) (*Manifest, error) {
+	return BuildManifest(b.Package, b.Files, b.Deps, b.Hooks, b.Questions)
+}
+
+// BuildManifestOptions enables optional checks in BuildManifestWithOptions.
+type BuildManifestOptions struct {
+	// ValidateMinClaudeVersion rejects a MinClaudeVer that is not a valid
+	// semantic version, so typos fail at build time instead of install time.
+	ValidateMinClaudeVersion bool
+	// ValidateVariables checks the variables JSON with ValidateVariables
+	// against the package's questions.
+	ValidateVariables bool
+	// ValidateOptions checks the options JSON with ValidateOptions.
+	ValidateOptions bool
+	// CheckDuplicatePaths rejects files sharing a dest_path, using
+	// CheckDuplicatePaths.
+	CheckDuplicatePaths bool
+}
+
+// BuildManifestWithOptions is BuildManifest with the checks in opts applied.
+func BuildManifestWithOptions(
+	pkg *Package,
+	files []PackageFile,
+	deps []PackageDep,
+	hooks []PackageHook,
+	questions []PackageQuestion,
+	opts BuildManifestOptions,
 ) (*Manifest, error) {
 	if pkg == nil {
 		return nil, fmt.Errorf("building manifest: package is nil")
@@ -96,8 +356,8 @@ func BuildManifest(
 	}
 
 	// Omit InstallScope if "any" (per export pipeline spec).
-	if pkg.InstallScope != InstallScopeAny {
-		m.InstallScope = string(pkg.InstallScope)
+	if !pkg.InstallScope.OmitFromManifest() {
+		m.InstallScope = pkg.InstallScope
 	}
 
 	// Copy optional scalar fields.
@@ -113,9 +373,18 @@ func BuildManifest(
 	if pkg.MinClaudeVer != nil {
 		m.MinClaudeVersion = *pkg.MinClaudeVer
 	}
+	if opts.ValidateMinClaudeVersion && strings.TrimSpace(m.MinClaudeVersion) != "" {
+		if _, err := parseMinVersion(m.MinClaudeVersion); err != nil {
+			return nil, fmt.Errorf("building manifest: min_claude_version: %w", err)
+		}
+	}
 
-	// Split comma-separated tags.
-	m.Tags = pkg.TagsList()
+	// Split comma-separated (or JSON array) tags.
+	tags, err := pkg.TagsList()
+	if err != nil {
+		return nil, fmt.Errorf("building manifest: %w", err)
+	}
+	m.Tags = tags
 
 	// Parse JSON fields.
 	if len(pkg.Variables) > 0 && string(pkg.Variables) != "null" {
@@ -130,20 +399,36 @@ func BuildManifest(
 		}
 	}
 
+	if opts.CheckDuplicatePaths {
+		if err := CheckDuplicatePaths(files); err != nil {
+			return nil, fmt.Errorf("building manifest: %w", err)
+		}
+	}
+
 	// Group files into artifacts by pluralized file_type key.
 	// Artifacts contain only dest_path strings per the export pipeline spec.
-	// Files with FileTypeConfig are skipped (config files are handled
-	// separately as plugin.json in the export pipeline).
+	// Files with FileTypeConfig are collected into ConfigFiles instead
+	// (config files are written separately as plugin.json in the export
+	// pipeline).
 	if len(files) > 0 {
 		m.Artifacts = make(map[string][]string)
 		for _, f := range files {
+			if f.FileType == FileTypeConfig {
+				m.ConfigFiles = append(m.ConfigFiles, NormalizeDestPath(f.DestPath))
+				continue
+			}
 			key, ok := fileTypePluralKey[f.FileType]
 			if !ok {
 				// Skip file types not in the artifacts map (e.g. config).
 				continue
 			}
-			m.Artifacts[key] = append(m.Artifacts[key], f.DestPath)
+			m.Artifacts[key] = append(m.Artifacts[key], NormalizeDestPath(f.DestPath))
+		}
+		// Sort so the manifest does not depend on query row order.
+		for _, paths := range m.Artifacts {
+			sort.Strings(paths)
 		}
+		sort.Strings(m.ConfigFiles)
 	}
 
 	// Build requires list from tool dependencies.
@@ -151,13 +436,15 @@ func BuildManifest(
 	for _, d := range deps {
 		if d.DepType == DepTypeTool {
 			entry := d.DepName
-			spec := strings.TrimSpace(d.DepSpec)
-			if spec != "" {
-				entry += " " + spec
+			if d.DepSpec != nil {
+				if spec := strings.TrimSpace(*d.DepSpec); spec != "" {
+					entry += " " + spec
+				}
 			}
 			m.Requires = append(m.Requires, entry)
 		}
 	}
+	sort.Strings(m.Requires)
 
 	// Convert hooks.
 	m.Hooks = make([]ManifestHook, 0, len(hooks))
@@ -181,12 +468,26 @@ func BuildManifest(
 			DefaultVal: q.DefaultVal,
 			SortOrder:  q.SortOrder,
 		}
-		choices := q.ChoicesList()
+		choices, err := q.ChoicesList()
+		if err != nil {
+			return nil, fmt.Errorf("building manifest: %w", err)
+		}
 		if len(choices) > 0 {
 			mq.Choices = choices
 		}
 		m.Questions = append(m.Questions, mq)
 	}
 
+	if opts.ValidateVariables {
+		if err := ValidateVariables(pkg.Variables, m.Questions); err != nil {
+			return nil, fmt.Errorf("building manifest: %w", err)
+		}
+	}
+	if opts.ValidateOptions {
+		if err := ValidateOptions(pkg.Options); err != nil {
+			return nil, fmt.Errorf("building manifest: %w", err)
+		}
+	}
+
 	return m, nil
 }
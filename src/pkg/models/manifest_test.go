@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
 	"testing"
 )
 
@@ -73,6 +76,22 @@ func TestBuildManifestInstallScopeAnyOmitted(t *testing.T) {
 	}
 }
 
+func TestBuildManifestMalformedTags(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{
+		ID:           "pkg-bad-tags",
+		Name:         "test",
+		Version:      "1.0.0",
+		InstallScope: InstallScopeAny,
+		Tags:         `["go",`,
+	}
+
+	if _, err := BuildManifest(pkg, nil, nil, nil, nil); err == nil {
+		t.Fatal("expected error for malformed JSON tags, got nil")
+	}
+}
+
 func TestBuildManifestOptionalFields(t *testing.T) {
 	t.Parallel()
 
@@ -185,6 +204,40 @@ func TestBuildManifestWithFiles(t *testing.T) {
 	}
 }
 
+func TestBuildManifestConfigFiles(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0"}
+	files := []PackageFile{
+		{DestPath: "settings.json", FileType: FileTypeConfig},
+		{DestPath: "skills/a.md", FileType: FileTypeSkill},
+		{DestPath: "plugin.json", FileType: FileTypeConfig},
+	}
+
+	m, err := BuildManifest(pkg, files, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(m.ConfigFiles, " "); got != "plugin.json settings.json" {
+		t.Errorf("ConfigFiles = %q, want sorted config dest paths", got)
+	}
+	for key, paths := range m.Artifacts {
+		for _, p := range paths {
+			if strings.HasSuffix(p, ".json") {
+				t.Errorf("config file %q leaked into artifacts[%s]", p, key)
+			}
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), "plugin.json") {
+		t.Errorf("ConfigFiles should not be serialized, got %s", data)
+	}
+}
+
 func TestBuildManifestWithDeps(t *testing.T) {
 	t.Parallel()
 
@@ -196,9 +249,9 @@ func TestBuildManifestWithDeps(t *testing.T) {
 	}
 
 	deps := []PackageDep{
-		{PackageID: "pkg-1", DepType: DepTypeTool, DepName: "tool-x", DepSpec: ">=1.0.0"},
+		{PackageID: "pkg-1", DepType: DepTypeTool, DepName: "tool-x", DepSpec: strPtr(">=1.0.0")},
 		{PackageID: "pkg-1", DepType: DepTypeTool, DepName: "tool-y"},
-		{PackageID: "pkg-1", DepType: DepTypeCLI, DepName: "cli-z", DepSpec: "^2.0"},
+		{PackageID: "pkg-1", DepType: DepTypeCLI, DepName: "cli-z", DepSpec: strPtr("^2.0")},
 	}
 
 	m, err := BuildManifest(pkg, nil, deps, nil, nil)
@@ -218,6 +271,53 @@ func TestBuildManifestWithDeps(t *testing.T) {
 	}
 }
 
+func TestBuildManifestSortsArtifactsAndRequires(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0"}
+	files := []PackageFile{
+		{DestPath: "skills/zeta.md", FileType: FileTypeSkill},
+		{DestPath: "agents/b.md", FileType: FileTypeAgent},
+		{DestPath: "skills/alpha.md", FileType: FileTypeSkill},
+		{DestPath: "skills/mid/x.md", FileType: FileTypeSkill},
+		{DestPath: "agents/a.md", FileType: FileTypeAgent},
+	}
+	deps := []PackageDep{
+		{DepType: DepTypeTool, DepName: "yq"},
+		{DepType: DepTypeTool, DepName: "jq", DepSpec: strPtr(">=1.6")},
+		{DepType: DepTypeTool, DepName: "git"},
+	}
+
+	want, err := BuildManifest(pkg, files, deps, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(want.Artifacts["skills"], " "); got != "skills/alpha.md skills/mid/x.md skills/zeta.md" {
+		t.Errorf("skills = %q, want sorted", got)
+	}
+	if got := strings.Join(want.Artifacts["agents"], " "); got != "agents/a.md agents/b.md" {
+		t.Errorf("agents = %q, want sorted", got)
+	}
+	if got := strings.Join(want.Requires, ","); got != "git,jq >=1.6,yq" {
+		t.Errorf("requires = %q, want sorted", got)
+	}
+
+	// Any input order must produce the same manifest.
+	wantJSON, _ := json.Marshal(want)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 10 {
+		rng.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+		rng.Shuffle(len(deps), func(i, j int) { deps[i], deps[j] = deps[j], deps[i] })
+		got, err := BuildManifest(pkg, files, deps, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotJSON, _ := json.Marshal(got); string(gotJSON) != string(wantJSON) {
+			t.Fatalf("shuffled input changed manifest:\n got %s\nwant %s", gotJSON, wantJSON)
+		}
+	}
+}
+
 func TestBuildManifestWithHooks(t *testing.T) {
 	t.Parallel()
 
@@ -358,7 +458,7 @@ func TestBuildManifestFullIntegration(t *testing.T) {
 	}
 
 	deps := []PackageDep{
-		{PackageID: "full-pkg", DepType: DepTypeTool, DepName: "node", DepSpec: "^1.0"},
+		{PackageID: "full-pkg", DepType: DepTypeTool, DepName: "node", DepSpec: strPtr("^1.0")},
 	}
 
 	hooks := []PackageHook{
@@ -411,3 +511,190 @@ func TestBuildManifestFullIntegration(t *testing.T) {
 		t.Errorf("Questions[0].DefaultVal = %q, want %q", m.Questions[0].DefaultVal, "default")
 	}
 }
+
+func TestBuildManifestMalformedChoices(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0"}
+	questions := []PackageQuestion{{QuestionID: "mode", Type: QuestionChoice, Choices: `["fast"`}}
+	if _, err := BuildManifest(pkg, nil, nil, nil, questions); err == nil || !strings.Contains(err.Error(), `"mode"`) {
+		t.Errorf("err = %v, want malformed choices error", err)
+	}
+}
+
+func TestManifestAccessors(t *testing.T) {
+	t.Parallel()
+
+	m := &Manifest{
+		Artifacts: map[string][]string{
+			"skills":  {"skills/a/SKILL.md", "skills/b/SKILL.md"},
+			"agents":  {"agents/reviewer.md"},
+			"scripts": {"scripts/lint.sh"},
+		},
+		Questions: []ManifestQuestion{{QuestionID: "style"}, {QuestionID: "lang"}},
+		Hooks: []ManifestHook{
+			{Event: HookPreToolUse, ScriptPath: "scripts/lint.sh"},
+			{Event: HookPostToolUse, ScriptPath: "hooks/format.sh"},
+			{Event: HookPostToolUse, ScriptPath: "scripts/lint.sh"},
+		},
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{path: "skills/b/SKILL.md", want: "skills", wantOK: true},
+		{path: "agents/reviewer.md", want: "agents", wantOK: true},
+		{path: `./scripts\lint.sh`, want: "scripts", wantOK: true},
+		{path: "skills/c/SKILL.md"},
+		{path: "agents"},
+	}
+	for _, tt := range tests {
+		got, ok := m.FindArtifact(tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("FindArtifact(%q) = %q, %v; want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if !m.HasQuestion("lang") || m.HasQuestion("scope") {
+		t.Error("HasQuestion gave the wrong answer")
+	}
+	if got := m.HookScripts(); fmt.Sprint(got) != "[hooks/format.sh scripts/lint.sh]" {
+		t.Errorf("HookScripts() = %v", got)
+	}
+	if got := (&Manifest{}).HookScripts(); got == nil || len(got) != 0 {
+		t.Errorf("HookScripts() on an empty manifest = %#v, want empty", got)
+	}
+}
+
+func TestManifestProblems(t *testing.T) {
+	t.Parallel()
+
+	valid := func() *Manifest {
+		return &Manifest{
+			Name:      "demo",
+			Version:   "1.0.0",
+			Artifacts: map[string][]string{"hooks": {"hooks/pre.sh"}, "skills": {"skills/a/SKILL.md"}},
+			Hooks:     []ManifestHook{{Event: HookPreToolUse, ScriptPath: "./hooks/pre.sh"}},
+			Questions: []ManifestQuestion{
+				{QuestionID: "style", Type: QuestionChoice, Choices: []string{"a", "b"}, DefaultVal: "b"},
+				{QuestionID: "scopes", Type: QuestionMulti, Choices: []string{"x", "y"}, DefaultVal: "x,y"},
+				{QuestionID: "name", Type: QuestionText},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(m *Manifest)
+		want   string
+	}{
+		{name: "valid"},
+		{name: "empty name", mutate: func(m *Manifest) { m.Name = " " }, want: "name is empty"},
+		{name: "bad version", mutate: func(m *Manifest) { m.Version = "one" }, want: `invalid version "one"`},
+		{name: "bad min version", mutate: func(m *Manifest) { m.MinClaudeVersion = "latest" }, want: "min_claude_version: invalid version"},
+		{name: "invalid scope", mutate: func(m *Manifest) { m.InstallScope = "global" }, want: `invalid install scope "global"`},
+		{name: "unknown group", mutate: func(m *Manifest) { m.Artifacts["widgets"] = []string{"w.md"} }, want: `unknown artifact group "widgets"`},
+		{
+			name:   "duplicate artifact",
+			mutate: func(m *Manifest) { m.Artifacts["skills"] = append(m.Artifacts["skills"], "hooks/pre.sh") },
+			want:   `artifact "hooks/pre.sh" is listed more than once`,
+		},
+		{name: "unsafe path", mutate: func(m *Manifest) { m.Artifacts["skills"] = []string{"../x.md"} }, want: "artifacts.skills:"},
+		{
+			name:   "dangling hook",
+			mutate: func(m *Manifest) { m.Hooks[0].ScriptPath = "hooks/missing.sh" },
+			want:   `hook 1 (PreToolUse): script_path "hooks/missing.sh" is not listed in artifacts`,
+		},
+		{name: "duplicate question", mutate: func(m *Manifest) { m.Questions[2].QuestionID = "style" }, want: `question "style" is defined more than once`},
+		{name: "choice without choices", mutate: func(m *Manifest) { m.Questions[0].Choices = nil }, want: "choice question has no choices"},
+		{name: "default not a choice", mutate: func(m *Manifest) { m.Questions[1].DefaultVal = "x,z" }, want: `default "z" is not one of its choices`},
+		{name: "text with choices", mutate: func(m *Manifest) { m.Questions[2].Choices = []string{"a"} }, want: "text question cannot have choices"},
+		{name: "invalid type", mutate: func(m *Manifest) { m.Questions[2].Type = "slider" }, want: `invalid type "slider"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := valid()
+			if tt.mutate != nil {
+				tt.mutate(m)
+			}
+			problems := m.Problems()
+			if tt.want == "" {
+				if len(problems) != 0 || m.Validate() != nil {
+					t.Errorf("valid manifest has problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("problems = %q, want one containing %q", problems, tt.want)
+			}
+			if err := m.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want it to report %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestClone(t *testing.T) {
+	t.Parallel()
+
+	orig := &Manifest{
+		Name:      "pkg",
+		Tags:      []string{"go"},
+		Requires:  []string{"dep"},
+		Artifacts: map[string][]string{"skills": {"skills/a.md"}},
+		Variables: map[string]any{"style": "terse", "nested": map[string]any{"k": "v"}},
+		Options:   map[string]any{"list": []any{"a"}},
+		Hooks:     []ManifestHook{{Event: HookPreToolUse, ScriptPath: "scripts/h.sh"}},
+		Questions: []ManifestQuestion{{QuestionID: "q", Choices: []string{"x", "y"}}},
+	}
+	c := orig.Clone()
+
+	c.Artifacts["skills"][0] = "skills/changed.md"
+	c.Artifacts["skills"] = append(c.Artifacts["skills"], "skills/b.md")
+	c.Variables["style"] = "verbose"
+	c.Variables["nested"].(map[string]any)["k"] = "changed"
+	c.Options["list"].([]any)[0] = "changed"
+	c.Tags[0] = "changed"
+	c.Requires[0] = "changed"
+	c.Hooks[0].ScriptPath = "changed"
+	c.Questions[0].Choices[0] = "changed"
+
+	if got := orig.Artifacts["skills"]; len(got) != 1 || got[0] != "skills/a.md" {
+		t.Errorf("original artifacts changed: %v", got)
+	}
+	if orig.Variables["style"] != "terse" || orig.Variables["nested"].(map[string]any)["k"] != "v" {
+		t.Errorf("original variables changed: %v", orig.Variables)
+	}
+	if orig.Options["list"].([]any)[0] != "a" {
+		t.Errorf("original options changed: %v", orig.Options)
+	}
+	if orig.Tags[0] != "go" || orig.Requires[0] != "dep" || orig.Hooks[0].ScriptPath != "scripts/h.sh" || orig.Questions[0].Choices[0] != "x" {
+		t.Errorf("original slices changed: %+v", orig)
+	}
+
+	if (*Manifest)(nil).Clone() != nil {
+		t.Error("Clone of nil should be nil")
+	}
+}
+
+func TestFromBundle(t *testing.T) {
+	t.Parallel()
+
+	b := &PackageBundle{
+		Package: &Package{ID: "pkg-1", Name: "test", Version: "1.0.0", InstallScope: InstallScopeAny},
+		Files:   []PackageFile{{PackageID: "pkg-1", DestPath: "skills/a.md", FileType: FileTypeSkill, Content: "# A\n"}},
+	}
+	m, err := FromBundle(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.ID != "pkg-1" || len(m.Artifacts["skills"]) != 1 {
+		t.Errorf("manifest = %+v, want pkg-1 with one skill", m)
+	}
+	if _, err := FromBundle(&PackageBundle{}); err == nil {
+		t.Error("a bundle without a package should fail")
+	}
+}
@@ -4,8 +4,12 @@
 package models
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // InstallScope enumerates the allowed values for packages.install_scope.
@@ -21,6 +25,56 @@ func (s InstallScope) IsValid() bool {
 	return s == InstallScopeAny || s == InstallScopeLocalOnly
 }
 
+// OmitFromManifest reports whether the scope is left out of manifest.yaml.
+// "any" is the default, so the export pipeline spec omits it; an empty scope
+// is the column default and is treated the same way.
+func (s InstallScope) OmitFromManifest() bool {
+	return s == InstallScopeAny || s == ""
+}
+
+// ParseInstallScope converts user input such as a --scope flag into an
+// InstallScope. Surrounding whitespace and case are ignored, and an empty
+// string yields the column default, InstallScopeAny.
+func ParseInstallScope(s string) (InstallScope, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return InstallScopeAny, nil
+	}
+	scope := InstallScope(s)
+	if !scope.IsValid() {
+		return "", fmt.Errorf("invalid install scope %q: must be %q or %q", s, InstallScopeAny, InstallScopeLocalOnly)
+	}
+	return scope, nil
+}
+
+// ClaudeDir is the directory, within a project or the home directory, that
+// packages install into.
+const ClaudeDir = ".claude"
+
+// ResolveDestRoot returns the directory a package with scope installs into.
+// A non-empty projectDir selects a local install into projectDir/.claude. An
+// empty projectDir selects a global install into homeDir/.claude, which
+// InstallScopeAny packages allow and InstallScopeLocalOnly packages reject.
+// An empty scope is the column default, InstallScopeAny.
+func ResolveDestRoot(scope InstallScope, projectDir, homeDir string) (string, error) {
+	if scope == "" {
+		scope = InstallScopeAny
+	}
+	if !scope.IsValid() {
+		return "", fmt.Errorf("resolving install root: unknown install scope %q", scope)
+	}
+	if projectDir != "" {
+		return filepath.Join(projectDir, ClaudeDir), nil
+	}
+	if scope == InstallScopeLocalOnly {
+		return "", fmt.Errorf("resolving install root: %s packages need a project directory", scope)
+	}
+	if homeDir == "" {
+		return "", fmt.Errorf("resolving install root: no home directory for a global install")
+	}
+	return filepath.Join(homeDir, ClaudeDir), nil
+}
+
 // Package represents a row in the packages table.
 type Package struct {
 	ID           string          `json:"id"`
@@ -36,15 +90,40 @@ type Package struct {
 	Options      json.RawMessage `json:"options,omitempty"`
 	SHA256       *string         `json:"sha256,omitempty"`
 	MinClaudeVer *string         `json:"min_claude_version,omitempty"`
+	// CreatedAt and UpdatedAt are the row's timestamps. They are zero when
+	// the columns are NULL.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// TagsList splits the tags field into a string slice. Most rows store a
+// comma-separated string, but some imports store a JSON array; a value
+// starting with "[" is parsed as JSON. Whitespace is trimmed and empty tags
+// are dropped in both forms. Returns an empty slice if tags is empty, and an
+// error only if a JSON array fails to parse.
+func (p *Package) TagsList() ([]string, error) {
+	tags, err := splitList(p.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("parsing tags of package %q: %w", p.ID, err)
+	}
+	return tags, nil
 }
 
-// TagsList splits the comma-separated tags field into a string slice.
-// Returns an empty slice if tags is empty.
-func (p *Package) TagsList() []string {
-	if p.Tags == "" {
-		return []string{}
+// splitList parses a list column stored either comma-separated or as a JSON
+// array, trimming entries and dropping empty ones. An empty value or JSON
+// null yields an empty slice.
+func splitList(s string) ([]string, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" || raw == "null" {
+		return []string{}, nil
+	}
+	parts := strings.Split(raw, ",")
+	if strings.HasPrefix(raw, "[") {
+		parts = nil
+		if err := json.Unmarshal([]byte(raw), &parts); err != nil {
+			return nil, err
+		}
 	}
-	parts := strings.Split(p.Tags, ",")
 	result := make([]string, 0, len(parts))
 	for _, t := range parts {
 		t = strings.TrimSpace(t)
@@ -52,7 +131,47 @@ func (p *Package) TagsList() []string {
 			result = append(result, t)
 		}
 	}
-	return result
+	return result, nil
+}
+
+// Validate checks that a package row is usable: ID, Name, and Version are
+// set, Version is a semantic version, InstallScope is valid (empty is the
+// column default), Variables and Options are well-formed JSON, and Tags
+// parses. Every problem found is reported in a single error.
+func (p *Package) Validate() error {
+	var problems []string
+	for _, f := range []struct{ name, value string }{
+		{"id", p.ID}, {"name", p.Name}, {"version", p.Version},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			problems = append(problems, f.name+" is empty")
+		}
+	}
+	if strings.TrimSpace(p.Version) != "" {
+		if _, err := parseSemver(p.Version); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if p.InstallScope != "" && !p.InstallScope.IsValid() {
+		problems = append(problems, fmt.Sprintf("invalid install scope %q", p.InstallScope))
+	}
+	for _, f := range []struct {
+		name string
+		raw  json.RawMessage
+	}{
+		{"variables", p.Variables}, {"options", p.Options},
+	} {
+		if len(f.raw) > 0 && !json.Valid(f.raw) {
+			problems = append(problems, f.name+" is not valid JSON")
+		}
+	}
+	if _, err := p.TagsList(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("package %q is invalid: %s", p.ID, strings.Join(problems, "; "))
+	}
+	return nil
 }
 
 // FileType enumerates the allowed values for package_files.file_type.
@@ -76,6 +195,9 @@ const (
 	ContentTypeJSON     ContentType = "json"
 	ContentTypeYAML     ContentType = "yaml"
 	ContentTypeText     ContentType = "text"
+	// ContentTypeBinary marks non-text assets (images, compiled snippets).
+	// Their content column holds standard base64 rather than raw bytes.
+	ContentTypeBinary ContentType = "binary"
 )
 
 // PackageFile represents a row in the package_files table.
@@ -94,6 +216,31 @@ type PackageFile struct {
 	FMModel       *string         `json:"fm_model,omitempty"`
 }
 
+// DecodedContent returns the file's raw bytes. Binary files are stored
+// base64-encoded in the content column and are decoded here; all other
+// content types are returned as-is. Exporters must write these bytes rather
+// than Content so that binary assets survive the round trip.
+func (f PackageFile) DecodedContent() ([]byte, error) {
+	if f.ContentType != ContentTypeBinary {
+		return []byte(f.Content), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(f.Content)
+	if err != nil {
+		return nil, fmt.Errorf("decoding binary content of %q: %w", f.DestPath, err)
+	}
+	return data, nil
+}
+
+// EncodeContent sets Content from raw file bytes, base64-encoding them when
+// ContentType is binary. Importers should set ContentType before calling it.
+func (f *PackageFile) EncodeContent(data []byte) {
+	if f.ContentType == ContentTypeBinary {
+		f.Content = base64.StdEncoding.EncodeToString(data)
+		return
+	}
+	f.Content = string(data)
+}
+
 // DepType enumerates the allowed values for package_deps.dep_type.
 type DepType string
 
@@ -108,9 +255,9 @@ type PackageDep struct {
 	PackageID  string  `json:"package_id"`
 	DepType    DepType `json:"dep_type"`
 	DepName    string  `json:"dep_name"`
-	DepSpec    string  `json:"dep_spec,omitempty"`
-	InstallCmd string  `json:"install_cmd,omitempty"`
-	CmdSHA256  string  `json:"cmd_sha256,omitempty"`
+	DepSpec    *string `json:"dep_spec,omitempty"`
+	InstallCmd *string `json:"install_cmd,omitempty"`
+	CmdSHA256  *string `json:"cmd_sha256,omitempty"`
 }
 
 // PackageVariant represents a row in the package_variants table.
@@ -162,19 +309,47 @@ type PackageQuestion struct {
 	SortOrder  int          `json:"sort_order"`
 }
 
-// ChoicesList splits the comma-separated choices field into a string slice.
-// Returns an empty slice if choices is empty.
-func (q *PackageQuestion) ChoicesList() []string {
-	if q.Choices == "" {
-		return []string{}
+// ChoicesList splits the choices field into a string slice. Like tags,
+// choices are usually comma-separated, but a value starting with "[" is
+// parsed as a JSON array. Returns an empty slice if choices is empty or
+// null, and an error only if a JSON array fails to parse.
+func (q *PackageQuestion) ChoicesList() ([]string, error) {
+	choices, err := splitList(q.Choices)
+	if err != nil {
+		return nil, fmt.Errorf("parsing choices of question %q: %w", q.QuestionID, err)
 	}
-	parts := strings.Split(q.Choices, ",")
-	result := make([]string, 0, len(parts))
-	for _, c := range parts {
+	return choices, nil
+}
+
+// NormalizedChoices is ChoicesList for display in a picker: besides
+// trimming and dropping empty entries, choices that differ only in case or
+// in runs of inner whitespace are reduced to the first one seen, keeping
+// first-seen order. ChoicesList itself returns the choices as stored.
+func (q *PackageQuestion) NormalizedChoices() ([]string, error) {
+	choices, err := q.ChoicesList()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(choices))
+	result := make([]string, 0, len(choices))
+	for _, c := range choices {
 		c = strings.TrimSpace(c)
-		if c != "" {
-			result = append(result, c)
+		key := strings.ToLower(strings.Join(strings.Fields(c), " "))
+		if key == "" || seen[key] {
+			continue
 		}
+		seen[key] = true
+		result = append(result, c)
 	}
-	return result
+	return result, nil
+}
+
+// PackageBundle is a package together with the rows of its related tables.
+// Unlike a Manifest it keeps the rows as stored, including file Content.
+type PackageBundle struct {
+	Package   *Package          `json:"package"`
+	Files     []PackageFile     `json:"files"`
+	Deps      []PackageDep      `json:"deps"`
+	Hooks     []PackageHook     `json:"hooks"`
+	Questions []PackageQuestion `json:"questions"`
 }
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -9,9 +12,10 @@ func TestPackageTagsList(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name string
-		tags string
-		want []string
+		name    string
+		tags    string
+		want    []string
+		wantErr bool
 	}{
 		{
 			name: "multiple tags",
@@ -38,13 +42,42 @@ func TestPackageTagsList(t *testing.T) {
 			tags: "go,cli,",
 			want: []string{"go", "cli"},
 		},
+		{
+			name: "json array",
+			tags: `["go","cli","tool"]`,
+			want: []string{"go", "cli", "tool"},
+		},
+		{
+			name: "json array with whitespace and empties",
+			tags: ` [" go ", "", "cli"] `,
+			want: []string{"go", "cli"},
+		},
+		{
+			name: "empty json array",
+			tags: `[]`,
+			want: []string{},
+		},
+		{
+			name:    "malformed json array",
+			tags:    `["go", "cli"`,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			p := &Package{Tags: tt.tags}
-			got := p.TagsList()
+			got, err := p.TagsList()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if len(got) != len(tt.want) {
 				t.Fatalf("got %d tags, want %d", len(got), len(tt.want))
 			}
@@ -64,6 +97,7 @@ func TestPackageQuestionChoicesList(t *testing.T) {
 		name    string
 		choices string
 		want    []string
+		wantErr bool
 	}{
 		{
 			name:    "valid choices",
@@ -85,15 +119,44 @@ func TestPackageQuestionChoicesList(t *testing.T) {
 			choices: "fast , slow , medium",
 			want:    []string{"fast", "slow", "medium"},
 		},
+		{
+			name:    "json array",
+			choices: `["fast", " slow ", "a, b"]`,
+			want:    []string{"fast", "slow", "a, b"},
+		},
+		{
+			name:    "json null",
+			choices: "null",
+			want:    []string{},
+		},
+		{
+			name:    "empty json array",
+			choices: "[]",
+			want:    []string{},
+		},
+		{
+			name:    "malformed json array",
+			choices: `["fast",`,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			q := &PackageQuestion{Choices: tt.choices}
-			got := q.ChoicesList()
-			if len(got) != len(tt.want) {
-				t.Fatalf("got %d choices, want %d", len(got), len(tt.want))
+			q := &PackageQuestion{QuestionID: "mode", Choices: tt.choices}
+			got, err := q.ChoicesList()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), `"mode"`) {
+					t.Fatalf("err = %v, want error naming the question", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil || len(got) != len(tt.want) {
+				t.Fatalf("got %#v, want %v", got, tt.want)
 			}
 			for i, choice := range got {
 				if choice != tt.want[i] {
@@ -104,6 +167,45 @@ func TestPackageQuestionChoicesList(t *testing.T) {
 	}
 }
 
+func TestPackageQuestionNormalizedChoices(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		choices string
+		want    []string
+	}{
+		{"no duplicates", "fast,slow", []string{"fast", "slow"}},
+		{"exact duplicates", "fast,slow,fast", []string{"fast", "slow"}},
+		{"mixed case keeps first spelling", "Fast,slow,FAST,fast", []string{"Fast", "slow"}},
+		{"whitespace variants", `["fast mode", " Fast  Mode ", "", "  "]`, []string{"fast mode"}},
+		{"empty", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			q := &PackageQuestion{QuestionID: "mode", Choices: tt.choices}
+			got, err := q.NormalizedChoices()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// ChoicesList stays verbatim.
+	q := &PackageQuestion{QuestionID: "mode", Choices: "Fast,fast"}
+	if got, _ := q.ChoicesList(); len(got) != 2 {
+		t.Errorf("ChoicesList = %v, want both entries", got)
+	}
+	q.Choices = `["fast",`
+	if _, err := q.NormalizedChoices(); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
 func TestFileTypeConstants(t *testing.T) {
 	t.Parallel()
 
@@ -137,6 +239,7 @@ func TestContentTypeConstants(t *testing.T) {
 		ContentTypeJSON:     "json",
 		ContentTypeYAML:     "yaml",
 		ContentTypeText:     "text",
+		ContentTypeBinary:   "binary",
 	}
 
 	for ct, want := range expected {
@@ -252,3 +355,201 @@ func TestInstallScopeIsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestParseInstallScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    InstallScope
+		wantErr bool
+	}{
+		{input: "any", want: InstallScopeAny},
+		{input: "local-only", want: InstallScopeLocalOnly},
+		{input: "  Local-Only ", want: InstallScopeLocalOnly},
+		{input: "", want: InstallScopeAny},
+		{input: "global", wantErr: true},
+		{input: "local", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseInstallScope(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseInstallScope(%q) expected error, got %q", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseInstallScope(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseInstallScope(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDestRoot(t *testing.T) {
+	t.Parallel()
+
+	project := filepath.Join("work", "repo")
+	home := filepath.Join("home", "me")
+
+	tests := []struct {
+		name       string
+		scope      InstallScope
+		projectDir string
+		homeDir    string
+		want       string
+		wantErr    bool
+	}{
+		{name: "local-only in project", scope: InstallScopeLocalOnly, projectDir: project, homeDir: home, want: filepath.Join(project, ".claude")},
+		{name: "any defaults to project", scope: InstallScopeAny, projectDir: project, homeDir: home, want: filepath.Join(project, ".claude")},
+		{name: "any overridden to global", scope: InstallScopeAny, homeDir: home, want: filepath.Join(home, ".claude")},
+		{name: "empty scope is any", scope: "", homeDir: home, want: filepath.Join(home, ".claude")},
+		{name: "local-only cannot be global", scope: InstallScopeLocalOnly, homeDir: home, wantErr: true},
+		{name: "global without home", scope: InstallScopeAny, wantErr: true},
+		{name: "unknown scope", scope: "global", projectDir: project, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ResolveDestRoot(tt.scope, tt.projectDir, tt.homeDir)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveDestRoot = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallScopeOmitFromManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scope InstallScope
+		want  bool
+	}{
+		{InstallScopeAny, true},
+		{"", true},
+		{InstallScopeLocalOnly, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.scope.OmitFromManifest(); got != tt.want {
+			t.Errorf("InstallScope(%q).OmitFromManifest() = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestPackageFileBinaryContentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// PNG signature followed by bytes that are not valid UTF-8.
+	raw := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff, 0xfe, 0x80}
+
+	f := PackageFile{DestPath: "assets/logo.png", ContentType: ContentTypeBinary}
+	f.EncodeContent(raw)
+	if f.Content == string(raw) {
+		t.Fatal("binary content should be base64-encoded, got raw bytes")
+	}
+
+	got, err := f.DecodedContent()
+	if err != nil {
+		t.Fatalf("DecodedContent failed: %v", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("round trip = %v, want %v", got, raw)
+	}
+}
+
+func TestPackageFileTextContentPassthrough(t *testing.T) {
+	t.Parallel()
+
+	f := PackageFile{DestPath: "agents/a.md", ContentType: ContentTypeMarkdown}
+	f.EncodeContent([]byte("# Agent\n"))
+	if f.Content != "# Agent\n" {
+		t.Errorf("Content = %q, want unencoded text", f.Content)
+	}
+
+	got, err := f.DecodedContent()
+	if err != nil {
+		t.Fatalf("DecodedContent failed: %v", err)
+	}
+	if string(got) != "# Agent\n" {
+		t.Errorf("DecodedContent = %q, want %q", got, "# Agent\n")
+	}
+}
+
+func TestPackageFileDecodedContentInvalidBase64(t *testing.T) {
+	t.Parallel()
+
+	f := PackageFile{DestPath: "assets/bad.bin", ContentType: ContentTypeBinary, Content: "not base64!"}
+	if _, err := f.DecodedContent(); err == nil {
+		t.Fatal("expected error for malformed base64 content")
+	}
+}
+
+func TestPackageValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() *Package {
+		return &Package{
+			ID:           "pkg-1",
+			Name:         "demo",
+			Version:      "1.2.3",
+			Tags:         "go,cli",
+			InstallScope: InstallScopeAny,
+			Variables:    json.RawMessage(`{"a":{"auto":"git"}}`),
+			Options:      json.RawMessage(`null`),
+		}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("valid package rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *Package)
+		want   []string
+	}{
+		{name: "empty id", mutate: func(p *Package) { p.ID = "" }, want: []string{"id is empty"}},
+		{name: "blank name", mutate: func(p *Package) { p.Name = "  " }, want: []string{"name is empty"}},
+		{name: "empty version", mutate: func(p *Package) { p.Version = "" }, want: []string{"version is empty"}},
+		{name: "bad version", mutate: func(p *Package) { p.Version = "one" }, want: []string{`invalid version "one"`}},
+		{name: "bad scope", mutate: func(p *Package) { p.InstallScope = "global" }, want: []string{`invalid install scope "global"`}},
+		{name: "bad variables", mutate: func(p *Package) { p.Variables = json.RawMessage(`{"a":`) }, want: []string{"variables is not valid JSON"}},
+		{name: "bad options", mutate: func(p *Package) { p.Options = json.RawMessage(`[1,`) }, want: []string{"options is not valid JSON"}},
+		{name: "bad tags", mutate: func(p *Package) { p.Tags = `["go",` }, want: []string{"parsing tags"}},
+		{
+			name:   "aggregated",
+			mutate: func(p *Package) { p.Name = ""; p.InstallScope = "global" },
+			want:   []string{"name is empty", "invalid install scope"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := valid()
+			tt.mutate(p)
+			err := p.Validate()
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q should contain %q", err, w)
+				}
+			}
+		})
+	}
+}
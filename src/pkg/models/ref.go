@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ref is a package reference given on the command line, in one of two
+// forms: "name" or "name@version" sets Name and, optionally, Version;
+// "logical:profile" names a variant and sets LogicalID and Profile.
+type Ref struct {
+	Name    string
+	Version string
+
+	LogicalID string
+	Profile   string
+}
+
+// IsVariant reports whether r is the logical:profile form.
+func (r Ref) IsVariant() bool {
+	return r.LogicalID != ""
+}
+
+// String returns r in the form it was parsed from.
+func (r Ref) String() string {
+	switch {
+	case r.IsVariant():
+		return r.LogicalID + ":" + r.Profile
+	case r.Version != "":
+		return r.Name + "@" + r.Version
+	}
+	return r.Name
+}
+
+// ParseRef parses a package reference: "name", "name@version", or
+// "logical:profile". Surrounding whitespace is ignored. Every part must be
+// non-empty and free of whitespace, a version must be a semantic version,
+// and the two forms cannot be combined.
+func ParseRef(s string) (Ref, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Ref{}, fmt.Errorf("empty package reference")
+	}
+	if strings.ContainsAny(s, " \t\n") {
+		return Ref{}, fmt.Errorf("invalid package reference %q: contains whitespace", s)
+	}
+	if strings.Contains(s, "@") && strings.Contains(s, ":") {
+		return Ref{}, fmt.Errorf("invalid package reference %q: use name@version or logical:profile, not both", s)
+	}
+
+	if logical, profile, ok := strings.Cut(s, ":"); ok {
+		if logical == "" || profile == "" || strings.Contains(profile, ":") {
+			return Ref{}, fmt.Errorf("invalid variant reference %q: want logical:profile", s)
+		}
+		return Ref{LogicalID: logical, Profile: profile}, nil
+	}
+
+	name, version, ok := strings.Cut(s, "@")
+	if !ok {
+		return Ref{Name: s}, nil
+	}
+	if name == "" {
+		return Ref{}, fmt.Errorf("invalid package reference %q: missing name before @", s)
+	}
+	if version == "" {
+		return Ref{}, fmt.Errorf("invalid package reference %q: missing version after @", s)
+	}
+	if _, err := parseSemver(version); err != nil {
+		return Ref{}, fmt.Errorf("invalid package reference %q: %w", s, err)
+	}
+	return Ref{Name: name, Version: version}, nil
+}
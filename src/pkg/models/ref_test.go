@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    Ref
+		wantErr bool
+	}{
+		{in: "commit-msg", want: Ref{Name: "commit-msg"}},
+		{in: " commit-msg ", want: Ref{Name: "commit-msg"}},
+		{in: "commit-msg@1.2.3", want: Ref{Name: "commit-msg", Version: "1.2.3"}},
+		{in: "commit-msg@v2.0.0-rc.1", want: Ref{Name: "commit-msg", Version: "v2.0.0-rc.1"}},
+		{in: "reviewer:claude-code", want: Ref{LogicalID: "reviewer", Profile: "claude-code"}},
+		{in: "", wantErr: true},
+		{in: "name@", wantErr: true},
+		{in: "@1.0.0", wantErr: true},
+		{in: "name@latest", wantErr: true},
+		{in: "name@1.0@2.0", wantErr: true},
+		{in: "logical:", wantErr: true},
+		{in: ":profile", wantErr: true},
+		{in: "a:b:c", wantErr: true},
+		{in: "name@1.0:codex", wantErr: true},
+		{in: "two words", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseRef(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRef(%q) = %+v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRef(%q) failed: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			if got.IsVariant() != (tt.want.LogicalID != "") {
+				t.Errorf("IsVariant() = %v", got.IsVariant())
+			}
+			if want := strings.TrimSpace(tt.in); got.String() != want {
+				t.Errorf("String() = %q, want %q", got.String(), want)
+			}
+		})
+	}
+}
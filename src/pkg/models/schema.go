@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect ManifestJSONSchema declares.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums lists the allowed values of the enumerated string types that
+// appear in a Manifest. Extend it when a new enumerated field is added.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeFor[InstallScope](): {string(InstallScopeAny), string(InstallScopeLocalOnly)},
+	reflect.TypeFor[HookEvent]():    {string(HookPreToolUse), string(HookPostToolUse)},
+	reflect.TypeFor[QuestionType](): {string(QuestionChoice), string(QuestionMulti), string(QuestionText), string(QuestionConfirm), string(QuestionAuto)},
+}
+
+// ManifestJSONSchema returns a draft-07 JSON Schema for the JSON form of
+// Manifest, for tools that generate manifests. It is derived from the
+// struct by reflection, so it follows the json tags: fields without
+// omitempty are required, and unknown fields are rejected. Artifacts keys
+// are limited to the file type groups (skills, agents, ...).
+func ManifestJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeFor[Manifest]())
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = "Synaptic Canvas package manifest"
+
+	groups := make([]string, 0, len(fileTypePluralKey))
+	for _, key := range fileTypePluralKey {
+		groups = append(groups, key)
+	}
+	sort.Strings(groups)
+	artifacts := schema["properties"].(map[string]any)["artifacts"].(map[string]any)
+	artifacts["propertyNames"] = map[string]any{"enum": groups}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema returns the JSON Schema for values of type t.
+func typeSchema(t reflect.Type) map[string]any {
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	// Interfaces such as the values of Variables accept any JSON value.
+	return map[string]any{}
+}
+
+// structSchema returns the object schema for struct type t from its
+// exported, JSON-serialized fields.
+func structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any, t.NumField())
+	required := []string{}
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, optional, ok := jsonField(sf)
+		if !ok {
+			continue
+		}
+		props[name] = typeSchema(sf.Type)
+		if !optional {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// jsonField returns the JSON name of sf and whether it is omitempty. ok is
+// false for unexported fields and fields tagged "-".
+func jsonField(sf reflect.StructField) (name string, omitempty, ok bool) {
+	if !sf.IsExported() {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
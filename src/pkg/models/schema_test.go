@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestManifestJSONSchemaCoversFields(t *testing.T) {
+	t.Parallel()
+
+	data, err := ManifestJSONSchema()
+	if err != nil {
+		t.Fatalf("ManifestJSONSchema failed: %v", err)
+	}
+	var schema struct {
+		Schema     string                     `json:"$schema"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	if schema.Schema != jsonSchemaDraft {
+		t.Errorf("$schema = %q, want %q", schema.Schema, jsonSchemaDraft)
+	}
+
+	mt := reflect.TypeFor[Manifest]()
+	for i := range mt.NumField() {
+		name, _, ok := jsonField(mt.Field(i))
+		if !ok {
+			if _, found := schema.Properties[mt.Field(i).Name]; found {
+				t.Errorf("unserialized field %s appears in the schema", mt.Field(i).Name)
+			}
+			continue
+		}
+		if _, found := schema.Properties[name]; !found {
+			t.Errorf("field %s (%q) is missing from the schema", mt.Field(i).Name, name)
+		}
+	}
+	if _, found := schema.Properties["ConfigFiles"]; found {
+		t.Error("ConfigFiles is not serialized and should not be in the schema")
+	}
+	for _, want := range []string{"id", "name", "version"} {
+		if !slices.Contains(schema.Required, want) {
+			t.Errorf("required = %v, missing %q", schema.Required, want)
+		}
+	}
+}
+
+func TestManifestJSONSchemaEnums(t *testing.T) {
+	t.Parallel()
+
+	data, err := ManifestJSONSchema()
+	if err != nil {
+		t.Fatalf("ManifestJSONSchema failed: %v", err)
+	}
+	var schema struct {
+		Properties struct {
+			InstallScope struct {
+				Enum []string `json:"enum"`
+			} `json:"install_scope"`
+			Artifacts struct {
+				PropertyNames struct {
+					Enum []string `json:"enum"`
+				} `json:"propertyNames"`
+			} `json:"artifacts"`
+			Hooks struct {
+				Items struct {
+					Properties struct {
+						Event struct {
+							Enum []string `json:"enum"`
+						} `json:"event"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"hooks"`
+			Questions struct {
+				Items struct {
+					Properties struct {
+						Type struct {
+							Enum []string `json:"enum"`
+						} `json:"type"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"questions"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	p := schema.Properties
+	checks := []struct {
+		name string
+		got  []string
+		want string
+	}{
+		{"install_scope", p.InstallScope.Enum, string(InstallScopeLocalOnly)},
+		{"artifacts keys", p.Artifacts.PropertyNames.Enum, "skills"},
+		{"hook event", p.Hooks.Items.Properties.Event.Enum, string(HookPreToolUse)},
+		{"question type", p.Questions.Items.Properties.Type.Enum, string(QuestionMulti)},
+	}
+	for _, c := range checks {
+		if !slices.Contains(c.got, c.want) {
+			t.Errorf("%s enum = %v, want it to include %q", c.name, c.got, c.want)
+		}
+	}
+}
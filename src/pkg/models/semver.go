@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed MAJOR[.MINOR[.PATCH]][-PRERELEASE] version. Omitted
+// components are zero and build metadata ("+...") is ignored.
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseMinVersion parses a min_claude_version value. The schema stores a
+// bare minimum such as "1.0.32"; a leading ">=" or "v" is accepted since
+// authors write both.
+func parseMinVersion(s string) (semver, error) {
+	v := strings.TrimSpace(s)
+	v = strings.TrimSpace(strings.TrimPrefix(v, ">="))
+	return parseSemver(v)
+}
+
+// parseSemver parses s, allowing a leading "v".
+func parseSemver(s string) (semver, error) {
+	v := strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var sv semver
+	v, sv.pre, _ = strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return semver{}, fmt.Errorf("invalid version %q: too many components", s)
+	}
+	nums := []*int{&sv.major, &sv.minor, &sv.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p == "" || (len(p) > 1 && p[0] == '0') {
+			return semver{}, fmt.Errorf("invalid version %q: component %q is not a number", s, p)
+		}
+		*nums[i] = n
+	}
+	return sv, nil
+}
+
+// compare returns -1, 0, or 1 as v is less than, equal to, or greater than o.
+// A prerelease sorts before its release; prereleases compare as strings.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	}
+	return strings.Compare(v.pre, o.pre)
+}
+
+// SatisfiedByClaude reports whether a Claude version meets the manifest's
+// MinClaudeVersion. Only the leading token of version is parsed, so the raw
+// output of "claude --version" (e.g. "1.0.33 (Claude Code)") is accepted.
+// A manifest without a minimum is satisfied by any version.
+func (m *Manifest) SatisfiedByClaude(version string) (bool, error) {
+	if strings.TrimSpace(m.MinClaudeVersion) == "" {
+		return true, nil
+	}
+	minVer, err := parseMinVersion(m.MinClaudeVersion)
+	if err != nil {
+		return false, fmt.Errorf("min_claude_version: %w", err)
+	}
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return false, fmt.Errorf("claude version is empty")
+	}
+	have, err := parseSemver(fields[0])
+	if err != nil {
+		return false, fmt.Errorf("claude version: %w", err)
+	}
+	return have.compare(minVer) >= 0, nil
+}
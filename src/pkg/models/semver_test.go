@@ -0,0 +1,115 @@
+package models
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    semver
+		wantErr bool
+	}{
+		{input: "1.0.32", want: semver{major: 1, patch: 32}},
+		{input: "v2.1", want: semver{major: 2, minor: 1}},
+		{input: "3", want: semver{major: 3}},
+		{input: "1.2.3-beta.1+build5", want: semver{major: 1, minor: 2, patch: 3, pre: "beta.1"}},
+		{input: "v1.x", wantErr: true},
+		{input: "1.2.3.4", wantErr: true},
+		{input: "01.2", wantErr: true},
+		{input: "1..2", wantErr: true},
+		{input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSemver(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSemver(%q) expected error, got %+v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSemver(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSemver(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBuildManifestValidatesMinClaudeVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		min      string
+		validate bool
+		wantErr  bool
+	}{
+		{name: "valid", min: "1.0.32", validate: true},
+		{name: "valid constraint", min: ">= 1.0.32", validate: true},
+		{name: "malformed", min: "v1.x", validate: true, wantErr: true},
+		{name: "malformed without validation", min: "v1.x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pkg := &Package{ID: "pkg-1", Name: "test", Version: "1.0.0", MinClaudeVer: strPtr(tt.min)}
+			m, err := BuildManifestWithOptions(pkg, nil, nil, nil, nil, BuildManifestOptions{ValidateMinClaudeVersion: tt.validate})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error for malformed min_claude_version")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.MinClaudeVersion != tt.min {
+				t.Errorf("MinClaudeVersion = %q, want %q", m.MinClaudeVersion, tt.min)
+			}
+		})
+	}
+}
+
+func TestManifestSatisfiedByClaude(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		min     string
+		version string
+		want    bool
+		wantErr bool
+	}{
+		{name: "no minimum", min: "", version: "0.1.0", want: true},
+		{name: "equal", min: "1.0.32", version: "1.0.32", want: true},
+		{name: "newer", min: "1.0.32", version: "1.1.0 (Claude Code)", want: true},
+		{name: "older", min: "1.0.32", version: "1.0.9", want: false},
+		{name: "prerelease of minimum", min: "1.0.32", version: "1.0.32-rc.1", want: false},
+		{name: "constraint prefix", min: ">=2", version: "v2.0.0", want: true},
+		{name: "malformed minimum", min: "v1.x", version: "1.0.0", wantErr: true},
+		{name: "malformed version", min: "1.0.0", version: "latest", wantErr: true},
+		{name: "empty version", min: "1.0.0", version: " ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := &Manifest{MinClaudeVersion: tt.min}
+			got, err := m.SatisfiedByClaude(tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SatisfiedByClaude(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "sort"
+
+// CatalogStats summarizes a set of packages for dashboards such as sc stats.
+type CatalogStats struct {
+	TotalPackages int            `json:"total_packages"`
+	ByScope       map[string]int `json:"by_scope"`
+	FilesByType   map[string]int `json:"files_by_type"`
+	TopTags       []TagCount     `json:"top_tags"`
+}
+
+// TagCount is the number of packages carrying a tag.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TopTags ranks tag counts by descending count, breaking ties by tag name,
+// and returns at most limit entries. A limit <= 0 returns every tag.
+func TopTags(counts map[string]int, limit int) []TagCount {
+	result := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		result = append(result, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestTopTags(t *testing.T) {
+	t.Parallel()
+	counts := map[string]int{"go": 3, "cli": 1, "ai": 3, "git": 2}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []TagCount
+	}{
+		{
+			name:  "limited",
+			limit: 2,
+			want:  []TagCount{{"ai", 3}, {"go", 3}},
+		},
+		{
+			name:  "unlimited",
+			limit: 0,
+			want:  []TagCount{{"ai", 3}, {"go", 3}, {"git", 2}, {"cli", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := TopTags(counts, tt.limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tags, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("TopTags[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+// StatusEntry is a row of Dolt's dolt_status system table: a table with
+// uncommitted changes in the working set or staging area.
+type StatusEntry struct {
+	TableName string `json:"table_name"`
+	// Staged is true for changes added to the staging area.
+	Staged bool `json:"staged"`
+	// Status describes the change, e.g. "modified", "new table", "deleted".
+	Status string `json:"status"`
+}
+
+// Conflict is a row of Dolt's dolt_conflicts system table: a table left
+// with unresolved merge conflicts.
+type Conflict struct {
+	Table        string `json:"table"`
+	NumConflicts int    `json:"num_conflicts"`
+}
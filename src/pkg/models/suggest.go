@@ -0,0 +1,78 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// ClosestNames returns up to max candidates closest to target by
+// case-insensitive Levenshtein distance, nearest first and alphabetically
+// among equals. Candidates further than a third of target's length (at
+// least two edits) are dropped, so a wildly different target returns none.
+// Only a candidate identical to target is skipped, so an ID differing only
+// in case is still suggested. It backs "did you mean" suggestions.
+func ClosestNames(target string, candidates []string, max int) []string {
+	if max <= 0 || target == "" {
+		return nil
+	}
+	lower := strings.ToLower(target)
+	threshold := len([]rune(lower)) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	type match struct {
+		name string
+		dist int
+	}
+	var matches []match
+	seen := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		d := levenshtein(lower, strings.ToLower(c))
+		if c == target || d > threshold {
+			continue
+		}
+		matches = append(matches, match{c, d})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	if len(matches) > max {
+		matches = matches[:max]
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.name
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b, counting runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
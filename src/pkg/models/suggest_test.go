@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClosestNames(t *testing.T) {
+	t.Parallel()
+
+	candidates := []string{"commit-msg", "sc-manage", "claude-history", "commit-msgs", "git-tools"}
+	tests := []struct {
+		name   string
+		target string
+		max    int
+		want   string
+	}{
+		{name: "near miss", target: "comit-msg", max: 3, want: "[commit-msg commit-msgs]"},
+		{name: "case insensitive", target: "SC-Manage", max: 3, want: "[sc-manage]"},
+		{name: "case only", target: "SC-MANAGE", max: 3, want: "[sc-manage]"},
+		{name: "limited", target: "comit-msg", max: 1, want: "[commit-msg]"},
+		{name: "wildly different", target: "zzzzzzzz", max: 3, want: "[]"},
+		{name: "exact match excluded", target: "git-tools", max: 3, want: "[]"},
+		{name: "zero max", target: "comit-msg", max: 0, want: "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := fmt.Sprint(ClosestNames(tt.target, candidates, tt.max)); got != tt.want {
+				t.Errorf("ClosestNames(%q) = %s, want %s", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UninstallPlan returns the absolute paths of the files m's package placed
+// under destRoot: every artifact path and every config file, joined with
+// JoinDestPath so a bad dest path cannot point outside destRoot. Paths are
+// unique and sorted deepest first, then by name, so directories can be
+// pruned as they empty. Side effects of hooks are not covered.
+func UninstallPlan(m *Manifest, destRoot string) ([]string, error) {
+	if m == nil {
+		return nil, fmt.Errorf("uninstall plan: nil manifest")
+	}
+	root, err := filepath.Abs(destRoot)
+	if err != nil {
+		return nil, fmt.Errorf("resolving install root %q: %w", destRoot, err)
+	}
+
+	seen := make(map[string]bool)
+	var plan []string
+	add := func(destPath string) error {
+		p, err := JoinDestPath(root, destPath)
+		if err != nil {
+			return fmt.Errorf("package %q: %w", m.Name, err)
+		}
+		if !seen[p] {
+			seen[p] = true
+			plan = append(plan, p)
+		}
+		return nil
+	}
+	for _, paths := range m.Artifacts {
+		for _, p := range paths {
+			if err := add(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, p := range m.ConfigFiles {
+		if err := add(p); err != nil {
+			return nil, err
+		}
+	}
+
+	depth := func(p string) int { return strings.Count(p, string(filepath.Separator)) }
+	sort.Slice(plan, func(i, j int) bool {
+		if di, dj := depth(plan[i]), depth(plan[j]); di != dj {
+			return di > dj
+		}
+		return plan[i] < plan[j]
+	})
+	return plan, nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUninstallPlan(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	m := &Manifest{
+		Name: "demo",
+		Artifacts: map[string][]string{
+			"skills":   {"skills/demo/SKILL.md", "skills/demo/refs/deep.md"},
+			"commands": {"commands/demo.md"},
+			"scripts":  {"scripts/run.sh", "./scripts/run.sh"},
+		},
+		ConfigFiles: []string{"plugin.json"},
+	}
+
+	plan, err := UninstallPlan(m, root)
+	if err != nil {
+		t.Fatalf("UninstallPlan failed: %v", err)
+	}
+	var rel []string
+	for _, p := range plan {
+		r, err := filepath.Rel(root, p)
+		if err != nil || !filepath.IsAbs(p) {
+			t.Fatalf("path %q is not absolute under %q", p, root)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	want := []string{
+		"skills/demo/refs/deep.md",
+		"skills/demo/SKILL.md",
+		"commands/demo.md",
+		"scripts/run.sh",
+		"plugin.json",
+	}
+	if !slices.Equal(rel, want) {
+		t.Errorf("plan =\n%s\nwant\n%s", strings.Join(rel, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUninstallPlanRejectsEscapingPath(t *testing.T) {
+	t.Parallel()
+
+	m := &Manifest{Name: "evil", Artifacts: map[string][]string{"scripts": {"../outside.sh"}}}
+	if _, err := UninstallPlan(m, t.TempDir()); err == nil {
+		t.Error("expected an error for a path escaping the install root")
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// optionKeys are the keys an option definition in packages.options may set,
+// e.g. {"no-tracking": {"type": "boolean", "default": false}}.
+var optionKeys = map[string]bool{
+	"type":        true,
+	"default":     true,
+	"description": true,
+}
+
+// optionTypes are the value types an option may declare.
+var optionTypes = map[string]bool{
+	"boolean": true,
+	"string":  true,
+}
+
+// ValidateVariables checks the packages.variables JSON. It must be an
+// object, and each key must either be the ID of one of questions, so the
+// install answer supplies it, or declare its own source with an "auto" key,
+// as in {"REPO_NAME": {"auto": "git-repo-basename"}}. An empty or null value
+// is valid. The error names the first offending key.
+func ValidateVariables(raw json.RawMessage, questions []ManifestQuestion) error {
+	vars, err := decodeObject("variables", raw)
+	if err != nil || vars == nil {
+		return err
+	}
+	known := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		known[q.QuestionID] = true
+	}
+	for _, key := range sortedKeys(vars) {
+		if known[key] {
+			continue
+		}
+		var def map[string]json.RawMessage
+		if json.Unmarshal(vars[key], &def) == nil && def["auto"] != nil {
+			continue
+		}
+		return fmt.Errorf("variables: %q matches no question and declares no auto source", key)
+	}
+	return nil
+}
+
+// ValidateOptions checks the packages.options JSON. It must be an object
+// whose values are option definitions using only the keys type, default,
+// and description, with type boolean or string. An empty or null value is
+// valid. The error names the first offending key.
+func ValidateOptions(raw json.RawMessage) error {
+	opts, err := decodeObject("options", raw)
+	if err != nil || opts == nil {
+		return err
+	}
+	for _, name := range sortedKeys(opts) {
+		var def map[string]json.RawMessage
+		if err := json.Unmarshal(opts[name], &def); err != nil || def == nil {
+			return fmt.Errorf("options: %q must be an object", name)
+		}
+		for _, key := range sortedKeys(def) {
+			if !optionKeys[key] {
+				return fmt.Errorf("options: %q has unknown key %q", name, key)
+			}
+		}
+		if t, ok := def["type"]; ok {
+			var typ string
+			if err := json.Unmarshal(t, &typ); err != nil || !optionTypes[typ] {
+				return fmt.Errorf("options: %q has invalid type %s", name, t)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeObject decodes raw as a JSON object. It returns nil for an empty or
+// null value and an error naming field for any other non-object.
+func decodeObject(field string, raw json.RawMessage) (map[string]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return nil, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &obj); err != nil {
+		return nil, fmt.Errorf("%s: must be a JSON object: %w", field, err)
+	}
+	return obj, nil
+}
+
+// sortedKeys returns the keys of m in sorted order, so that output and
+// error messages built from a map are deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateVariables(t *testing.T) {
+	t.Parallel()
+
+	questions := []ManifestQuestion{{QuestionID: "style"}, {QuestionID: "lang"}}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "matching questions", raw: `{"style": {}, "lang": {"description": "Languages"}}`},
+		{name: "auto source", raw: `{"REPO_NAME": {"auto": "git-repo-basename"}}`},
+		{name: "empty", raw: ``},
+		{name: "null", raw: `null`},
+		{name: "unknown key", raw: `{"style": {}, "stlye": {}}`, wantErr: `"stlye"`},
+		{name: "non-object", raw: `["style"]`, wantErr: "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateVariables(json.RawMessage(tt.raw), questions)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "valid", raw: `{"no-tracking": {"type": "boolean", "default": false, "description": "Disable tracking"}}`},
+		{name: "null", raw: `null`},
+		{name: "unknown key", raw: `{"no-tracking": {"type": "boolean", "defualt": false}}`, wantErr: `"defualt"`},
+		{name: "bad type", raw: `{"level": {"type": "integer"}}`, wantErr: `"level"`},
+		{name: "definition not object", raw: `{"no-tracking": true}`, wantErr: `"no-tracking"`},
+		{name: "non-object", raw: `"no-tracking"`, wantErr: "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateOptions(json.RawMessage(tt.raw))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildManifestValidatesVariablesAndOptions(t *testing.T) {
+	t.Parallel()
+
+	pkg := &Package{
+		ID:        "pkg-1",
+		Name:      "test",
+		Version:   "1.0.0",
+		Variables: json.RawMessage(`{"style": {}}`),
+		Options:   json.RawMessage(`{"x": {"kind": "boolean"}}`),
+	}
+	questions := []PackageQuestion{{QuestionID: "style", Type: QuestionChoice}}
+
+	if _, err := BuildManifest(pkg, nil, nil, nil, questions); err != nil {
+		t.Fatalf("validation must be opt-in: %v", err)
+	}
+	opts := BuildManifestOptions{ValidateVariables: true}
+	if _, err := BuildManifestWithOptions(pkg, nil, nil, nil, questions, opts); err != nil {
+		t.Fatalf("variables match the questions: %v", err)
+	}
+	if _, err := BuildManifestWithOptions(pkg, nil, nil, nil, nil, opts); err == nil {
+		t.Error("expected error for a variable with no question")
+	}
+	opts = BuildManifestOptions{ValidateOptions: true}
+	if _, err := BuildManifestWithOptions(pkg, nil, nil, nil, questions, opts); err == nil || !strings.Contains(err.Error(), `"kind"`) {
+		t.Errorf("err = %v, want unknown option key", err)
+	}
+}
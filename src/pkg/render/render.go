@@ -0,0 +1,356 @@
+// Package render fills install-time templates (package files with
+// is_template set) from the variables and question answers gathered by the
+// installer. Templates use Go text/template syntax plus these functions:
+//
+//	lower STRING            lower-cases STRING
+//	upper STRING            upper-cases STRING
+//	trim STRING             strips leading and trailing white space
+//	replace OLD NEW STRING  replaces every OLD in STRING with NEW
+//	default FALLBACK VALUE  VALUE, or FALLBACK if VALUE is empty or missing
+//
+// All take their last argument from a pipeline, as in
+// {{ .answers.style | default "conventional" | upper }}. The set is kept
+// small on purpose; a template calling any other function fails to parse.
+// A reference passed to default may name a key the data lacks; anywhere
+// else that is an error.
+//
+// Variables may also be written in the Jinja style used by the install
+// docs, without the leading dot: a bare name that is not a function is read
+// as a field of the data wherever a value can appear, so {{ repo.name }},
+// {{ answers.style | upper }}, and {{ if eq answers.style "x" }} all work.
+// Jinja blocks such as {% if %} and filter calls such as join(", ") are not
+// supported.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// SyntaxError reports a template that cannot be parsed. It is the package
+// author's fault, not the user's.
+type SyntaxError struct {
+	// Name is the template name, usually the file's dest path.
+	Name string
+	Err  error
+}
+
+// Error implements error.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("template %s is malformed: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// MissingVarError reports a variable the template references but the data
+// does not define, typically a question the user did not answer. For a
+// nested reference such as .answers.style, Name is the last key, "style".
+type MissingVarError struct {
+	// Template is the template name.
+	Template string
+	Name     string
+}
+
+// Error implements error.
+func (e *MissingVarError) Error() string {
+	return fmt.Sprintf("template %s references undefined variable %q", e.Template, e.Name)
+}
+
+// missingKeyPattern extracts the key from the error text/template raises
+// under missingkey=error. text/template has no callback for missing keys,
+// so the name is recovered from its ExecError.
+var missingKeyPattern = regexp.MustCompile(`map has no entry for key "([^"]*)"`)
+
+// builtins are the functions text/template predefines. A bare name that is
+// neither one of these nor in funcs is read as a variable.
+var builtins = map[string]bool{
+	"and": true, "or": true, "not": true, "len": true, "index": true, "slice": true,
+	"print": true, "printf": true, "println": true, "html": true, "js": true,
+	"urlquery": true, "call": true, "eq": true, "ne": true, "lt": true, "le": true,
+	"gt": true, "ge": true,
+}
+
+// funcs are the functions available to templates, documented in the
+// package comment.
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"replace": func(old, replacement, s string) string {
+		return strings.ReplaceAll(s, old, replacement)
+	},
+	"default": defaultValue,
+}
+
+// lookupFunc is the name under which lookup is available to templates. It
+// is not in funcs, so a template cannot call it directly.
+const lookupFunc = "_lookup"
+
+// lookup returns the value at keys under v, or nil if a key is absent. It
+// stands in for the references passed to default, which missingkey=error
+// would otherwise reject before default could fall back.
+func lookup(v any, keys ...string) (any, error) {
+	for _, key := range keys {
+		rv := reflect.ValueOf(v)
+		switch {
+		case !rv.IsValid():
+			return nil, nil
+		case rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String:
+			return nil, fmt.Errorf("can't evaluate field %s in type %T", key, v)
+		}
+		e := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+		if !e.IsValid() {
+			return nil, nil
+		}
+		v = e.Interface()
+	}
+	return v, nil
+}
+
+// defaultValue returns value, or fallback if value is nil or the zero value
+// of a string, bool, or number.
+func defaultValue(fallback, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return fallback
+	case string:
+		if v == "" {
+			return fallback
+		}
+	case bool:
+		if !v {
+			return fallback
+		}
+	case int:
+		if v == 0 {
+			return fallback
+		}
+	case float64:
+		if v == 0 {
+			return fallback
+		}
+	}
+	return value
+}
+
+// Render executes the template text named name against data. A parse
+// failure returns a *SyntaxError and a reference to a key absent from data,
+// other than one passed to default, returns a *MissingVarError; test for
+// them with errors.As. A call to a
+// function outside the package's set is a parse failure. Jinja-style
+// references such as {{ repo.name }} are accepted; see the package comment.
+func Render(name, text string, data map[string]any) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", &SyntaxError{Name: name, Err: err}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		var execErr template.ExecError
+		if errors.As(err, &execErr) {
+			if m := missingKeyPattern.FindStringSubmatch(execErr.Err.Error()); m != nil {
+				return "", &MissingVarError{Template: name, Name: m[1]}
+			}
+		}
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// parseTemplate parses text as the template name. The parse itself skips
+// the function check so that bare names survive; bareNames then sorts them
+// into function calls and variable references on the parse tree.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil, err
+	}
+	if trees[name] == nil {
+		trees[name] = tree
+	}
+
+	tmpl := template.New(name).Option("missingkey=error").Funcs(funcs).
+		Funcs(template.FuncMap{lookupFunc: lookup})
+	for n, t := range trees {
+		if err := bareNames(t, t.Root); err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.AddParseTree(n, t); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// isFunc reports whether name is a function a template may call.
+func isFunc(name string) bool {
+	return funcs[name] != nil || builtins[name]
+}
+
+// bareNames rewrites the bare names under node that are not functions into
+// field references, so {{ repo.name }} executes as {{ .repo.name }}. A bare
+// name in a position that can only be a call, such as the stage of a
+// pipeline or the head of a command with arguments, must be a function.
+func bareNames(t *parse.Tree, node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := bareNames(t, child); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return bareNamesPipe(t, n.Pipe)
+	case *parse.IfNode:
+		return bareNamesBranch(t, &n.BranchNode)
+	case *parse.RangeNode:
+		return bareNamesBranch(t, &n.BranchNode)
+	case *parse.WithNode:
+		return bareNamesBranch(t, &n.BranchNode)
+	case *parse.TemplateNode:
+		return bareNamesPipe(t, n.Pipe)
+	}
+	return nil
+}
+
+func bareNamesBranch(t *parse.Tree, b *parse.BranchNode) error {
+	if err := bareNamesPipe(t, b.Pipe); err != nil {
+		return err
+	}
+	if err := bareNames(t, b.List); err != nil {
+		return err
+	}
+	return bareNames(t, b.ElseList)
+}
+
+func bareNamesPipe(t *parse.Tree, p *parse.PipeNode) error {
+	if p == nil {
+		return nil
+	}
+	for i, cmd := range p.Cmds {
+		for j, arg := range cmd.Args {
+			call := j == 0 && (i > 0 || len(cmd.Args) > 1)
+			rewritten, err := bareNamesArg(t, arg, call)
+			if err != nil {
+				return err
+			}
+			cmd.Args[j] = rewritten
+		}
+	}
+	defaults(p)
+	return nil
+}
+
+// defaults rewrites the references p passes to default, either as the value
+// argument or from the previous stage of the pipeline, into calls to
+// lookup, so a missing key reaches default as nil.
+func defaults(p *parse.PipeNode) {
+	for i, cmd := range p.Cmds {
+		if id, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || id.Ident != "default" {
+			continue
+		}
+		switch {
+		case len(cmd.Args) == 3:
+			if args := lookupArgs(cmd.Args[2]); args != nil {
+				cmd.Args[2] = &parse.PipeNode{
+					NodeType: parse.NodePipe,
+					Pos:      cmd.Args[2].Position(),
+					Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: cmd.Pos, Args: args}},
+				}
+			}
+		case len(cmd.Args) == 2 && i > 0 && len(p.Cmds[i-1].Args) == 1:
+			if args := lookupArgs(p.Cmds[i-1].Args[0]); args != nil {
+				p.Cmds[i-1].Args = args
+			}
+		}
+	}
+}
+
+// lookupArgs returns the arguments of the lookup call equivalent to the
+// reference ref, or nil if ref is not a reference with keys.
+func lookupArgs(ref parse.Node) []parse.Node {
+	var receiver parse.Node
+	var keys []string
+	switch n := ref.(type) {
+	case *parse.FieldNode:
+		receiver, keys = &parse.DotNode{NodeType: parse.NodeDot, Pos: n.Pos}, n.Ident
+	case *parse.VariableNode:
+		if len(n.Ident) < 2 {
+			return nil
+		}
+		receiver = &parse.VariableNode{NodeType: parse.NodeVariable, Pos: n.Pos, Ident: n.Ident[:1]}
+		keys = n.Ident[1:]
+	case *parse.ChainNode:
+		receiver, keys = n.Node, n.Field
+	default:
+		return nil
+	}
+
+	args := []parse.Node{parse.NewIdentifier(lookupFunc).SetPos(ref.Position()), receiver}
+	for _, key := range keys {
+		args = append(args, &parse.StringNode{
+			NodeType: parse.NodeString,
+			Pos:      ref.Position(),
+			Quoted:   strconv.Quote(key),
+			Text:     key,
+		})
+	}
+	return args
+}
+
+// bareNamesArg returns arg with any bare variable name replaced by a field
+// reference. call reports whether arg is in a position only a function can
+// take.
+func bareNamesArg(t *parse.Tree, arg parse.Node, call bool) (parse.Node, error) {
+	switch n := arg.(type) {
+	case *parse.IdentifierNode:
+		switch {
+		case isFunc(n.Ident):
+			return n, nil
+		case call:
+			location, _ := t.ErrorContext(n)
+			return nil, fmt.Errorf("template: %s: function %q not defined", location, n.Ident)
+		}
+		return field(n, nil), nil
+	case *parse.ChainNode:
+		switch base := n.Node.(type) {
+		case *parse.IdentifierNode:
+			if !isFunc(base.Ident) {
+				return field(base, n.Field), nil
+			}
+		case *parse.PipeNode:
+			if err := bareNamesPipe(t, base); err != nil {
+				return nil, err
+			}
+		}
+	case *parse.PipeNode:
+		if err := bareNamesPipe(t, n); err != nil {
+			return nil, err
+		}
+	}
+	return arg, nil
+}
+
+// field returns the field reference .name.rest... for the bare name id.
+func field(id *parse.IdentifierNode, rest []string) *parse.FieldNode {
+	return &parse.FieldNode{
+		NodeType: parse.NodeField,
+		Pos:      id.Pos,
+		Ident:    append([]string{id.Ident}, rest...),
+	}
+}
@@ -0,0 +1,183 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"repo":    map[string]any{"name": "canvas"},
+		"answers": map[string]any{"style": "conventional"},
+	}
+	got, err := Render("main.md", "{{.repo.name}} uses {{.answers.style}} commits", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "canvas uses conventional commits" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRenderJinjaReferences(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"repo":    map[string]any{"name": "my-api", "primary_language": "python"},
+		"answers": map[string]any{"style": "conventional"},
+		"lang":    "go",
+		"items":   []string{"a", "b"},
+	}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "documented form",
+			text: "You are helping with the **{{ repo.name }}** repository.",
+			want: "You are helping with the **my-api** repository.",
+		},
+		{name: "top level", text: "- {{ lang }}", want: "- go"},
+		{name: "pipeline", text: "{{ answers.style | upper }}", want: "CONVENTIONAL"},
+		{name: "trim markers", text: "a {{- repo.primary_language -}} b", want: "apythonb"},
+		{name: "dotted form unchanged", text: "{{ .repo.name }}", want: "my-api"},
+		{name: "function untouched", text: `{{ lower "MiXeD" }}`, want: "mixed"},
+		{name: "keyword untouched", text: "{{ if .lang }}yes{{ end }}", want: "yes"},
+		{name: "condition", text: `{{ if eq answers.style "conventional" }}yes{{ end }}`, want: "yes"},
+		{name: "argument", text: `{{ replace "-" "_" repo.name }}`, want: "my_api"},
+		{name: "range", text: "{{ range items }}[{{ . }}]{{ end }}", want: "[a][b]"},
+		{name: "parenthesized", text: "{{ (repo).name }}", want: "my-api"},
+		{name: "with", text: "{{ with repo }}{{ .name }}{{ end }}", want: "my-api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Render("main.md", tt.text, data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, err := Render("main.md", "{{ repo.ci_system }}", data)
+	var missing *MissingVarError
+	if !errors.As(err, &missing) || missing.Name != "ci_system" {
+		t.Errorf("err = %v, want MissingVarError for ci_system", err)
+	}
+}
+
+func TestRenderSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	_, err := Render("broken.md", "Hello {{.name", nil)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("err = %v (%T), want *SyntaxError", err, err)
+	}
+	if syntaxErr.Name != "broken.md" {
+		t.Errorf("Name = %q, want broken.md", syntaxErr.Name)
+	}
+	var missing *MissingVarError
+	if errors.As(err, &missing) {
+		t.Error("a syntax error should not be a MissingVarError")
+	}
+}
+
+func TestRenderMissingVar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "top level", text: "Hello {{.user}}", want: "user"},
+		{name: "nested", text: "{{.answers.scope_prefix}}", want: "scope_prefix"},
+		{name: "not passed to default", text: `{{.answers.scope_prefix | upper | default "x"}}`, want: "scope_prefix"},
+		{name: "default fallback", text: `{{.answers.style | default .answers.fallback}}`, want: "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Render("t.md", tt.text, map[string]any{"answers": map[string]any{}})
+			var missing *MissingVarError
+			if !errors.As(err, &missing) {
+				t.Fatalf("err = %v (%T), want *MissingVarError", err, err)
+			}
+			if missing.Name != tt.want || missing.Template != "t.md" {
+				t.Errorf("got %+v, want Name %q in t.md", missing, tt.want)
+			}
+			var syntaxErr *SyntaxError
+			if errors.As(err, &syntaxErr) {
+				t.Error("a missing variable should not be a SyntaxError")
+			}
+		})
+	}
+}
+
+func TestRenderFuncs(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"name":  "  Canvas Tools ",
+		"style": "",
+		"path":  "a/b/c",
+		"on":    false,
+	}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "lower", text: `{{lower "MiXeD"}}`, want: "mixed"},
+		{name: "upper", text: `{{.path | upper}}`, want: "A/B/C"},
+		{name: "trim", text: `[{{trim .name}}]`, want: "[Canvas Tools]"},
+		{name: "replace", text: `{{.path | replace "/" "."}}`, want: "a.b.c"},
+		{name: "default empty string", text: `{{.style | default "conventional"}}`, want: "conventional"},
+		{name: "default false", text: `{{.on | default "yes"}}`, want: "yes"},
+		{name: "default set value", text: `{{.path | default "x"}}`, want: "a/b/c"},
+		{name: "default missing key", text: `{{.style_name | default "conventional"}}`, want: "conventional"},
+		{name: "default missing parent", text: `{{.answers.style | default "conventional"}}`, want: "conventional"},
+		{name: "default missing argument", text: `{{default "conventional" .answers.style}}`, want: "conventional"},
+		{name: "default missing bare name", text: `{{ answers.style | default "conventional" | upper }}`, want: "CONVENTIONAL"},
+		{name: "default missing variable", text: `{{with .path}}{{default "x" $.answers.style}}{{end}}`, want: "x"},
+		{name: "chained", text: `{{.name | trim | lower | replace " " "-"}}`, want: "canvas-tools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Render("t.md", tt.text, data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderUnknownFunc(t *testing.T) {
+	t.Parallel()
+
+	_, err := Render("t.md", `{{.name | title}}`, map[string]any{"name": "x"})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("err = %v (%T), want *SyntaxError", err, err)
+	}
+	if !strings.Contains(err.Error(), `function "title" not defined`) {
+		t.Errorf("error should name the unknown function: %v", err)
+	}
+
+	_, err = Render("t.md", `{{ title "x" }}`, nil)
+	if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), `function "title" not defined`) {
+		t.Errorf("a bare name called with arguments should be an unknown function: %v", err)
+	}
+}